@@ -12,6 +12,7 @@ const REASON_MAP = {
     'too-complex': 'Too Complex',
     offensive: 'Offensive',
     'hidden-absent': 'Hidden bc Absent',
+    back: 'Marked Back',
 } as any;
 
 interface FlagsPopupProps {
@@ -102,7 +102,18 @@ const JudgeRow = ({ judge, idx }: JudgeRowProps) => {
                 {options.multi_group && selectedTrack === '' && (
                     <td className="text-center">{judge.group}</td>
                 )}
-                <td className="text-center">{judge.seen}</td>
+                <td className="text-center">
+                    {judge.seen}
+                    {judge.progress?.quota > 0 && ` / ${judge.progress.quota}`}
+                    {judge.progress?.budget > 0 && (
+                        <div className="text-light text-sm">
+                            {judge.progress.minutes} / {judge.progress.budget} min
+                        </div>
+                    )}
+                    {judge.progress?.reached && (
+                        <div className="text-error text-sm">Wrapping up</div>
+                    )}
+                </td>
                 <td className="text-center">{idToProj(judge.current)}</td>
                 <td className="text-center">{timeSince(judge.last_activity)}</td>
                 <td className="text-right font-bold flex align-center justify-end">
@@ -20,13 +20,14 @@ import TextInput from '../../components/TextInput';
 import { Helmet } from 'react-helmet';
 import { useGroupInfoStore } from '../../store';
 
-const infoPages = ['paused', 'hidden', 'no-projects', 'done', 'doneTrack'];
+const infoPages = ['paused', 'hidden', 'no-projects', 'done', 'doneTrack', 'wrap-up'];
 const infoData = [
     data.judgeInfo.paused,
     data.judgeInfo.hidden,
     data.judgeInfo.noProjects,
     data.judgeInfo.done,
     data.judgeInfo.doneTrack,
+    data.judgeInfo.wrapUp,
 ];
 
 const audio = new Audio(alarm);
@@ -153,6 +154,12 @@ const JudgeLive = () => {
             return;
         }
 
+        // The judge has reached their quota or time budget, so they should rank their projects
+        if (newProject.data?.wrap_up) {
+            setInfoPage('wrap-up');
+            return;
+        }
+
         // No project has been returned (all projects have been judged)
         if (!newProject.data?.project_id) {
             setInfoPage(judge?.track === '' ? 'done' : 'doneTrack');
@@ -173,6 +180,18 @@ const JudgeLive = () => {
         getJudgeData();
     }, [verified]);
 
+    // Renew the lease on the current project every minute, so it isn't
+    // released to other judges while this judge is still judging it
+    useEffect(() => {
+        if (!judge?.current) return;
+
+        const interval = setInterval(() => {
+            postRequest<OkResponse>('/judge/heartbeat', 'judge', null);
+        }, 60000);
+
+        return () => clearInterval(interval);
+    }, [judge?.current]);
+
     // Timer logic
     useEffect(() => {
         if (timerStart === 0 || time === 0 || totalJudgingTime === 0) {
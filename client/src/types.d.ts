@@ -14,6 +14,7 @@ interface Project {
     score: number;
     stars: number;
     track_stars: { [track: string]: number };
+    track_scores: { [track: string]: number };
     group: number;
     last_activity: number;
 }
@@ -45,9 +46,18 @@ interface Judge {
     group: number;
     current: string;
     flagged: string[];
+    progress: QuotaProgress;
     last_activity: number;
 }
 
+interface QuotaProgress {
+    seen: number;
+    quota: number;
+    minutes: number;
+    budget: number;
+    reached: boolean;
+}
+
 interface Stats {
     projects: number;
     avg_project_seen: number;
@@ -152,6 +162,7 @@ interface Timer {
 
 interface NextJudgeProject {
     project_id: string;
+    wrap_up?: boolean;
 }
 
 interface ScoredItem {
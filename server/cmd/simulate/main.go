@@ -0,0 +1,115 @@
+// Simulate runs synthetic judges and projects through the judging flow to test settings
+// like min views, groups, and the assignment strategy before an event, without real judges.
+//
+// The simulated event is kept in memory, so no database is needed and real data is never touched.
+//
+//	go run ./cmd/simulate -projects 120 -judges 40 -views 8 -min-views 3
+//	go run ./cmd/simulate -multi-group -groups 3 -group-sizes 40,40 -auto-switch-prop 0.2
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"server/judging"
+	"server/models"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func main() {
+	op := models.NewOptions()
+	cfg := &judging.SimulationConfig{}
+
+	// Synthetic event
+	flag.IntVar(&cfg.Projects, "projects", 100, "number of projects")
+	flag.IntVar(&cfg.Judges, "judges", 30, "number of judges")
+	flag.IntVar(&cfg.Views, "views", 10, "number of projects each judge judges")
+	flag.Float64Var(&cfg.Noise, "noise", 0.5, "standard deviation of judges' errors (project quality is standard normal)")
+	flag.IntVar(&cfg.TopN, "top", 10, "number of top places to compare between the true and recovered rankings")
+	flag.Int64Var(&cfg.Seed, "seed", time.Now().UnixNano(), "seed for the synthetic projects and judges")
+
+	// Options to test
+	flag.Int64Var(&op.MinViews, "min-views", op.MinViews, "min views option")
+	flag.StringVar(&op.RankingMethod, "ranking-method", op.RankingMethod, "ranking method option")
+	flag.StringVar(&op.AssignStrategy, "strategy", op.AssignStrategy, "assignment strategy option")
+	flag.BoolVar(&op.AdaptiveAssign, "adaptive", op.AdaptiveAssign, "adaptive assignment option")
+	flag.Int64Var(&op.AdaptiveTopN, "adaptive-top-n", op.AdaptiveTopN, "adaptive top n option")
+	flag.BoolVar(&op.MultiGroup, "multi-group", op.MultiGroup, "multi group option")
+	flag.Int64Var(&op.NumGroups, "groups", op.NumGroups, "number of groups option")
+	groupSizes := flag.String("group-sizes", "", "comma-separated group sizes option (all groups except the last)")
+	flag.StringVar(&op.SwitchingMode, "switching-mode", op.SwitchingMode, "group switching mode option (auto or manual)")
+	flag.Float64Var(&op.AutoSwitchProp, "auto-switch-prop", op.AutoSwitchProp, "auto switch proportion option")
+	asJson := flag.Bool("json", false, "print the report as JSON")
+	flag.Parse()
+
+	// Make sure the settings are valid
+	if cfg.Projects < 1 || cfg.Judges < 1 || cfg.Views < 1 {
+		log.Fatalln("projects, judges, and views must be at least 1")
+	}
+	if !judging.IsValidRankingMethod(op.RankingMethod) {
+		log.Fatalf("invalid ranking method: %s\n", op.RankingMethod)
+	}
+	if !judging.IsValidAssignmentStrategy(op.AssignStrategy) {
+		log.Fatalf("invalid assignment strategy: %s\n", op.AssignStrategy)
+	}
+	if op.MultiGroup && op.NumGroups < 1 {
+		log.Fatalln("there must be at least 1 group")
+	}
+	if *groupSizes != "" {
+		op.GroupSizes = []int64{}
+		for _, s := range strings.Split(*groupSizes, ",") {
+			size, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+			if err != nil || size < 1 {
+				log.Fatalf("invalid group size: %s\n", s)
+			}
+			op.GroupSizes = append(op.GroupSizes, size)
+		}
+	}
+
+	// Run the simulation
+	report, err := judging.Simulate(context.Background(), op, cfg)
+	if err != nil {
+		log.Fatalf("error running simulation: %s\n", err.Error())
+	}
+
+	// Print the report
+	if *asJson {
+		out, _ := json.MarshalIndent(report, "", "  ")
+		fmt.Println(string(out))
+		return
+	}
+	printReport(os.Stdout, report, op, cfg)
+}
+
+// printReport prints a readable summary of the simulation
+func printReport(out io.Writer, r *judging.SimulationReport, op *models.Options, cfg *judging.SimulationConfig) {
+	fmt.Fprintf(out, "Simulated %d judges judging %d projects each out of %d projects (seed %d)\n\n", r.Judges, cfg.Views, r.Projects, cfg.Seed)
+
+	fmt.Fprintf(out, "Views\n")
+	fmt.Fprintf(out, "  total %d, min %d, max %d, mean %.2f\n", r.TotalViews, r.MinViews, r.MaxViews, r.MeanViews)
+	fmt.Fprintf(out, "  unseen projects: %d\n", r.Unseen)
+	fmt.Fprintf(out, "  projects below min views (%d): %d\n", op.MinViews, r.BelowMin)
+	views := make([]int64, 0, len(r.ViewCounts))
+	for v := range r.ViewCounts {
+		views = append(views, v)
+	}
+	slices.Sort(views)
+	for _, v := range views {
+		fmt.Fprintf(out, "  %3d views: %s %d\n", v, strings.Repeat("#", min(r.ViewCounts[v], 60)), r.ViewCounts[v])
+	}
+
+	fmt.Fprintf(out, "\nComparisons\n")
+	fmt.Fprintf(out, "  pairs compared: %.1f%%\n", r.PairCoverage*100)
+	fmt.Fprintf(out, "  projects in the largest connected part: %.1f%%\n", r.Connected*100)
+
+	fmt.Fprintf(out, "\nRanking recovery (%s)\n", op.RankingMethod)
+	fmt.Fprintf(out, "  spearman correlation with the true ranking: %.3f\n", r.Spearman)
+	fmt.Fprintf(out, "  true top %d found in the recovered top %d: %d\n", r.TopN, r.TopN, r.TopOverlap)
+}
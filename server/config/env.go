@@ -51,3 +51,8 @@ func GetOptEnv(key string, defaultVal string) string {
 	}
 	return val
 }
+
+// MultiInstance returns true if several instances of the server share the database (JURY_MULTI_INSTANCE=true)
+func MultiInstance() bool {
+	return GetOptEnv("JURY_MULTI_INSTANCE", "false") == "true"
+}
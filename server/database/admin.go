@@ -105,7 +105,7 @@ func AggregateStats(db *mongo.Database, track string) (*models.Stats, error) {
 // DropAll drops the entire database
 func DropAll(db *mongo.Database) error {
 	// Drop all collections
-	var collections = []string{"projects", "judges", "flags", "options", "logs"}
+	var collections = []string{"projects", "judges", "flags", "options", "logs", "snapshots", "versions", "conflicts", "rounds", "past_flags", "past_comparisons", "comparisons", "leader", "rate_limits"}
 	for _, c := range collections {
 		if err := db.Collection(c).Drop(context.Background()); err != nil {
 			return err
@@ -119,12 +119,28 @@ func DropAll(db *mongo.Database) error {
 func DropProjects(db *mongo.Database) error {
 	fmt.Println("??????")
 	err := db.Collection("projects").Drop(context.Background())
+	if err != nil {
+		return err
+	}
+
+	// Conflicts are tied to projects, so remove them too
+	err = db.Collection("conflicts").Drop(context.Background())
+	if err != nil {
+		return err
+	}
+	_, err = db.Collection("judges").UpdateMany(context.Background(), gin.H{}, gin.H{"$set": gin.H{"conflicts": []primitive.ObjectID{}}})
 	return err
 }
 
 // DropJudges removes all judges
 func DropJudges(db *mongo.Database) error {
 	err := db.Collection("judges").Drop(context.Background())
+	if err != nil {
+		return err
+	}
+
+	// Conflicts are tied to judges, so remove them too
+	err = db.Collection("conflicts").Drop(context.Background())
 	return err
 }
 
@@ -142,14 +158,31 @@ func DropJudgingData(db *mongo.Database) error {
 			"read_welcome":  false,
 			"seen_projects": []models.JudgedProject{},
 			"rankings":      []primitive.ObjectID{},
+			"ranking_tiers": [][]primitive.ObjectID{},
 			"rankings_agg":  []models.AggRanking{},
 			"flagged":       []primitive.ObjectID{},
+			"calibration":   models.JudgeCalibration{},
+			"round":         0,
+			"past_rounds":   []models.JudgeRound{},
+			"schedule":      []models.ScheduleStop{},
+			"started":       primitive.DateTime(0),
+			"started_clock": 0,
 		}},
 	)
 	if err != nil {
 		return err
 	}
 
+	// Reset automatic judge weights, keeping the ones set by an admin
+	_, err = db.Collection("judges").UpdateMany(
+		context.Background(),
+		gin.H{"weight_manual": gin.H{"$ne": true}},
+		gin.H{"$set": gin.H{"weight": 1}},
+	)
+	if err != nil {
+		return err
+	}
+
 	_, err = db.Collection("projects").UpdateMany(
 		context.Background(),
 		gin.H{},
@@ -158,6 +191,7 @@ func DropJudgingData(db *mongo.Database) error {
 			"seen":        0,
 			"track_seen":  map[string]int64{},
 			"prioritized": false,
+			"round":       1,
 		}},
 	)
 	if err != nil {
@@ -169,6 +203,26 @@ func DropJudgingData(db *mongo.Database) error {
 		return err
 	}
 
+	err = db.Collection("rounds").Drop(context.Background())
+	if err != nil {
+		return err
+	}
+
+	err = db.Collection("past_flags").Drop(context.Background())
+	if err != nil {
+		return err
+	}
+
+	err = db.Collection("past_comparisons").Drop(context.Background())
+	if err != nil {
+		return err
+	}
+
+	err = db.Collection("comparisons").Drop(context.Background())
+	if err != nil {
+		return err
+	}
+
 	_, err = db.Collection("options").UpdateOne(
 		context.Background(),
 		gin.H{"ref": 0},
@@ -178,6 +232,7 @@ func DropJudgingData(db *mongo.Database) error {
 			"manual_switches": 0,
 			"qr_code":         "",
 			"track_qr_codes":  make(map[string]string),
+			"round":           1,
 		}},
 	)
 	return err
@@ -190,6 +245,7 @@ func DropRankings(db *mongo.Database) error {
 		gin.H{},
 		gin.H{"$set": gin.H{
 			"rankings":                  []primitive.ObjectID{},
+			"ranking_tiers":             [][]primitive.ObjectID{},
 			"rankings_agg":              []models.AggRanking{},
 			"seen_projects.$[].starred": false,
 		}},
@@ -0,0 +1,107 @@
+package database
+
+import (
+	"context"
+	"server/models"
+	"server/util"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// FindAllComparisons returns every pair of projects that has been compared
+func FindAllComparisons(db *mongo.Database, ctx context.Context) ([]*models.Comparison, error) {
+	return FindComparisonsUpdatedSince(db, ctx, 0)
+}
+
+// FindComparisonsUpdatedSince returns every pair of projects whose count changed at or after the given time,
+// including the pairs that are no longer compared (with a count of 0)
+func FindComparisonsUpdatedSince(db *mongo.Database, ctx context.Context, since primitive.DateTime) ([]*models.Comparison, error) {
+	comparisons := make([]*models.Comparison, 0)
+	cursor, err := db.Collection("comparisons").Find(ctx, gin.H{"updated": gin.H{"$gte": since}})
+	if err != nil {
+		return nil, err
+	}
+	err = cursor.All(ctx, &comparisons)
+	return comparisons, err
+}
+
+// IncrementComparisons adds the count of each comparison to the stored counts,
+// creating the pairs that haven't been compared yet. Counts never drop below 0, so taking
+// back the pairs of a project whose comparisons were already cleared (eg. deleting a judge
+// after one of the projects they saw was deleted) leaves them at 0.
+func IncrementComparisons(db *mongo.Database, ctx context.Context, comparisons []*models.Comparison) error {
+	if len(comparisons) == 0 {
+		return nil
+	}
+
+	now := util.Now()
+	var writes []mongo.WriteModel
+	for _, c := range comparisons {
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(gin.H{"_id": c.Key}).
+			SetUpdate([]gin.H{{"$set": gin.H{
+				"a":       gin.H{"$ifNull": []any{"$a", c.A}},
+				"b":       gin.H{"$ifNull": []any{"$b", c.B}},
+				"count":   gin.H{"$max": []any{0, gin.H{"$add": []any{gin.H{"$ifNull": []any{"$count", 0}}, c.Count}}}},
+				"updated": now,
+			}}}).
+			SetUpsert(true))
+	}
+	_, err := db.Collection("comparisons").BulkWrite(ctx, writes)
+	return err
+}
+
+// DeleteProjectComparisons sets the count of every comparison with a project to 0
+func DeleteProjectComparisons(db *mongo.Database, ctx context.Context, id *primitive.ObjectID) error {
+	_, err := db.Collection("comparisons").UpdateMany(
+		ctx,
+		gin.H{"$or": []gin.H{{"a": id}, {"b": id}}},
+		gin.H{"$set": gin.H{"count": 0, "updated": util.Now()}},
+	)
+	return err
+}
+
+// ReplaceComparisons replaces all stored comparisons and increments the comparisons version,
+// which tells other instances of the server to reload all of them
+func ReplaceComparisons(db *mongo.Database, ctx context.Context, comparisons []*models.Comparison) error {
+	_, err := db.Collection("comparisons").DeleteMany(ctx, gin.H{})
+	if err != nil {
+		return err
+	}
+
+	if len(comparisons) > 0 {
+		now := util.Now()
+		var docs []any
+		for _, c := range comparisons {
+			c.Updated = now
+			docs = append(docs, c)
+		}
+		_, err = db.Collection("comparisons").InsertMany(ctx, docs)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = db.Collection("versions").UpdateOne(
+		ctx,
+		gin.H{"_id": "comparisons"},
+		gin.H{"$inc": gin.H{"version": 1}},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+// GetComparisonsVersion returns the number of times the stored comparisons have been replaced
+func GetComparisonsVersion(db *mongo.Database, ctx context.Context) (int64, error) {
+	var doc struct {
+		Version int64 `bson:"version"`
+	}
+	err := db.Collection("versions").FindOne(ctx, gin.H{"_id": "comparisons"}).Decode(&doc)
+	if err == mongo.ErrNoDocuments {
+		return 0, nil
+	}
+	return doc.Version, err
+}
@@ -0,0 +1,113 @@
+package database
+
+import (
+	"context"
+	"server/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// InsertConflicts inserts conflicts into the database, skipping any judge/project pair that already has one.
+// Each conflicted project is also added to its judge's conflicts list.
+// Returns the number of new conflicts.
+func InsertConflicts(db *mongo.Database, ctx context.Context, conflicts []*models.Conflict) (int64, error) {
+	var added int64
+	for _, c := range conflicts {
+		res, err := db.Collection("conflicts").UpdateOne(
+			ctx,
+			gin.H{"judge_id": c.JudgeId, "project_id": c.ProjectId},
+			gin.H{"$setOnInsert": c},
+			options.Update().SetUpsert(true),
+		)
+		if err != nil {
+			return added, err
+		}
+		if res.UpsertedID == nil {
+			continue
+		}
+		c.Id = res.UpsertedID.(primitive.ObjectID)
+		added++
+
+		_, err = db.Collection("judges").UpdateOne(ctx, gin.H{"_id": c.JudgeId}, gin.H{"$addToSet": gin.H{"conflicts": c.ProjectId}})
+		if err != nil {
+			return added, err
+		}
+	}
+	return added, nil
+}
+
+// FindAllConflicts returns all conflicts
+func FindAllConflicts(db *mongo.Database, ctx context.Context) ([]*models.Conflict, error) {
+	conflicts := make([]*models.Conflict, 0)
+	cursor, err := db.Collection("conflicts").Find(ctx, gin.H{})
+	if err != nil {
+		return nil, err
+	}
+	err = cursor.All(ctx, &conflicts)
+	if err != nil {
+		return nil, err
+	}
+	return conflicts, nil
+}
+
+// FindConflict finds a conflict by ID.
+// Returns nil if no conflict was found.
+func FindConflict(db *mongo.Database, ctx context.Context, id *primitive.ObjectID) (*models.Conflict, error) {
+	var conflict models.Conflict
+	err := db.Collection("conflicts").FindOne(ctx, gin.H{"_id": id}).Decode(&conflict)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &conflict, nil
+}
+
+// DeleteConflict deletes a conflict and removes the project from its judge's conflicts list
+func DeleteConflict(db *mongo.Database, ctx context.Context, conflict *models.Conflict) error {
+	_, err := db.Collection("conflicts").DeleteOne(ctx, gin.H{"_id": conflict.Id})
+	if err != nil {
+		return err
+	}
+	_, err = db.Collection("judges").UpdateOne(ctx, gin.H{"_id": conflict.JudgeId}, gin.H{"$pull": gin.H{"conflicts": conflict.ProjectId}})
+	return err
+}
+
+// DeleteConflictsCascade will delete conflicts based on either the project ID and/or judge ID
+// This is used in a cascade deletion when projects/judges are deleted
+func DeleteConflictsCascade(db *mongo.Database, ctx context.Context, projectId *primitive.ObjectID, judgeId *primitive.ObjectID) error {
+	filter := make(gin.H)
+	if projectId != nil {
+		filter["project_id"] = projectId
+	}
+	if judgeId != nil {
+		filter["judge_id"] = judgeId
+	}
+
+	_, err := db.Collection("conflicts").DeleteMany(ctx, filter)
+	if err != nil {
+		return err
+	}
+
+	// Remove the deleted project from all judges' conflicts lists
+	if projectId != nil {
+		_, err = db.Collection("judges").UpdateMany(ctx, gin.H{"conflicts": projectId}, gin.H{"$pull": gin.H{"conflicts": projectId}})
+	}
+	return err
+}
+
+// SetJudgeAffiliations sets the affiliations (e.g. universities or companies) of a judge
+func SetJudgeAffiliations(db *mongo.Database, ctx context.Context, id *primitive.ObjectID, affiliations []string) error {
+	_, err := db.Collection("judges").UpdateOne(ctx, gin.H{"_id": id}, gin.H{"$set": gin.H{"affiliations": affiliations}})
+	return err
+}
+
+// SetProjectAffiliations sets the team member emails and universities of a project
+func SetProjectAffiliations(db *mongo.Database, ctx context.Context, id *primitive.ObjectID, teamEmails []string, universities []string) error {
+	_, err := db.Collection("projects").UpdateOne(ctx, gin.H{"_id": id}, gin.H{"$set": gin.H{"team_emails": teamEmails, "universities": universities}})
+	return err
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 // InsertFlag inserts a skip object into the database
@@ -43,11 +44,36 @@ func FindFlagsByJudge(db *mongo.Database, ctx context.Context, judge *models.Jud
 	return flags, nil
 }
 
-// GetProjectAbsentCount finds the number of times that a specified project has been skipped.
-func GetProjectAbsentCount(db *mongo.Database, ctx context.Context, projectId *primitive.ObjectID) (int, error) {
+// FindFlagsByProject will find all flags for a project
+func FindFlagsByProject(db *mongo.Database, ctx context.Context, projectId *primitive.ObjectID) ([]*models.Flag, error) {
+	flags := make([]*models.Flag, 0)
+	cursor, err := db.Collection("flags").Find(ctx, gin.H{"project_id": projectId})
+	if err != nil {
+		return nil, err
+	}
+	err = cursor.All(ctx, &flags)
+	if err != nil {
+		return nil, err
+	}
+	return flags, nil
+}
+
+// GetProjectAbsentCount finds the number of times that a specified project has been skipped as absent
+// since its absences were last reset (when it was hidden or marked back).
+// Absences during the project's away windows don't count, since the team was away for a known reason.
+func GetProjectAbsentCount(db *mongo.Database, ctx context.Context, project *models.Project) (int, error) {
+	match := gin.H{"project_id": project.Id, "reason": "absent", "time": gin.H{"$gt": project.AbsentReset}}
+	if len(project.Away) > 0 {
+		away := make([]gin.H, len(project.Away))
+		for i, w := range project.Away {
+			away[i] = gin.H{"time": gin.H{"$gte": w.Start, "$lt": w.End}}
+		}
+		match["$nor"] = away
+	}
+
 	// Use an aggregation pipeline to count projects with the reason "absent"
 	cursor, err := db.Collection("flags").Aggregate(ctx, []gin.H{
-		{"$match": gin.H{"project_id": projectId, "reason": "absent"}},
+		{"$match": match},
 		{"$count": "absent_count"},
 	})
 	if err != nil {
@@ -68,10 +94,20 @@ func GetProjectAbsentCount(db *mongo.Database, ctx context.Context, projectId *p
 	return result.AbsentCount, nil
 }
 
-// DeleteAbsentFlags deletes all flags with the reason "absent" for a given project ID
-func DeleteAbsentFlags(db *mongo.Database, ctx context.Context, projectId *primitive.ObjectID) error {
-	_, err := db.Collection("flags").DeleteMany(ctx, gin.H{"project_id": projectId, "reason": "absent"})
-	return err
+// FindAbsenceFlags returns the absence history of a project: every time it was marked absent,
+// hidden for being absent, and marked back, oldest first
+func FindAbsenceFlags(db *mongo.Database, ctx context.Context, projectId *primitive.ObjectID) ([]*models.Flag, error) {
+	flags := make([]*models.Flag, 0)
+	cursor, err := db.Collection("flags").Find(
+		ctx,
+		gin.H{"project_id": projectId, "reason": gin.H{"$in": models.AbsenceReasons}},
+		options.Find().SetSort(gin.H{"time": 1}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	err = cursor.All(ctx, &flags)
+	return flags, err
 }
 
 // DeleteFlag deletes a flag from the database
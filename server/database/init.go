@@ -11,9 +11,14 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-// InitDb initializes the database connection to MongoDB.
+// InitDb initializes the database connection to MongoDB and returns the "jury" database.
 // This will proactively panic if any step of the connection protocol breaks
 func InitDb() *mongo.Database {
+	return InitClient().Database("jury")
+}
+
+// InitClient connects to MongoDB, panicking if any step of the connection protocol breaks
+func InitClient() *mongo.Client {
 	// Use the SetServerAPIOptions() method to set the Stable API version to 1
 	serverAPI := options.ServerAPI(options.ServerAPIVersion1)
 	opts := options.Client().ApplyURI(config.GetEnv("MONGODB_URI")).SetServerAPIOptions(serverAPI)
@@ -31,6 +36,5 @@ func InitDb() *mongo.Database {
 	}
 	fmt.Println("Successfully connected to database!")
 
-	// Return the "jury" database
-	return client.Database("jury")
+	return client
 }
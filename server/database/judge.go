@@ -101,6 +101,69 @@ func UpdateJudgeGroups(db *mongo.Database, sc mongo.SessionContext, judges []*mo
 	return err
 }
 
+// SetJudgeSchedules replaces the itineraries of all judges.
+// Judges that aren't in the map get an empty itinerary.
+func SetJudgeSchedules(db *mongo.Database, ctx context.Context, schedules map[primitive.ObjectID][]models.ScheduleStop) error {
+	err := ClearJudgeSchedules(db, ctx)
+	if err != nil || len(schedules) == 0 {
+		return err
+	}
+
+	models := make([]mongo.WriteModel, 0, len(schedules))
+	for id, stops := range schedules {
+		models = append(models, mongo.NewUpdateOneModel().SetFilter(gin.H{"_id": id}).SetUpdate(gin.H{"$set": gin.H{"schedule": stops}}))
+	}
+	opts := options.BulkWrite().SetOrdered(false)
+	_, err = db.Collection("judges").BulkWrite(ctx, models, opts)
+	return err
+}
+
+// ClearJudgeSchedules removes the itineraries of all judges
+func ClearJudgeSchedules(db *mongo.Database, ctx context.Context) error {
+	_, err := db.Collection("judges").UpdateMany(ctx, gin.H{}, gin.H{"$set": gin.H{"schedule": []models.ScheduleStop{}}})
+	return err
+}
+
+// FindJudgesWithCurrent returns all judges that are currently holding a project
+func FindJudgesWithCurrent(db *mongo.Database, ctx context.Context) ([]*models.Judge, error) {
+	judges := make([]*models.Judge, 0)
+	cursor, err := db.Collection("judges").Find(ctx, gin.H{"current": gin.H{"$ne": nil}})
+	if err != nil {
+		return nil, err
+	}
+	err = cursor.All(ctx, &judges)
+	if err != nil {
+		return nil, err
+	}
+	return judges, nil
+}
+
+// RenewJudgeLease extends the lease on the judge's current project
+func RenewJudgeLease(db *mongo.Database, ctx context.Context, judgeId *primitive.ObjectID, leaseExpiry primitive.DateTime) error {
+	_, err := db.Collection("judges").UpdateOne(ctx, gin.H{"_id": judgeId, "current": gin.H{"$ne": nil}}, gin.H{"$set": gin.H{"lease_expiry": leaseExpiry}})
+	return err
+}
+
+// RenewAllJudgeLeases extends the leases of every judge that has a current project
+func RenewAllJudgeLeases(db *mongo.Database, ctx context.Context, leaseExpiry primitive.DateTime) error {
+	_, err := db.Collection("judges").UpdateMany(ctx, gin.H{"current": gin.H{"$ne": nil}}, gin.H{"$set": gin.H{"lease_expiry": leaseExpiry}})
+	return err
+}
+
+// ReleaseJudgeLease clears the judge's current project, as long as they are still holding the same
+// project under the same lease. Returns false if the judge has moved on or renewed the lease since.
+func ReleaseJudgeLease(db *mongo.Database, ctx context.Context, judge *models.Judge) (bool, error) {
+	filter := gin.H{"_id": judge.Id, "current": judge.Current, "lease_expiry": judge.LeaseExpiry}
+	if judge.LeaseExpiry == 0 {
+		filter["lease_expiry"] = gin.H{"$in": []any{primitive.DateTime(0), nil}}
+	}
+	res, err := db.Collection("judges").UpdateOne(ctx, filter, gin.H{"$set": gin.H{"current": nil}})
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount == 1, nil
+}
+
 // FindAllJudges returns a list of all judges in the database
 func FindAllJudges(db *mongo.Database, ctx context.Context) ([]*models.Judge, error) {
 	judges := make([]*models.Judge, 0)
@@ -231,12 +294,24 @@ func UpdateJudgeBasicInfo(db *mongo.Database, judgeId *primitive.ObjectID, addRe
 	return err
 }
 
-// UpdateJudgeRanking updates the judge's ranking array
-func UpdateJudgeRanking(db *mongo.Database, ctx context.Context, id primitive.ObjectID, rankings []primitive.ObjectID, rankingsAgg []models.AggRanking) error {
+// SetJudgeTags sets the expertise tags of a judge
+func SetJudgeTags(db *mongo.Database, ctx context.Context, id *primitive.ObjectID, tags []string) error {
+	_, err := db.Collection("judges").UpdateOne(ctx, gin.H{"_id": id}, gin.H{"$set": gin.H{"tags": tags}})
+	return err
+}
+
+// SetJudgeQuota sets the maximum number of projects and the time budget of a judge
+func SetJudgeQuota(db *mongo.Database, ctx context.Context, id *primitive.ObjectID, quota int64, timeBudget int64) error {
+	_, err := db.Collection("judges").UpdateOne(ctx, gin.H{"_id": id}, gin.H{"$set": gin.H{"quota": quota, "time_budget": timeBudget}})
+	return err
+}
+
+// UpdateJudgeRanking updates the judge's ranking array and ranking tiers
+func UpdateJudgeRanking(db *mongo.Database, ctx context.Context, id primitive.ObjectID, rankings []primitive.ObjectID, tiers [][]primitive.ObjectID, rankingsAgg []models.AggRanking) error {
 	_, err := db.Collection("judges").UpdateOne(
 		ctx,
 		gin.H{"_id": id},
-		gin.H{"$set": gin.H{"rankings": rankings, "rankings_agg": rankingsAgg}},
+		gin.H{"$set": gin.H{"rankings": rankings, "ranking_tiers": tiers, "rankings_agg": rankingsAgg}},
 	)
 	return err
 }
@@ -430,6 +505,12 @@ func SetJudgesGroup(db *mongo.Database, ctx context.Context, judgeIds []primitiv
 	return err
 }
 
+// SetJudgeWeight sets the weight of a judge and whether it was set manually
+func SetJudgeWeight(db *mongo.Database, ctx context.Context, judgeId *primitive.ObjectID, weight float64, manual bool) error {
+	_, err := db.Collection("judges").UpdateOne(ctx, gin.H{"_id": judgeId}, gin.H{"$set": gin.H{"weight": weight, "weight_manual": manual}})
+	return err
+}
+
 // UpdateSeenProjectNumber will change the table number for all instances of a seen project
 func UpdateSeenProjectNumber(db *mongo.Database, ctx context.Context, projectId *primitive.ObjectID, newLocation int64) error {
 	_, err := db.Collection("judges").UpdateMany(
@@ -443,6 +524,25 @@ func UpdateSeenProjectNumber(db *mongo.Database, ctx context.Context, projectId
 	return err
 }
 
+// UpdateScheduledProjectNumber will change the table number of a project on every itinerary it is on
+func UpdateScheduledProjectNumber(db *mongo.Database, ctx context.Context, projectId *primitive.ObjectID, newLocation int64) error {
+	_, err := db.Collection("judges").UpdateMany(
+		ctx,
+		gin.H{"schedule.project_id": projectId},
+		gin.H{"$set": gin.H{"schedule.$[elem].location": newLocation}},
+		options.Update().SetArrayFilters(options.ArrayFilters{
+			Filters: []any{gin.H{"elem.project_id": projectId}},
+		}),
+	)
+	return err
+}
+
+// DeleteScheduledProject removes a project from every itinerary it is on
+func DeleteScheduledProject(db *mongo.Database, ctx context.Context, projectId *primitive.ObjectID) error {
+	_, err := db.Collection("judges").UpdateMany(ctx, gin.H{"schedule.project_id": projectId}, gin.H{"$pull": gin.H{"schedule": gin.H{"project_id": projectId}}})
+	return err
+}
+
 // DeleteSeenProject will delete all instances of a given project from both judge seen and judge ranking arrays
 // This will also decrement the seen count for those judges by 1
 func DeleteSeenProject(db *mongo.Database, ctx context.Context, projectId *primitive.ObjectID) error {
@@ -451,6 +551,16 @@ func DeleteSeenProject(db *mongo.Database, ctx context.Context, projectId *primi
 		gin.H{"seen_projects.project_id": projectId},
 		gin.H{"$pull": gin.H{"seen_projects": gin.H{"project_id": projectId}, "rankings": projectId}, "$inc": gin.H{"seen": -1}},
 	)
+	if err != nil {
+		return err
+	}
+
+	// Remove the project from any ranking tiers it is in (empty tiers are ignored when scoring)
+	_, err = db.Collection("judges").UpdateMany(
+		ctx,
+		gin.H{"ranking_tiers": gin.H{"$elemMatch": gin.H{"$elemMatch": gin.H{"$eq": projectId}}}},
+		gin.H{"$pull": gin.H{"ranking_tiers.$[]": projectId}},
+	)
 	return err
 }
 
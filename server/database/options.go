@@ -23,6 +23,12 @@ func GetOptions(db *mongo.Database, ctx context.Context) (*models.Options, error
 	return &options, err
 }
 
+// InsertOptions inserts the options into a database that doesn't have any yet
+func InsertOptions(db *mongo.Database, ctx context.Context, options *models.Options) error {
+	_, err := db.Collection("options").InsertOne(ctx, options)
+	return err
+}
+
 // UpdateOptions updates the options in the database
 func UpdateOptions(db *mongo.Database, ctx context.Context, options *models.OptionalOptions) error {
 	update := gin.H{}
@@ -30,6 +36,9 @@ func UpdateOptions(db *mongo.Database, ctx context.Context, options *models.Opti
 	if options.JudgingTimer != nil {
 		update["judging_timer"] = *options.JudgingTimer
 	}
+	if options.LeaseGrace != nil {
+		update["lease_grace"] = *options.LeaseGrace
+	}
 	if options.MinViews != nil {
 		update["min_views"] = *options.MinViews
 	}
@@ -75,6 +84,42 @@ func UpdateOptions(db *mongo.Database, ctx context.Context, options *models.Opti
 	if options.BlockReqs != nil {
 		update["block_reqs"] = *options.BlockReqs
 	}
+	if options.RankingMethod != nil {
+		update["ranking_method"] = *options.RankingMethod
+	}
+	if options.AdaptiveAssign != nil {
+		update["adaptive_assign"] = *options.AdaptiveAssign
+	}
+	if options.AdaptiveTopN != nil {
+		update["adaptive_top_n"] = *options.AdaptiveTopN
+	}
+	if options.AssignStrategy != nil {
+		update["assign_strategy"] = *options.AssignStrategy
+	}
+	if options.FloorPlan != nil {
+		update["floor_plan"] = *options.FloorPlan
+	}
+	if options.Criteria != nil {
+		update["criteria"] = *options.Criteria
+	}
+	if options.ScoreFormula != nil {
+		update["score_formula"] = *options.ScoreFormula
+	}
+	if options.PublishResults != nil {
+		update["publish_results"] = *options.PublishResults
+	}
+	if options.ScheduleMode != nil {
+		update["schedule_mode"] = *options.ScheduleMode
+	}
+	if options.TagWeight != nil {
+		update["tag_weight"] = *options.TagWeight
+	}
+	if options.TagQuestion != nil {
+		update["tag_question"] = *options.TagQuestion
+	}
+	if options.AbsentLimit != nil {
+		update["absent_limit"] = *options.AbsentLimit
+	}
 
 	_, err := db.Collection("options").UpdateOne(ctx, gin.H{}, gin.H{"$set": update})
 	return err
@@ -97,6 +142,21 @@ func UpdateClockConditional(db *mongo.Database, ctx context.Context, clock *mode
 	return err
 }
 
+// UpdateClockIfVersion saves the clock only if the stored clock is still at the given version.
+// Returns false if the clock was changed first (ie. by another instance of the server).
+func UpdateClockIfVersion(db *mongo.Database, ctx context.Context, clock *models.ClockState, version int64) (bool, error) {
+	filter := gin.H{"clock.version": version}
+	if version == 0 {
+		filter = gin.H{"$or": []gin.H{{"clock.version": 0}, {"clock.version": gin.H{"$exists": false}}}}
+	}
+
+	res, err := db.Collection("options").UpdateOne(ctx, filter, gin.H{"$set": gin.H{"clock": clock}})
+	if err != nil {
+		return false, err
+	}
+	return res.MatchedCount > 0, nil
+}
+
 // UpdateClock updates the clock in the database
 func UpdateClock(db *mongo.Database, clock *models.ClockState) error {
 	_, err := db.Collection("options").UpdateOne(context.Background(), gin.H{}, gin.H{"$set": gin.H{"clock": clock}})
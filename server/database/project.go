@@ -203,8 +203,9 @@ func FindProject(db *mongo.Database, ctx context.Context, id *primitive.ObjectID
 }
 
 // UpdateAfterPicked updates the seen value of the new project picked and the judge's current project.
+// The judge holds the project until the lease expires (see judging.LeaseExpiry).
 // This should be called within a transaction.
-func UpdateAfterPicked(db *mongo.Database, ctx context.Context, project *models.Project, judge *models.Judge) error {
+func UpdateAfterPicked(db *mongo.Database, ctx context.Context, project *models.Project, judge *models.Judge, leaseExpiry primitive.DateTime, clock int64) error {
 	// De-prioritize project
 	_, err := db.Collection("projects").UpdateOne(
 		ctx,
@@ -215,12 +216,13 @@ func UpdateAfterPicked(db *mongo.Database, ctx context.Context, project *models.
 		return err
 	}
 
-	// Set the judge's current project
-	_, err = db.Collection("judges").UpdateOne(
-		ctx,
-		gin.H{"_id": judge.Id},
-		gin.H{"$set": gin.H{"last_location": project.Location, "current": project.Id, "last_activity": util.Now()}},
-	)
+	// Set the judge's current project, starting the time budget on their first project
+	set := gin.H{"last_location": project.Location, "current": project.Id, "lease_expiry": leaseExpiry, "last_activity": util.Now()}
+	if judge.Started == 0 {
+		set["started"] = util.Now()
+		set["started_clock"] = clock
+	}
+	_, err = db.Collection("judges").UpdateOne(ctx, gin.H{"_id": judge.Id}, gin.H{"$set": set})
 	return err
 }
 
@@ -234,12 +236,66 @@ func CountTrackProjects(db *mongo.Database, track string) (int64, error) {
 	return db.Collection("projects").CountDocuments(context.Background(), gin.H{"challenge_list": track})
 }
 
+// SetProjectAway sets the times when a project's team is away from their table
+func SetProjectAway(db *mongo.Database, ctx context.Context, id *primitive.ObjectID, away []models.AwayWindow) error {
+	_, err := db.Collection("projects").UpdateOne(ctx, gin.H{"_id": id}, gin.H{"$set": gin.H{"away": away}})
+	return err
+}
+
+// SetProjectTags sets the expertise tags of a project
+func SetProjectTags(db *mongo.Database, ctx context.Context, id *primitive.ObjectID, tags []string) error {
+	_, err := db.Collection("projects").UpdateOne(ctx, gin.H{"_id": id}, gin.H{"$set": gin.H{"tags": tags}})
+	return err
+}
+
+// FindProjectByTeamCode finds a project by its team code.
+// Returns nil if no project was found.
+func FindProjectByTeamCode(db *mongo.Database, ctx context.Context, code string) (*models.Project, error) {
+	var project models.Project
+	err := db.Collection("projects").FindOne(ctx, gin.H{"team_code": code}).Decode(&project)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &project, nil
+}
+
+// EnsureTeamCodeIndex creates the unique index on team codes if it doesn't exist yet,
+// so no two projects can have the same team code. Projects without a code aren't indexed.
+func EnsureTeamCodeIndex(db *mongo.Database, ctx context.Context) error {
+	_, err := db.Collection("projects").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    gin.H{"team_code": 1},
+		Options: options.Index().SetUnique(true).SetPartialFilterExpression(gin.H{"team_code": gin.H{"$gt": ""}}),
+	})
+	return err
+}
+
+// SetProjectTeamCode sets the code a project's team uses to set their own away times
+func SetProjectTeamCode(db *mongo.Database, ctx context.Context, id *primitive.ObjectID, code string) error {
+	_, err := db.Collection("projects").UpdateOne(ctx, gin.H{"_id": id}, gin.H{"$set": gin.H{"team_code": code}})
+	return err
+}
+
 // SetProjectActive sets the active field of a project (hide or unhide project)
 func SetProjectActive(db *mongo.Database, ctx context.Context, id *primitive.ObjectID, active bool) error {
 	_, err := db.Collection("projects").UpdateOne(context.Background(), gin.H{"_id": id}, gin.H{"$set": gin.H{"active": active}})
 	return err
 }
 
+// SetProjectAbsentReset sets when the project's absences were last reset, optionally also setting it
+// active and prioritized (when the project is marked back)
+func SetProjectAbsentReset(db *mongo.Database, ctx context.Context, id *primitive.ObjectID, reset primitive.DateTime, back bool) error {
+	set := gin.H{"absent_reset": reset}
+	if back {
+		set["active"] = true
+		set["prioritized"] = true
+	}
+	_, err := db.Collection("projects").UpdateOne(ctx, gin.H{"_id": id}, gin.H{"$set": set})
+	return err
+}
+
 // SetProjectsActive sets the active field of a project (hide or unhide project)
 func SetProjectsActive(db *mongo.Database, ctx context.Context, ids []primitive.ObjectID, active bool) error {
 	_, err := db.Collection("projects").UpdateMany(ctx, gin.H{"_id": gin.H{"$in": ids}}, gin.H{"$set": gin.H{"active": active}})
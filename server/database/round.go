@@ -0,0 +1,176 @@
+package database
+
+import (
+	"context"
+	"server/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ArchiveJudgingRound moves every judge's seen projects, rankings, and flags into their past rounds,
+// then clears the judging data of all judges and projects so the next round starts fresh.
+// The flags and comparisons are archived separately (see ArchiveRoundFlags and InsertPastComparisons).
+// Judges that aren't pinned to a round are pinned to the round being archived.
+func ArchiveJudgingRound(db *mongo.Database, ctx context.Context, round int64) error {
+	// Archive the data of every judge that did anything this round
+	_, err := db.Collection("judges").UpdateMany(
+		ctx,
+		gin.H{"$or": bson.A{
+			gin.H{"seen_projects.0": gin.H{"$exists": true}},
+			gin.H{"flagged.0": gin.H{"$exists": true}},
+		}},
+		mongo.Pipeline{bson.D{{Key: "$set", Value: gin.H{
+			"past_rounds": gin.H{"$concatArrays": bson.A{
+				gin.H{"$ifNull": bson.A{"$past_rounds", bson.A{}}},
+				bson.A{gin.H{
+					"round":         round,
+					"seen":          "$seen",
+					"seen_projects": "$seen_projects",
+					"rankings":      "$rankings",
+					"ranking_tiers": gin.H{"$ifNull": bson.A{"$ranking_tiers", bson.A{}}},
+					"flagged":       "$flagged",
+				}},
+			}},
+		}}}},
+	)
+	if err != nil {
+		return err
+	}
+
+	// Pin judges that judged whichever round was current to the archived round
+	_, err = db.Collection("judges").UpdateMany(ctx, gin.H{"round": gin.H{"$in": bson.A{0, nil}}}, gin.H{"$set": gin.H{"round": round}})
+	if err != nil {
+		return err
+	}
+
+	// Clear the judging data of all judges
+	_, err = db.Collection("judges").UpdateMany(
+		ctx,
+		gin.H{},
+		gin.H{"$set": gin.H{
+			"current":       nil,
+			"last_location": -1,
+			"seen":          0,
+			"group_seen":    0,
+			"seen_projects": []models.JudgedProject{},
+			"rankings":      []primitive.ObjectID{},
+			"ranking_tiers": [][]primitive.ObjectID{},
+			"rankings_agg":  []models.AggRanking{},
+			"flagged":       []primitive.ObjectID{},
+			"calibration":   models.JudgeCalibration{},
+			"schedule":      []models.ScheduleStop{},
+			"started":       primitive.DateTime(0),
+			"started_clock": 0,
+		}},
+	)
+	if err != nil {
+		return err
+	}
+
+	// Reset automatic judge weights, keeping the ones set by an admin
+	_, err = db.Collection("judges").UpdateMany(ctx, gin.H{"weight_manual": gin.H{"$ne": true}}, gin.H{"$set": gin.H{"weight": 1}})
+	if err != nil {
+		return err
+	}
+
+	// Clear the view counts of all projects
+	_, err = db.Collection("projects").UpdateMany(
+		ctx,
+		gin.H{},
+		gin.H{"$set": gin.H{
+			"seen":        0,
+			"track_seen":  map[string]int64{},
+			"prioritized": false,
+		}},
+	)
+	return err
+}
+
+// ArchiveRoundFlags moves all flags into the past flags, tagged with the round being archived,
+// so the flags and absence counts of the next round start fresh
+func ArchiveRoundFlags(db *mongo.Database, ctx context.Context, round int64) error {
+	flags := make([]*models.Flag, 0)
+	cursor, err := db.Collection("flags").Find(ctx, gin.H{})
+	if err != nil {
+		return err
+	}
+	err = cursor.All(ctx, &flags)
+	if err != nil {
+		return err
+	}
+	if len(flags) == 0 {
+		return nil
+	}
+
+	docs := make([]any, len(flags))
+	for i, f := range flags {
+		f.Round = round
+		docs[i] = f
+	}
+	_, err = db.Collection("past_flags").InsertMany(ctx, docs)
+	if err != nil {
+		return err
+	}
+	_, err = db.Collection("flags").DeleteMany(ctx, gin.H{})
+	return err
+}
+
+// InsertPastComparisons inserts the comparison counts of a closed round into the past comparisons
+func InsertPastComparisons(db *mongo.Database, ctx context.Context, comparisons []*models.PastComparison) error {
+	if len(comparisons) == 0 {
+		return nil
+	}
+
+	docs := make([]any, len(comparisons))
+	for i, c := range comparisons {
+		docs[i] = c
+	}
+	_, err := db.Collection("past_comparisons").InsertMany(ctx, docs)
+	return err
+}
+
+// PromoteProjects moves projects into a round
+func PromoteProjects(db *mongo.Database, ctx context.Context, projectIds []primitive.ObjectID, round int64) error {
+	_, err := db.Collection("projects").UpdateMany(ctx, gin.H{"_id": gin.H{"$in": projectIds}}, gin.H{"$set": gin.H{"round": round}})
+	return err
+}
+
+// SetJudgesRound moves multiple judges into the pool of a round (0 = whichever round is current)
+func SetJudgesRound(db *mongo.Database, ctx context.Context, judgeIds []primitive.ObjectID, round int64) error {
+	_, err := db.Collection("judges").UpdateMany(ctx, gin.H{"_id": gin.H{"$in": judgeIds}}, gin.H{"$set": gin.H{"round": round}})
+	return err
+}
+
+// UpdateRound sets the current judging round
+func UpdateRound(db *mongo.Database, ctx context.Context, round int64) error {
+	_, err := db.Collection("options").UpdateOne(ctx, gin.H{}, gin.H{"$set": gin.H{"round": round}})
+	return err
+}
+
+// InsertRound inserts the record of a closed round into the database
+func InsertRound(db *mongo.Database, ctx context.Context, round *models.Round) error {
+	res, err := db.Collection("rounds").InsertOne(ctx, round)
+	if err != nil {
+		return err
+	}
+	round.Id = res.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// FindAllRounds returns the records of all closed rounds, in order
+func FindAllRounds(db *mongo.Database, ctx context.Context) ([]*models.Round, error) {
+	rounds := make([]*models.Round, 0)
+	cursor, err := db.Collection("rounds").Find(ctx, gin.H{}, options.Find().SetSort(gin.H{"round": 1}))
+	if err != nil {
+		return nil, err
+	}
+	err = cursor.All(ctx, &rounds)
+	if err != nil {
+		return nil, err
+	}
+	return rounds, nil
+}
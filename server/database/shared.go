@@ -0,0 +1,64 @@
+package database
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ClaimLeader makes the given instance of the server the leader if it already is, there is no leader,
+// or the leader hasn't renewed its claim before it expired. Returns true if the instance is the leader.
+func ClaimLeader(db *mongo.Database, ctx context.Context, instance string, timeout time.Duration) (bool, error) {
+	now := time.Now()
+	_, err := db.Collection("leader").UpdateOne(
+		ctx,
+		gin.H{"_id": "leader", "$or": []gin.H{{"instance": instance}, {"expires": gin.H{"$lt": primitive.NewDateTimeFromTime(now)}}}},
+		gin.H{"$set": gin.H{"instance": instance, "expires": primitive.NewDateTimeFromTime(now.Add(timeout))}},
+		options.Update().SetUpsert(true),
+	)
+
+	// Another instance holds the claim, so the upsert tried to insert a second leader
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// CountRequest counts a rate-limited request from an IP in the given minute, returning the
+// number of requests from that IP in that minute (including this one) across all instances of the server
+func CountRequest(db *mongo.Database, ctx context.Context, ip string, minute int64) (int64, error) {
+	var doc struct {
+		Count int64 `bson:"count"`
+	}
+	err := db.Collection("rate_limits").FindOneAndUpdate(
+		ctx,
+		gin.H{"_id": ip + "|" + strconv.FormatInt(minute, 10)},
+		gin.H{"$inc": gin.H{"count": 1}, "$setOnInsert": gin.H{"minute": minute}},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&doc)
+	return doc.Count, err
+}
+
+// GetRequestCount returns the number of rate-limited requests from an IP in the given minute
+// across all instances of the server, without counting a new one
+func GetRequestCount(db *mongo.Database, ctx context.Context, ip string, minute int64) (int64, error) {
+	var doc struct {
+		Count int64 `bson:"count"`
+	}
+	err := db.Collection("rate_limits").FindOne(ctx, gin.H{"_id": ip + "|" + strconv.FormatInt(minute, 10)}).Decode(&doc)
+	if err == mongo.ErrNoDocuments {
+		return 0, nil
+	}
+	return doc.Count, err
+}
+
+// DeleteOldRequestCounts deletes the request counts from before the given minute
+func DeleteOldRequestCounts(db *mongo.Database, ctx context.Context, minute int64) error {
+	_, err := db.Collection("rate_limits").DeleteMany(ctx, gin.H{"minute": gin.H{"$lt": minute}})
+	return err
+}
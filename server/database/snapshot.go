@@ -0,0 +1,81 @@
+package database
+
+import (
+	"context"
+	"server/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// InsertSnapshot inserts a snapshot into the database
+func InsertSnapshot(db *mongo.Database, ctx context.Context, snapshot *models.Snapshot) error {
+	res, err := db.Collection("snapshots").InsertOne(ctx, snapshot)
+	if err != nil {
+		return err
+	}
+	snapshot.Id = res.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// GetNextSnapshotVersion reserves and returns the version number of the next snapshot to take.
+// The number comes from a counter in the versions collection that is incremented atomically,
+// so two snapshots taken at the same time never get the same version.
+func GetNextSnapshotVersion(db *mongo.Database, ctx context.Context) (int64, error) {
+	// Snapshots taken before the counter existed have versions the counter must start after
+	var latest models.Snapshot
+	opts := options.FindOne().SetSort(gin.H{"version": -1}).SetProjection(gin.H{"version": 1})
+	err := db.Collection("snapshots").FindOne(ctx, gin.H{}, opts).Decode(&latest)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return 0, err
+	}
+	_, err = db.Collection("versions").UpdateOne(
+		ctx,
+		gin.H{"_id": "snapshots"},
+		gin.H{"$max": gin.H{"version": latest.Version}},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	// Reserve the next version
+	var counter struct {
+		Version int64 `bson:"version"`
+	}
+	err = db.Collection("versions").FindOneAndUpdate(
+		ctx,
+		gin.H{"_id": "snapshots"},
+		gin.H{"$inc": gin.H{"version": 1}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&counter)
+	return counter.Version, err
+}
+
+// FindAllSnapshots returns all snapshots sorted by version, without their results
+func FindAllSnapshots(db *mongo.Database, ctx context.Context) ([]*models.Snapshot, error) {
+	snapshots := make([]*models.Snapshot, 0)
+	opts := options.Find().SetSort(gin.H{"version": 1}).SetProjection(gin.H{"projects": 0, "rankings": 0, "options": 0})
+	cursor, err := db.Collection("snapshots").Find(ctx, gin.H{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	err = cursor.All(ctx, &snapshots)
+	if err != nil {
+		return nil, err
+	}
+	return snapshots, nil
+}
+
+// FindSnapshot finds a snapshot by ID.
+// Returns nil if no snapshot was found.
+func FindSnapshot(db *mongo.Database, ctx context.Context, id primitive.ObjectID) (*models.Snapshot, error) {
+	var snapshot models.Snapshot
+	err := db.Collection("snapshots").FindOne(ctx, gin.H{"_id": id}).Decode(&snapshot)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	return &snapshot, err
+}
@@ -9,11 +9,13 @@ import (
 	"io"
 	"net/http"
 	"server/database"
+	"server/judging"
 	"server/models"
 	"server/util"
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"golang.org/x/exp/slices"
 )
@@ -43,9 +45,9 @@ func ParseJudgeCSV(content string, hasHeader bool) ([]*models.Judge, error) {
 			return nil, err
 		}
 
-		// Make sure the record has 2+ elements (name, email, tracks [optional], notes [optional])
+		// Make sure the record has 2+ elements (name, email, tracks [optional], notes [optional], tags [optional])
 		if len(record) < 2 {
-			return nil, fmt.Errorf("record does not contain 2-3 (name, email, tracks [optional] notes [optional]) elements: '%s'", strings.Join(record, ","))
+			return nil, fmt.Errorf("record does not contain 2-5 (name, email, tracks [optional] notes [optional] tags [optional]) elements: '%s'", strings.Join(record, ","))
 		}
 
 		// Assign notes and tracks
@@ -59,7 +61,11 @@ func ParseJudgeCSV(content string, hasHeader bool) ([]*models.Judge, error) {
 		}
 
 		// Add judge to slice
-		judges = append(judges, models.NewJudge(record[0], record[1], track, notes, -1))
+		judge := models.NewJudge(record[0], record[1], track, notes, -1)
+		if len(record) >= 5 {
+			judge.Tags = models.ParseTags(record[4])
+		}
+		judges = append(judges, judge)
 	}
 
 	return judges, nil
@@ -142,7 +148,11 @@ func ParseProjectCsv(content string, hasHeader bool, db *mongo.Database) ([]*mod
 		tableNum++
 
 		// Add project to slice
-		projects = append(projects, models.NewProject(record[0], tableNum, util.GroupFromTable(options, tableNum), record[1], record[2], tryLink, videoLink, challengeList))
+		project := models.NewProject(record[0], tableNum, util.GroupFromTable(options, tableNum), record[1], record[2], tryLink, videoLink, challengeList)
+		if len(record) > 6 {
+			project.Tags = models.ParseTags(record[6])
+		}
+		projects = append(projects, project)
 	}
 
 	return projects, nil
@@ -163,9 +173,9 @@ func ParseProjectCsv(content string, hasHeader bool, db *mongo.Database) ([]*mod
 //  9. Opt-In Prizes - challenge_list
 //  10. Built With - ignore
 //  11. Notes - ignore
-//  12. Team Colleges/Universities - ignore
+//  12. Team Colleges/Universities - universities
 //  13. Additional Team Member Count - ignore
-//  14. (and remiaining rows) Custom questions - custom_questions (ignore for now)
+//  14. (and remiaining rows) Custom questions - the answers to the tag question (see Options.TagQuestion) are added to the tags
 func ParseDevpostCSV(content string, db *mongo.Database) ([]*models.Project, error) {
 	r := csv.NewReader(strings.NewReader(content))
 
@@ -174,8 +184,11 @@ func ParseDevpostCSV(content string, db *mongo.Database) ([]*models.Project, err
 		return []*models.Project{}, nil
 	}
 
-	// Skip the first line
-	r.Read()
+	// Read the header, used to find the custom questions
+	header, err := r.Read()
+	if err != nil {
+		return nil, err
+	}
 
 	// Get the starting table number
 	tableNum, err := database.GetMaxTableNum(db, context.Background())
@@ -189,6 +202,14 @@ func ParseDevpostCSV(content string, db *mongo.Database) ([]*models.Project, err
 		return nil, err
 	}
 
+	// Find the custom question with expertise tags, if any
+	tagColumn := -1
+	if options.TagQuestion != "" {
+		tagColumn = slices.IndexFunc(header, func(h string) bool {
+			return strings.EqualFold(strings.TrimSpace(h), strings.TrimSpace(options.TagQuestion))
+		})
+	}
+
 	// Read the CSV file, looping through each record
 	var projects []*models.Project
 	for {
@@ -231,11 +252,19 @@ func ParseDevpostCSV(content string, db *mongo.Database) ([]*models.Project, err
 			continue
 		}
 
+		// Split the universities into a slice, used to find conflicts of interest
+		universities := []string{}
+		for _, u := range strings.Split(record[12], ",") {
+			if u = strings.TrimSpace(u); u != "" {
+				universities = append(universities, u)
+			}
+		}
+
 		// Increment the table number
 		tableNum++
 
 		// Add project to slice
-		projects = append(projects, models.NewProject(
+		project := models.NewProject(
 			record[0],
 			tableNum,
 			util.GroupFromTable(options, tableNum),
@@ -244,7 +273,12 @@ func ParseDevpostCSV(content string, db *mongo.Database) ([]*models.Project, err
 			record[7],
 			record[8],
 			challengeList,
-		))
+		)
+		project.Universities = universities
+		if tagColumn != -1 && tagColumn < len(record) {
+			project.Tags = models.ParseTags(record[tagColumn])
+		}
+		projects = append(projects, project)
 	}
 
 	return projects, nil
@@ -264,6 +298,13 @@ func AddZipFile(name string, content []byte, ctx *gin.Context) {
 	ctx.Data(http.StatusOK, "application/octet-stream", content)
 }
 
+// AddPdfFile adds a PDF file to the response
+func AddPdfFile(name string, content []byte, ctx *gin.Context) {
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s.pdf", name))
+	ctx.Header("Content-Type", "application/pdf")
+	ctx.Data(http.StatusOK, "application/pdf", content)
+}
+
 // Create a CSV file from a list of judges
 func CreateJudgeCSV(judges []*models.Judge) []byte {
 	csvBuffer := &bytes.Buffer{}
@@ -273,11 +314,11 @@ func CreateJudgeCSV(judges []*models.Judge) []byte {
 
 	// Write the header
 	// TODO: Add judge rankings to output
-	w.Write([]string{"Name", "Email", "Notes", "Code", "Active", "ReadWelcome", "Seen", "LastActivity"})
+	w.Write([]string{"Name", "Email", "Notes", "Code", "Active", "ReadWelcome", "Seen", "Weight", "WeightManual", "LastActivity"})
 
 	// Write each judge
 	for _, judge := range judges {
-		w.Write([]string{judge.Name, judge.Email, judge.Notes, judge.Code, fmt.Sprintf("%t", judge.Active), fmt.Sprintf("%t", judge.ReadWelcome), fmt.Sprintf("%d", judge.Seen), fmt.Sprintf("%d", judge.LastActivity)})
+		w.Write([]string{judge.Name, judge.Email, judge.Notes, judge.Code, fmt.Sprintf("%t", judge.Active), fmt.Sprintf("%t", judge.ReadWelcome), fmt.Sprintf("%d", judge.Seen), fmt.Sprintf("%.2f", judging.JudgeWeight(judge)), fmt.Sprintf("%t", judge.WeightManual), fmt.Sprintf("%d", judge.LastActivity)})
 	}
 
 	// Flush the writer
@@ -286,15 +327,18 @@ func CreateJudgeCSV(judges []*models.Judge) []byte {
 	return csvBuffer.Bytes()
 }
 
-// Create a CSV file from the judges but only the rankings
-func CreateJudgeRankingCSV(judges []*models.Judge) []byte {
+// Create a CSV file from the judges but only the rankings. Tied projects are joined by "=" in the ranked column.
+// The scores column contains each project's aggregated score for the judge using the given method.
+// After the judges, the final standings of the active projects under the score formula are added
+// below a blank line, with every input to the formula so the standings can be reproduced.
+func CreateJudgeRankingCSV(judges []*models.Judge, method string, formula models.ScoreFormula, standings []*judging.CombinedScore) []byte {
 	csvBuffer := &bytes.Buffer{}
 
 	// Create a new CSV writer
 	w := csv.NewWriter(csvBuffer)
 
 	// Write the header
-	w.Write([]string{"Name", "Code", "Ranked", "Unranked"})
+	w.Write([]string{"Name", "Code", "Weight", "Ranked", "Unranked", "Scores"})
 
 	// Write each judge
 	for _, judge := range judges {
@@ -303,33 +347,68 @@ func CreateJudgeRankingCSV(judges []*models.Judge) []byte {
 			continue
 		}
 
-		// Create a list of all ranked projects (just their location)
-		ranked := make([]int64, 0, len(judge.Rankings))
-		for _, projId := range judge.Rankings {
-			idx := util.IndexFunc(judge.SeenProjects, func(p models.JudgedProject) bool {
-				return p.ProjectId == projId
-			})
-			if idx == -1 {
-				continue
+		// Create a list of all ranked projects (just their location), with tied projects joined by "="
+		ranked := make([]string, 0, len(judge.Rankings))
+		for _, tier := range util.GetRankingTiers(judge) {
+			tables := make([]int64, 0, len(tier))
+			for _, projId := range tier {
+				idx := util.FindSeenProjectIndex(judge, projId)
+				if idx == -1 {
+					continue
+				}
+				tables = append(tables, judge.SeenProjects[idx].Location)
+			}
+			if len(tables) > 0 {
+				ranked = append(ranked, strings.Join(util.IntToString(tables), "="))
 			}
-
-			ranked = append(ranked, judge.SeenProjects[idx].Location)
 		}
 
 		// Create a list of all unranked projects (filter using ranked projects)
 		unranked := make([]int64, 0, len(judge.SeenProjects)-len(judge.Rankings))
 		for _, proj := range judge.SeenProjects {
-			if util.ContainsFunc(ranked, func(table int64) bool { return table == proj.Location }) {
+			if !slices.Contains(judge.Rankings, proj.ProjectId) {
 				unranked = append(unranked, proj.Location)
 			}
 		}
 
+		// Create a list of each project's score in the form "table:score"
+		scores := make([]string, 0, len(judge.SeenProjects))
+		for _, agg := range judging.AggregateRanking(judge, method) {
+			idx := util.FindSeenProjectIndex(judge, agg.ProjectId)
+			if idx == -1 {
+				continue
+			}
+			scores = append(scores, fmt.Sprintf("%d:%g", judge.SeenProjects[idx].Location, agg.Score))
+		}
+
 		// Convert arrays to strings
-		rankedStr := util.IntToString(ranked)
 		unrankedStr := util.IntToString(unranked)
 
 		// Write line to CSV
-		w.Write([]string{judge.Name, judge.Code, strings.Join(rankedStr, ","), strings.Join(unrankedStr, ",")})
+		w.Write([]string{judge.Name, judge.Code, fmt.Sprintf("%.2f", judging.JudgeWeight(judge)), strings.Join(ranked, ","), strings.Join(unrankedStr, ","), strings.Join(scores, ",")})
+	}
+
+	// Write the final standings and the formula used to calculate them
+	w.Write([]string{})
+	w.Write([]string{"Formula", fmt.Sprintf("rank_weight=%g", formula.RankWeight), fmt.Sprintf("star_weight=%g", formula.StarWeight), fmt.Sprintf("rubric_weight=%g", formula.RubricWeight)})
+	w.Write([]string{"Place", "Table", "Name", "RankScore", "Stars", "Seen", "RubricTotal", "NormalizedRank", "StarsPerView", "NormalizedRubric", "Combined"})
+	for _, s := range standings {
+		if !s.Active {
+			continue
+		}
+		w.Write([]string{
+			fmt.Sprintf("%d", s.Place),
+			fmt.Sprintf("%d", s.Location),
+			s.Name,
+			fmt.Sprintf("%g", s.RankScore),
+			fmt.Sprintf("%d", s.Stars),
+			fmt.Sprintf("%d", s.Seen),
+			fmt.Sprintf("%.4f", s.RubricTotal),
+			fmt.Sprintf("%.4f", s.NormalizedRank),
+			fmt.Sprintf("%.4f", s.StarsPerView),
+			fmt.Sprintf("%.4f", s.NormalizedRubric),
+			fmt.Sprintf("%.4f", s.Combined),
+		})
 	}
 
 	// Flush the writer
@@ -338,19 +417,131 @@ func CreateJudgeRankingCSV(judges []*models.Judge) []byte {
 	return csvBuffer.Bytes()
 }
 
-// Create a CSV file from a list of projects
-func CreateProjectCSV(projects []*models.Project) []byte {
+// CreateRankingComparisonCSV creates a CSV file with the score and place of every project
+// under each ranking method. Projects are sorted by their place using the sortMethod.
+func CreateRankingComparisonCSV(projects []*models.Project, methods []string, scores map[string]map[primitive.ObjectID]float64, sortMethod string) []byte {
 	csvBuffer := &bytes.Buffer{}
 
 	// Create a new CSV writer
 	w := csv.NewWriter(csvBuffer)
 
 	// Write the header
-	w.Write([]string{"Name", "Table", "Description", "URL", "TryLink", "VideoLink", "ChallengeList", "Seen", "Active", "LastActivity"})
+	header := []string{"Name", "Table"}
+	for _, method := range methods {
+		header = append(header, method+" score", method+" place")
+	}
+	w.Write(header)
+
+	// Calculate the place of each project for every method (ties share the same place)
+	places := make(map[string]map[primitive.ObjectID]int, len(methods))
+	for _, method := range methods {
+		places[method] = make(map[primitive.ObjectID]int, len(projects))
+		for _, a := range projects {
+			place := 1
+			for _, b := range projects {
+				if scores[method][b.Id] > scores[method][a.Id] {
+					place++
+				}
+			}
+			places[method][a.Id] = place
+		}
+	}
+
+	// Sort projects by place using the sort method
+	sorted := slices.Clone(projects)
+	slices.SortStableFunc(sorted, func(a, b *models.Project) int {
+		return places[sortMethod][a.Id] - places[sortMethod][b.Id]
+	})
+
+	// Write each project
+	for _, project := range sorted {
+		row := []string{project.Name, fmt.Sprintf("Table %d", project.Location)}
+		for _, method := range methods {
+			row = append(row, fmt.Sprintf("%.2f", scores[method][project.Id]), fmt.Sprintf("%d", places[method][project.Id]))
+		}
+		w.Write(row)
+	}
+
+	// Flush the writer
+	w.Flush()
+
+	return csvBuffer.Bytes()
+}
+
+// CreatePairwiseCSV creates a CSV file from the pairwise preference matrix, with one row
+// for every ordered pair of projects that have been compared by at least one judge
+func CreatePairwiseCSV(matrix *judging.PairwiseMatrix) []byte {
+	csvBuffer := &bytes.Buffer{}
+
+	// Create a new CSV writer
+	w := csv.NewWriter(csvBuffer)
+
+	// Write the header
+	w.Write([]string{"ProjectId", "Name", "Table", "OpponentId", "OpponentName", "OpponentTable", "Wins", "Losses", "RawWins", "RawLosses", "Comparisons"})
+
+	// Write each compared pair
+	for a, project := range matrix.Projects {
+		for b, opponent := range matrix.Projects {
+			if a == b || matrix.Comparisons[a][b] == 0 {
+				continue
+			}
+			w.Write([]string{
+				project.ProjectId.Hex(),
+				project.Name,
+				fmt.Sprintf("%d", project.Location),
+				opponent.ProjectId.Hex(),
+				opponent.Name,
+				fmt.Sprintf("%d", opponent.Location),
+				fmt.Sprintf("%g", matrix.Wins[a][b]),
+				fmt.Sprintf("%g", matrix.Wins[b][a]),
+				fmt.Sprintf("%g", matrix.RawWins[a][b]),
+				fmt.Sprintf("%g", matrix.RawWins[b][a]),
+				fmt.Sprintf("%d", matrix.Comparisons[a][b]),
+			})
+		}
+	}
+
+	// Flush the writer
+	w.Flush()
+
+	return csvBuffer.Bytes()
+}
+
+// Create a CSV file from a list of projects, including the Bradley-Terry strength of each project
+func CreateProjectCSV(projects []*models.Project, strengths map[primitive.ObjectID]*judging.BTScore) []byte {
+	return createProjectCSV(projects, strengths, "")
+}
+
+// createProjectCSV creates the project CSV. If a track is given, the track judges'
+// ranking score, star count, and rubric total for that track are added as extra columns.
+func createProjectCSV(projects []*models.Project, strengths map[primitive.ObjectID]*judging.BTScore, track string) []byte {
+	csvBuffer := &bytes.Buffer{}
+
+	// Create a new CSV writer
+	w := csv.NewWriter(csvBuffer)
+
+	// Write the header
+	header := []string{"Name", "Table", "Description", "URL", "TryLink", "VideoLink", "ChallengeList", "Seen", "Active", "LastActivity", "Strength", "StrengthLower", "StrengthUpper"}
+	if track != "" {
+		header = append(header, "TrackScore", "TrackStars", "TrackRubricTotal")
+	}
+	w.Write(header)
 
 	// Write each project
 	for _, project := range projects {
-		w.Write([]string{project.Name, fmt.Sprintf("Table %d", project.Location), project.Description, project.Url, project.TryLink, project.VideoLink, strings.Join(project.ChallengeList, ","), fmt.Sprintf("%d", project.Seen), fmt.Sprintf("%t", project.Active), fmt.Sprintf("%d", project.LastActivity)})
+		// Projects that were never compared have no strength
+		strength, lower, upper := "", "", ""
+		if bt, ok := strengths[project.Id]; ok {
+			strength = fmt.Sprintf("%.4f", bt.Strength)
+			lower = fmt.Sprintf("%.4f", bt.Lower)
+			upper = fmt.Sprintf("%.4f", bt.Upper)
+		}
+
+		row := []string{project.Name, fmt.Sprintf("Table %d", project.Location), project.Description, project.Url, project.TryLink, project.VideoLink, strings.Join(project.ChallengeList, ","), fmt.Sprintf("%d", project.Seen), fmt.Sprintf("%t", project.Active), fmt.Sprintf("%d", project.LastActivity), strength, lower, upper}
+		if track != "" {
+			row = append(row, fmt.Sprintf("%.2f", project.TrackScores[track]), fmt.Sprintf("%d", project.TrackStars[track]), fmt.Sprintf("%.2f", project.TrackRubricTotals[track]))
+		}
+		w.Write(row)
 	}
 
 	// Flush the writer
@@ -359,8 +550,10 @@ func CreateProjectCSV(projects []*models.Project) []byte {
 	return csvBuffer.Bytes()
 }
 
-// CreateProjectChallengeZip creates a zip file with a CSV for each challenge
-func CreateProjectChallengeZip(projects []*models.Project) ([]byte, error) {
+// CreateProjectChallengeZip creates a zip file with a CSV for each challenge.
+// Each CSV includes the track judges' score and stars for its challenge, so the
+// projects should have their scores filled in (see judging.AggregateScores).
+func CreateProjectChallengeZip(projects []*models.Project, strengths map[primitive.ObjectID]*judging.BTScore) ([]byte, error) {
 	csvList := [][]byte{}
 
 	// Get list of challenges
@@ -383,7 +576,7 @@ func CreateProjectChallengeZip(projects []*models.Project) ([]byte, error) {
 		}
 
 		// Create CSV for the challenge
-		csv := CreateProjectCSV(currChallengeProjects)
+		csv := createProjectCSV(currChallengeProjects, strengths, challenge)
 		csvList = append(csvList, csv)
 	}
 
@@ -415,6 +608,128 @@ func CreateProjectChallengeZip(projects []*models.Project) ([]byte, error) {
 	return zipBuffer.Bytes(), nil
 }
 
+// CreateSnapshotCSV creates a CSV file with the results of every project in a snapshot,
+// sorted by place. The score and stars of each track are added as extra columns.
+func CreateSnapshotCSV(snapshot *models.Snapshot) []byte {
+	csvBuffer := &bytes.Buffer{}
+
+	// Create a new CSV writer
+	w := csv.NewWriter(csvBuffer)
+
+	// Write the header
+	header := []string{"Place", "Name", "Table", "Active", "Score", "Stars"}
+	for _, track := range snapshot.Options.Tracks {
+		header = append(header, track+" score", track+" stars")
+	}
+	w.Write(header)
+
+	// Write each project
+	for _, p := range snapshot.Projects {
+		row := []string{fmt.Sprintf("%d", p.Place), p.Name, fmt.Sprintf("Table %d", p.Location), fmt.Sprintf("%t", p.Active), fmt.Sprintf("%.2f", p.Score), fmt.Sprintf("%d", p.Stars)}
+		for _, track := range snapshot.Options.Tracks {
+			row = append(row, fmt.Sprintf("%.2f", p.TrackScores[track]), fmt.Sprintf("%d", p.TrackStars[track]))
+		}
+		w.Write(row)
+	}
+
+	// Flush the writer
+	w.Flush()
+
+	return csvBuffer.Bytes()
+}
+
+// CreateItineraryCSV creates a CSV file with the stops on a judge's itinerary, in order
+func CreateItineraryCSV(judge *models.Judge, op *models.Options) []byte {
+	csvBuffer := &bytes.Buffer{}
+
+	// Create a new CSV writer
+	w := csv.NewWriter(csvBuffer)
+
+	// Write the header
+	w.Write([]string{"Stop", "Table", "Project", "Start (min)", "Done"})
+
+	// Write each stop
+	for i, stop := range judge.Schedule {
+		w.Write([]string{fmt.Sprintf("%d", i+1), fmt.Sprintf("%d", stop.Location), stop.Name, fmt.Sprintf("%d", int64(i)*op.JudgingTimer/60), ""})
+	}
+
+	// Flush the writer
+	w.Flush()
+
+	return csvBuffer.Bytes()
+}
+
+// CreateItineraryZip creates a zip file with the itinerary of each judge that has one,
+// as either a CSV or a PDF file (format is "csv" or "pdf")
+func CreateItineraryZip(judges []*models.Judge, op *models.Options, format string) ([]byte, error) {
+	// Create buffer for zip file
+	zipBuffer := &bytes.Buffer{}
+
+	// Create a new zip writer
+	w := zip.NewWriter(zipBuffer)
+
+	// Write each itinerary to the zip file, numbered so judges with the same name don't clash
+	n := 0
+	for _, judge := range judges {
+		if len(judge.Schedule) == 0 {
+			continue
+		}
+		n++
+
+		content := CreateItineraryCSV(judge, op)
+		if format == "pdf" {
+			content = CreateItineraryPDF(judge, op)
+		}
+
+		f, err := w.Create(fmt.Sprintf("%03d-%s.%s", n, ItineraryFileName(judge), format))
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = f.Write(content)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Close the zip writer
+	err := w.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	return zipBuffer.Bytes(), nil
+}
+
+// ItineraryFileName returns a file name for the judge's itinerary (without an extension),
+// keeping only letters, numbers, and dashes from the judge's name
+func ItineraryFileName(judge *models.Judge) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(judge.Name) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '-':
+			b.WriteRune(r)
+		case r == ' ' || r == '_':
+			b.WriteRune('-')
+		}
+	}
+	if b.Len() == 0 {
+		return "itinerary-" + judge.Id.Hex()
+	}
+	return "itinerary-" + b.String()
+}
+
+// itineraryDescription describes which projects the judge's itinerary covers (their track, group, or all projects)
+func itineraryDescription(judge *models.Judge, op *models.Options) string {
+	if op.JudgeTracks && judge.Track != "" {
+		return "Track: " + judge.Track
+	}
+	if op.MultiGroup && judge.Group >= 0 && judge.Group < int64(len(op.GroupNames)) {
+		return "Group: " + op.GroupNames[judge.Group]
+	}
+	return "All projects"
+}
+
 // contains checks if a string is in a list of strings
 func contains(list []string, str string) bool {
 	for _, s := range list {
@@ -0,0 +1,150 @@
+package funcs
+
+import (
+	"bytes"
+	"fmt"
+	"server/models"
+	"strings"
+)
+
+// Letter-sized pages, in points
+const (
+	pdfPageWidth  = 612
+	pdfPageHeight = 792
+	pdfMargin     = 54
+)
+
+// pdfLine is a single line of text in a PDF
+type pdfLine struct {
+	Text string
+	Size float64
+	Bold bool
+}
+
+// createTextPDF creates a PDF with the given lines of text, starting a new page whenever one fills up.
+// Lines that are too long for the page are wrapped. Only the built-in Helvetica fonts are used, so
+// characters outside of Latin-1 are replaced with "?".
+func createTextPDF(lines []pdfLine) []byte {
+	// Lay out the lines into the content stream of each page
+	var pages []*bytes.Buffer
+	var page *bytes.Buffer
+	y := 0.0
+	for _, line := range lines {
+		for _, text := range wrapPDFText(line.Text, line.Size) {
+			height := line.Size * 1.4
+			if page == nil || y-height < pdfMargin {
+				page = &bytes.Buffer{}
+				pages = append(pages, page)
+				y = pdfPageHeight - pdfMargin
+			}
+			y -= height
+
+			font := "F1"
+			if line.Bold {
+				font = "F2"
+			}
+			fmt.Fprintf(page, "BT /%s %.1f Tf %d %.1f Td (%s) Tj ET\n", font, line.Size, pdfMargin, y, escapePDFText(text))
+		}
+	}
+	if len(pages) == 0 {
+		pages = append(pages, &bytes.Buffer{})
+	}
+
+	// Objects 1-4 are the catalog, page tree, and fonts; each page is followed by its content stream
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>",
+	}
+	kids := make([]string, len(pages))
+	for i, content := range pages {
+		pageNum := len(objects) + 1
+		kids[i] = fmt.Sprintf("%d 0 R", pageNum)
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>", pdfPageWidth, pdfPageHeight, pageNum+1),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()),
+		)
+	}
+	objects[1] = fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages))
+
+	// Write the objects, keeping track of where each one starts for the cross-reference table
+	out := &bytes.Buffer{}
+	out.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = out.Len()
+		fmt.Fprintf(out, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	xref := out.Len()
+	fmt.Fprintf(out, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+
+	return out.Bytes()
+}
+
+// wrapPDFText splits text into lines that fit the width of the page, breaking at spaces where possible.
+// Helvetica characters are assumed to be about half as wide as the font size.
+func wrapPDFText(text string, size float64) []string {
+	maxChars := int((pdfPageWidth - 2*pdfMargin) / (size * 0.5))
+	runes := []rune(text)
+	if len(runes) <= maxChars {
+		return []string{text}
+	}
+
+	var out []string
+	for len(runes) > maxChars {
+		cut := maxChars
+		for i := maxChars; i > maxChars/2; i-- {
+			if runes[i] == ' ' {
+				cut = i
+				break
+			}
+		}
+		out = append(out, strings.TrimRight(string(runes[:cut]), " "))
+		runes = []rune(strings.TrimLeft(string(runes[cut:]), " "))
+	}
+	return append(out, string(runes))
+}
+
+// escapePDFText escapes text for a PDF string literal in WinAnsi encoding
+func escapePDFText(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		switch {
+		case r == '\\' || r == '(' || r == ')':
+			b.WriteRune('\\')
+			b.WriteRune(r)
+		case r >= 0x20 && r < 0x7f:
+			b.WriteRune(r)
+		case r >= 0xa0 && r <= 0xff:
+			fmt.Fprintf(&b, "\\%03o", r)
+		default:
+			b.WriteRune('?')
+		}
+	}
+	return b.String()
+}
+
+// CreateItineraryPDF creates a printable PDF of a judge's itinerary, with a checkbox and
+// the estimated start time (from the judging timer) of each stop
+func CreateItineraryPDF(judge *models.Judge, op *models.Options) []byte {
+	lines := []pdfLine{
+		{Text: "Judging itinerary: " + judge.Name, Size: 18, Bold: true},
+		{Text: itineraryDescription(judge, op), Size: 11},
+		{Text: "", Size: 11},
+	}
+	if len(judge.Schedule) == 0 {
+		lines = append(lines, pdfLine{Text: "No stops have been planned for this judge.", Size: 12})
+	}
+	for i, stop := range judge.Schedule {
+		lines = append(lines,
+			pdfLine{Text: fmt.Sprintf("[  ]  %d. Table %d - %s", i+1, stop.Location, stop.Name), Size: 12, Bold: true},
+			pdfLine{Text: fmt.Sprintf("          Starts after about %d min", int64(i)*op.JudgingTimer/60), Size: 10},
+		)
+	}
+	return createTextPDF(lines)
+}
@@ -0,0 +1,53 @@
+package judging
+
+import (
+	"context"
+	"errors"
+	"server/database"
+	"server/models"
+	"server/util"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// AbsentLimit returns the number of absences before a project is hidden.
+// Options saved before the limit was configurable don't have one, so they use the old limit of 3.
+func AbsentLimit(op *models.Options) int64 {
+	if op.AbsentLimit < 1 {
+		return 3
+	}
+	return op.AbsentLimit
+}
+
+// HiddenForAbsence returns true if a project is hidden because it was absent, going by its
+// absence history (oldest first). Projects hidden by an admin aren't, even if they were absent before.
+func HiddenForAbsence(project *models.Project, history []*models.Flag) bool {
+	if project.Active || len(history) == 0 {
+		return false
+	}
+	return history[len(history)-1].Reason == "hidden-absent"
+}
+
+// MarkProjectBack marks a project as back after being absent: it is unhidden, prioritized so the next
+// judge is sent to it, and its earlier absences no longer count towards hiding it.
+// The "back" flag is added to its absence history with the given source ("admin" or "team") as the judge name.
+// This should be run in a transaction.
+func MarkProjectBack(db *mongo.Database, ctx context.Context, project *models.Project, source string) error {
+	err := database.SetProjectAbsentReset(db, ctx, &project.Id, util.Now(), true)
+	if err != nil {
+		return errors.New("error marking project as back: " + err.Error())
+	}
+
+	judge := models.NewDummyJudge()
+	judge.Name = source
+	backFlag, err := models.NewFlag(project, judge, "back")
+	if err != nil {
+		return errors.New("error creating back flag object: " + err.Error())
+	}
+	err = database.InsertFlag(db, ctx, backFlag)
+	if err != nil {
+		return errors.New("error inserting back flag into database: " + err.Error())
+	}
+
+	return nil
+}
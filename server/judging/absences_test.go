@@ -0,0 +1,47 @@
+package judging
+
+import (
+	"server/models"
+	"testing"
+)
+
+func TestAbsences(t *testing.T) {
+	op := models.NewOptions()
+	if AbsentLimit(op) != 3 {
+		t.Errorf("expected the default absent limit to be 3, got %d", AbsentLimit(op))
+	}
+	op.AbsentLimit = 0
+	if AbsentLimit(op) != 3 {
+		t.Errorf("expected options without an absent limit to use 3, got %d", AbsentLimit(op))
+	}
+	op.AbsentLimit = 5
+	if AbsentLimit(op) != 5 {
+		t.Errorf("expected the absent limit option to be used, got %d", AbsentLimit(op))
+	}
+
+	project := models.DefaultProject()
+	history := []*models.Flag{{Reason: "absent"}, {Reason: "absent"}, {Reason: "hidden-absent"}}
+	for _, f := range history {
+		if !f.IsAbsence() {
+			t.Errorf("expected %s to be part of the absence history", f.Reason)
+		}
+	}
+	if (&models.Flag{Reason: "offensive"}).IsAbsence() {
+		t.Errorf("expected offensive not to be part of the absence history")
+	}
+
+	// Active projects aren't hidden, whatever their history
+	if HiddenForAbsence(project, history) {
+		t.Errorf("expected an active project not to be hidden for absence")
+	}
+	project.Active = false
+	if !HiddenForAbsence(project, history) {
+		t.Errorf("expected the project to be hidden for absence")
+	}
+
+	// Once marked back, hiding it again is up to the admin
+	history = append(history, &models.Flag{Reason: "back"})
+	if HiddenForAbsence(project, history) || HiddenForAbsence(project, nil) {
+		t.Errorf("expected a project hidden after being back not to be hidden for absence")
+	}
+}
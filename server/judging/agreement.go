@@ -0,0 +1,241 @@
+package judging
+
+import (
+	"fmt"
+	"math"
+	"server/models"
+	"server/util"
+	"slices"
+	"sort"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	agreementRandomZ      = 1.0   // Judges whose agreement is less than this many standard errors above 0 look random
+	agreementAdversarialZ = 1.645 // Judges whose agreement is this many standard errors below 0 look adversarial (one-sided 95%)
+	agreementMinCommon    = 3     // Minimum number of projects two judges must have in common to be compared
+)
+
+// AgreementReport is the inter-rater agreement of a set of judges
+type AgreementReport struct {
+	Judges []*JudgeAgreement `json:"judges"`
+	Groups []*GroupAgreement `json:"groups"`
+}
+
+// JudgeAgreement is how closely a single judge agrees with the consensus of all other judges
+type JudgeAgreement struct {
+	JudgeId    primitive.ObjectID `json:"judge_id"`
+	JudgeName  string             `json:"judge_name"`
+	Group      int64              `json:"group"`
+	Seen       int64              `json:"seen"`
+	Concordant int64              `json:"concordant"` // Pairwise preferences that agree with the consensus
+	Discordant int64              `json:"discordant"` // Pairwise preferences that disagree with the consensus
+	Tau        float64            `json:"tau"`        // Kendall tau against the consensus, from -1 to 1
+	Spearman   float64            `json:"spearman"`   // Spearman correlation against the consensus, from -1 to 1
+	Flag       string             `json:"flag"`       // "random", "adversarial", or "" if the judge looks fine (or has too few preferences to tell)
+	Reason     string             `json:"reason"`     // Human-readable explanation of the flag
+}
+
+// GroupAgreement is the overall agreement of the judges in a group
+type GroupAgreement struct {
+	Group        int64   `json:"group"`
+	Judges       int64   `json:"judges"`        // Number of judges that ranked at least one project
+	Pairs        int64   `json:"pairs"`         // Number of judge pairs that had enough projects in common to compare
+	MeanSpearman float64 `json:"mean_spearman"` // Average Spearman correlation between pairs of judges
+	W            float64 `json:"w"`             // Kendall's W, from 0 (no agreement) to 1 (complete agreement)
+}
+
+// AnalyzeAgreement measures how closely each judge's rankings agree with the consensus
+// of every other judge (see CalibrateJudges), and flags judges whose rankings look random
+// (no detectable agreement) or adversarial (significantly reversed).
+// Kendall's W is calculated for each group from the average Spearman correlation between
+// pairs of judges over the projects they both saw, since judges never see every project.
+func AnalyzeAgreement(judges []*models.Judge) *AgreementReport {
+	report := &AgreementReport{Judges: []*JudgeAgreement{}, Groups: []*GroupAgreement{}}
+	judges = withoutConflicts(judges)
+	consensus := newConsensus(judges)
+
+	for i, judge := range judges {
+		concordant, discordant := consensus.agreement(i, judge)
+		ja := &JudgeAgreement{
+			JudgeId:    judge.Id,
+			JudgeName:  judge.Name,
+			Group:      judge.Group,
+			Seen:       int64(len(judge.SeenProjects)),
+			Concordant: concordant,
+			Discordant: discordant,
+		}
+
+		// Correlate the judge's ranks with the consensus ranks of the same projects
+		seen := seenProjectIds(judge)
+		consensusKeys := make([]float64, len(seen))
+		for j, id := range seen {
+			consensusKeys[j] = consensus.without(i, id)
+		}
+		ja.Spearman, _ = spearman(judgeRankKeys(judge, seen), consensusKeys)
+
+		// Flag the judge if the agreement is too low to be explained by chance
+		pairs := concordant + discordant
+		if pairs < calibrationMinPairs {
+			ja.Reason = fmt.Sprintf("Only %d comparable preferences (at least %d needed)", pairs, calibrationMinPairs)
+		} else {
+			ja.Tau = float64(concordant-discordant) / float64(pairs)
+			z := ja.Tau / kendallTauStdErr(len(seen))
+			switch {
+			case z <= -agreementAdversarialZ:
+				ja.Flag = "adversarial"
+				ja.Reason = fmt.Sprintf("Rankings are significantly reversed from the consensus (tau = %.2f, z = %.2f)", ja.Tau, z)
+			case z < agreementRandomZ:
+				ja.Flag = "random"
+				ja.Reason = fmt.Sprintf("Rankings show no clear agreement with the consensus (tau = %.2f, z = %.2f)", ja.Tau, z)
+			default:
+				ja.Reason = fmt.Sprintf("Rankings agree with the consensus (tau = %.2f, z = %.2f)", ja.Tau, z)
+			}
+		}
+
+		report.Judges = append(report.Judges, ja)
+	}
+
+	// Split the judges that ranked anything into groups
+	groups := make(map[int64][]*models.Judge)
+	for _, judge := range judges {
+		if len(judge.Rankings) > 0 {
+			groups[judge.Group] = append(groups[judge.Group], judge)
+		}
+	}
+	for group, groupJudges := range groups {
+		report.Groups = append(report.Groups, groupAgreement(group, groupJudges))
+	}
+	sort.Slice(report.Groups, func(i, j int) bool {
+		return report.Groups[i].Group < report.Groups[j].Group
+	})
+
+	return report
+}
+
+// groupAgreement calculates Kendall's W for a group of judges. For complete rankings,
+// W = ((m - 1) * r + 1) / m, where r is the average Spearman correlation between all
+// pairs of the m judges. Here r is averaged over the pairs of judges that saw enough
+// of the same projects, each correlated over only the projects they have in common.
+func groupAgreement(group int64, judges []*models.Judge) *GroupAgreement {
+	ga := &GroupAgreement{Group: group, Judges: int64(len(judges))}
+
+	total := 0.0
+	for i, a := range judges {
+		for _, b := range judges[i+1:] {
+			var common []primitive.ObjectID
+			for _, id := range seenProjectIds(a) {
+				if util.FindSeenProjectIndex(b, id) != -1 {
+					common = append(common, id)
+				}
+			}
+			if len(common) < agreementMinCommon {
+				continue
+			}
+
+			r, ok := spearman(judgeRankKeys(a, common), judgeRankKeys(b, common))
+			if !ok {
+				continue
+			}
+			total += r
+			ga.Pairs++
+		}
+	}
+
+	if ga.Pairs == 0 {
+		return ga
+	}
+	ga.MeanSpearman = total / float64(ga.Pairs)
+	m := float64(ga.Judges)
+	ga.W = math.Max(0, math.Min(1, ((m-1)*ga.MeanSpearman+1)/m))
+	return ga
+}
+
+// seenProjectIds returns the IDs of all projects the judge has seen
+func seenProjectIds(judge *models.Judge) []primitive.ObjectID {
+	out := make([]primitive.ObjectID, len(judge.SeenProjects))
+	for i, p := range judge.SeenProjects {
+		out[i] = p.ProjectId
+	}
+	return out
+}
+
+// judgeRankKeys returns a key for each project where a higher key is a better rank.
+// Projects in the same tier share the same key, and unranked projects are tied below all tiers.
+func judgeRankKeys(judge *models.Judge, ids []primitive.ObjectID) []float64 {
+	tiers := util.GetRankingTiers(judge)
+	out := make([]float64, len(ids))
+	for i, id := range ids {
+		out[i] = -float64(len(tiers))
+		for t, tier := range tiers {
+			if slices.Contains(tier, id) {
+				out[i] = -float64(t)
+				break
+			}
+		}
+	}
+	return out
+}
+
+// spearman calculates the Spearman rank correlation between two lists of keys, where ties get
+// their average rank. Returns false if there are fewer than 3 items or either list is all tied.
+func spearman(a []float64, b []float64) (float64, bool) {
+	if len(a) < 3 || len(a) != len(b) {
+		return 0, false
+	}
+	ra, rb := averageRanks(a), averageRanks(b)
+
+	// Pearson correlation of the ranks
+	n := float64(len(ra))
+	var meanA, meanB float64
+	for i := range ra {
+		meanA += ra[i] / n
+		meanB += rb[i] / n
+	}
+	var cov, varA, varB float64
+	for i := range ra {
+		cov += (ra[i] - meanA) * (rb[i] - meanB)
+		varA += (ra[i] - meanA) * (ra[i] - meanA)
+		varB += (rb[i] - meanB) * (rb[i] - meanB)
+	}
+	if varA == 0 || varB == 0 {
+		return 0, false
+	}
+	return cov / math.Sqrt(varA*varB), true
+}
+
+// averageRanks converts keys to ranks (1 = highest key), giving tied keys their average rank
+func averageRanks(keys []float64) []float64 {
+	order := make([]int, len(keys))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return keys[order[i]] > keys[order[j]]
+	})
+
+	ranks := make([]float64, len(keys))
+	for i := 0; i < len(order); {
+		j := i
+		for j+1 < len(order) && keys[order[j+1]] == keys[order[i]] {
+			j++
+		}
+		avg := float64(i+j)/2 + 1
+		for k := i; k <= j; k++ {
+			ranks[order[k]] = avg
+		}
+		i = j + 1
+	}
+	return ranks
+}
+
+// kendallTauStdErr is the standard error of Kendall tau between n items under the null
+// hypothesis of no agreement
+func kendallTauStdErr(n int) float64 {
+	if n < 2 {
+		return math.Inf(1)
+	}
+	fn := float64(n)
+	return math.Sqrt(2 * (2*fn + 5) / (9 * fn * (fn - 1)))
+}
@@ -0,0 +1,53 @@
+package judging
+
+import (
+	"math"
+	"server/models"
+	"slices"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestAnalyzeAgreement(t *testing.T) {
+	ids := make([]primitive.ObjectID, 5)
+	for i := range ids {
+		ids[i] = primitive.NewObjectID()
+	}
+	reversed := slices.Clone(ids)
+	slices.Reverse(reversed)
+
+	// Four judges agree completely and one reverses everything
+	var judges []*models.Judge
+	for range 4 {
+		judges = append(judges, rankingJudge(ids, 5))
+	}
+	adversary := rankingJudge(reversed, 5)
+	judges = append(judges, adversary)
+	for _, judge := range judges {
+		judge.Id = primitive.NewObjectID()
+	}
+
+	report := AnalyzeAgreement(judges)
+	for _, ja := range report.Judges {
+		if ja.JudgeId == adversary.Id {
+			if ja.Flag != "adversarial" || ja.Tau != -1 || math.Abs(ja.Spearman+1) > 1e-9 {
+				t.Errorf("expected adversary to be flagged with tau -1, got %+v", ja)
+			}
+		} else if ja.Flag != "" || ja.Tau != 1 {
+			t.Errorf("expected agreeing judge not to be flagged, got %+v", ja)
+		}
+	}
+
+	// 6 agreeing pairs and 4 reversed pairs give a mean correlation of 0.2
+	if len(report.Groups) != 1 || report.Groups[0].Pairs != 10 || math.Abs(report.Groups[0].W-0.36) > 1e-9 {
+		t.Errorf("unexpected group agreement: %+v", report.Groups)
+	}
+}
+
+func TestAverageRanks(t *testing.T) {
+	ranks := averageRanks([]float64{3, 1, 3, 0})
+	if !slices.Equal(ranks, []float64{1.5, 3, 1.5, 4}) {
+		t.Errorf("unexpected ranks %v", ranks)
+	}
+}
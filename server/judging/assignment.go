@@ -0,0 +1,209 @@
+package judging
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"server/models"
+	"slices"
+)
+
+// Assignment strategies that can be selected in the options
+const (
+	StrategyDefault       = "default"
+	StrategyRandom        = "random"
+	StrategyRoundRobin    = "round-robin"
+	StrategyLeastCompared = "least-compared"
+	StrategyCoverageFirst = "coverage-first"
+)
+
+// AssignmentStrategies is the list of all valid assignment strategies
+var AssignmentStrategies = []string{StrategyDefault, StrategyRandom, StrategyRoundRobin, StrategyLeastCompared, StrategyCoverageFirst}
+
+// AssignmentStrategy picks the next project for a judge out of the projects they are
+// allowed to judge (see FindAvailableItems). Items are never empty.
+type AssignmentStrategy interface {
+	Pick(store Store, ctx context.Context, judge *models.Judge, items []*models.Project, comps *Comparisons, op *models.Options) (*models.Project, error)
+}
+
+// IsValidAssignmentStrategy returns true if the name is one of the supported assignment strategies
+func IsValidAssignmentStrategy(name string) bool {
+	return slices.Contains(AssignmentStrategies, name)
+}
+
+// GetAssignmentStrategy returns the assignment strategy with the given name, defaulting
+// to the default strategy for databases created before the option existed
+func GetAssignmentStrategy(name string) AssignmentStrategy {
+	switch name {
+	case StrategyRandom:
+		return randomStrategy{}
+	case StrategyRoundRobin:
+		return roundRobinStrategy{}
+	case StrategyLeastCompared:
+		return leastComparedStrategy{}
+	case StrategyCoverageFirst:
+		return coverageFirstStrategy{}
+	default:
+		return defaultStrategy{}
+	}
+}
+
+// defaultStrategy is the standard Jury assignment flow:
+//  1. If judging a track, pick the closest project if there is a floor plan, or else the next project in order
+//  2. Only keep the projects with the fewest views (skipped with adaptive assignment once every project has reached min views)
+//  3. If any project is prioritized and on the list, return that
+//  4. Shuffle projects
+//  5. If any projects seen less than min views (set in admin side), only select from that list
+//  6. If adaptive assignment is on, pick the project whose place in the top N is least certain
+//  7. Otherwise, pick the project with the minimum number of comparisons with every other project
+//
+// Whenever several projects are tied in steps 5 and 7, the one closest to the judge's last table is picked.
+type defaultStrategy struct{}
+
+func (defaultStrategy) Pick(store Store, ctx context.Context, judge *models.Judge, items []*models.Project, comps *Comparisons, op *models.Options) (*models.Project, error) {
+	// If judging a track, pick the closest project or simply the next one
+	if judge.Track != "" {
+		if len(op.FloorPlan.Tables) > 0 && judge.LastLocation != -1 {
+			return closestProject(items, judge.LastLocation, &op.FloorPlan), nil
+		}
+		return GetNextFreeProject(judge.LastLocation, items)
+	}
+
+	// Get the minimum number of views of the projects
+	minSeen := items[0].Seen
+	for _, proj := range items {
+		if proj.Seen < minSeen {
+			minSeen = proj.Seen
+		}
+	}
+
+	// Filter out projects that have more than the minimum number of views
+	// With adaptive assignment, extra views go to uncertain projects instead of being spread evenly
+	if !op.AdaptiveAssign || minSeen < op.MinViews {
+		var minViewProjects []*models.Project
+		for _, proj := range items {
+			if proj.Seen == minSeen {
+				minViewProjects = append(minViewProjects, proj)
+			}
+		}
+		items = minViewProjects
+	}
+
+	// Get prioritized projects
+	prioritizedProjects, err := store.GetPrioritizedProjects(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// If any prioritized projects are in the list, return the first one
+	for _, proj := range prioritizedProjects {
+		if slices.ContainsFunc(items, func(p *models.Project) bool {
+			return p.Id == proj.Id
+		}) {
+			return proj, nil
+		}
+	}
+
+	// Shuffle items
+	shuffleProjects(items)
+
+	// Stable sort by the number of views
+	slices.SortStableFunc(items, func(a, b *models.Project) int {
+		return int(a.Seen - b.Seen)
+	})
+
+	// If any items have not been seen minViews times, return the closest of the least seen items
+	// Without a floor plan, this will be a random item due to shuffling + stable sort
+	if items[0].Seen < op.MinViews {
+		leastSeen := slices.DeleteFunc(slices.Clone(items), func(p *models.Project) bool {
+			return p.Seen != items[0].Seen
+		})
+		return closestProject(leastSeen, judge.LastLocation, &op.FloorPlan), nil
+	}
+
+	// Settle the closest races around the top N if adaptive assignment is on
+	if op.AdaptiveAssign {
+		return PickMostUncertain(store, ctx, items, judge, comps, op.AdaptiveTopN)
+	}
+
+	// Otherwise, pick the project that has been compared to other projects the least
+	return closestProject(comps.LeastCompared(items, judge.SeenProjects), judge.LastLocation, &op.FloorPlan), nil
+}
+
+// randomStrategy picks any available project at random
+type randomStrategy struct{}
+
+func (randomStrategy) Pick(store Store, ctx context.Context, judge *models.Judge, items []*models.Project, comps *Comparisons, op *models.Options) (*models.Project, error) {
+	return items[rand.Intn(len(items))], nil
+}
+
+// roundRobinStrategy walks the judge down the tables in order, wrapping around at the end
+type roundRobinStrategy struct{}
+
+func (roundRobinStrategy) Pick(store Store, ctx context.Context, judge *models.Judge, items []*models.Project, comps *Comparisons, op *models.Options) (*models.Project, error) {
+	return GetNextFreeProject(judge.LastLocation, items)
+}
+
+// leastComparedStrategy picks the project that has been compared the least to the judge's
+// seen projects, ignoring view counts. Ties are broken by distance, then at random.
+type leastComparedStrategy struct{}
+
+func (leastComparedStrategy) Pick(store Store, ctx context.Context, judge *models.Judge, items []*models.Project, comps *Comparisons, op *models.Options) (*models.Project, error) {
+	shuffleProjects(items)
+	return closestProject(comps.LeastCompared(items, judge.SeenProjects), judge.LastLocation, &op.FloorPlan), nil
+}
+
+// coverageFirstStrategy picks the project with the fewest views (track views for track judges),
+// so that every project is seen as evenly as possible. Ties are broken by picking the
+// project that has been compared the least to the judge's seen projects, then by distance.
+type coverageFirstStrategy struct{}
+
+func (coverageFirstStrategy) Pick(store Store, ctx context.Context, judge *models.Judge, items []*models.Project, comps *Comparisons, op *models.Options) (*models.Project, error) {
+	views := func(p *models.Project) int64 {
+		if judge.Track != "" {
+			return p.TrackSeen[judge.Track]
+		}
+		return p.Seen
+	}
+
+	minViews := views(items[0])
+	for _, proj := range items {
+		minViews = min(minViews, views(proj))
+	}
+	var leastViewed []*models.Project
+	for _, proj := range items {
+		if views(proj) == minViews {
+			leastViewed = append(leastViewed, proj)
+		}
+	}
+
+	shuffleProjects(leastViewed)
+	return closestProject(comps.LeastCompared(leastViewed, judge.SeenProjects), judge.LastLocation, &op.FloorPlan), nil
+}
+
+// closestProject picks the project closest to the given table on the floor plan.
+// The first project is picked if the judge hasn't been to a table yet or no distances are known.
+// Items param MUST not be empty.
+func closestProject(items []*models.Project, from int64, plan *models.FloorPlan) *models.Project {
+	closest := items[0]
+	if from == -1 || len(plan.Tables) == 0 {
+		return closest
+	}
+
+	best := math.Inf(1)
+	for _, p := range items {
+		if dist, ok := plan.Distance(from, p.Location); ok && dist < best {
+			best = dist
+			closest = p
+		}
+	}
+	return closest
+}
+
+// shuffleProjects shuffles the projects in place
+func shuffleProjects(items []*models.Project) {
+	for i := range items {
+		j := rand.Intn(i + 1)
+		items[i], items[j] = items[j], items[i]
+	}
+}
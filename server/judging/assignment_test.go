@@ -0,0 +1,99 @@
+package judging
+
+import (
+	"math"
+	"server/models"
+	"slices"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestAssignmentStrategies(t *testing.T) {
+	projects := []*models.Project{
+		{Id: primitive.NewObjectID(), Location: 1, Seen: 2, TrackSeen: map[string]int64{"AI": 0}},
+		{Id: primitive.NewObjectID(), Location: 2, Seen: 0, TrackSeen: map[string]int64{"AI": 1}},
+		{Id: primitive.NewObjectID(), Location: 3, Seen: 1, TrackSeen: map[string]int64{"AI": 1}},
+	}
+	judge := rankingJudge([]primitive.ObjectID{}, 0)
+	comps := CreateComparisons([]*models.Judge{})
+	op := models.NewOptions()
+	pick := func(strategy string) *models.Project {
+		items := slices.Clone(projects)
+		p, err := GetAssignmentStrategy(strategy).Pick(nil, nil, judge, items, comps, op)
+		if err != nil {
+			t.Fatalf("unexpected error from %s: %s", strategy, err)
+		}
+		return p
+	}
+
+	// Round robin goes to the next table, wrapping around
+	judge.LastLocation = 1
+	if p := pick(StrategyRoundRobin); p != projects[1] {
+		t.Errorf("expected round robin to pick table 2, got table %d", p.Location)
+	}
+	judge.LastLocation = 3
+	if p := pick(StrategyRoundRobin); p != projects[0] {
+		t.Errorf("expected round robin to wrap around to table 1, got table %d", p.Location)
+	}
+
+	// Coverage first picks the fewest views, or the fewest track views for track judges
+	if p := pick(StrategyCoverageFirst); p != projects[1] {
+		t.Errorf("expected coverage first to pick table 2, got table %d", p.Location)
+	}
+	judge.Track = "AI"
+	if p := pick(StrategyCoverageFirst); p != projects[0] {
+		t.Errorf("expected coverage first to pick table 1 for track judges, got table %d", p.Location)
+	}
+
+	if !IsValidAssignmentStrategy(StrategyLeastCompared) || IsValidAssignmentStrategy("fastest") {
+		t.Errorf("unexpected assignment strategy validity")
+	}
+	if _, ok := GetAssignmentStrategy("").(defaultStrategy); !ok {
+		t.Errorf("expected an empty strategy to fall back to the default")
+	}
+}
+
+func TestFloorPlanAssignment(t *testing.T) {
+	plan := models.FloorPlan{
+		Tables: []models.TablePosition{
+			{Table: 1, X: 0, Y: 0, Room: "A", Floor: 1},
+			{Table: 2, X: 30, Y: 40, Room: "A", Floor: 1},
+			{Table: 3, X: 0, Y: 10, Room: "B", Floor: 1},
+			{Table: 4, X: 0, Y: 0, Room: "A", Floor: 3},
+		},
+		RoomPenalty:  50,
+		FloorPenalty: 200,
+	}
+	for _, c := range []struct {
+		a, b int64
+		dist float64
+	}{{1, 2, 50}, {1, 3, 60}, {1, 4, 400}} {
+		if dist, ok := plan.Distance(c.a, c.b); !ok || math.Abs(dist-c.dist) > 1e-9 {
+			t.Errorf("expected distance %g between tables %d and %d, got %g", c.dist, c.a, c.b, dist)
+		}
+	}
+	if _, ok := plan.Distance(1, 5); ok {
+		t.Errorf("expected unknown distance to a table not in the floor plan")
+	}
+
+	// Equally good projects go to the closest table, and unknown tables are never preferred
+	projects := []*models.Project{{Location: 5}, {Location: 4}, {Location: 3}, {Location: 2}}
+	if p := closestProject(projects, 1, &plan); p.Location != 2 {
+		t.Errorf("expected the closest table to be 2, got %d", p.Location)
+	}
+	if p := closestProject(projects, -1, &plan); p.Location != 5 {
+		t.Errorf("expected the first project before the judge has been to a table, got %d", p.Location)
+	}
+
+	// Track judges go to the closest table instead of the next table number
+	judge := rankingJudge([]primitive.ObjectID{}, 0)
+	judge.Track = "AI"
+	judge.LastLocation = 1
+	op := models.NewOptions()
+	op.FloorPlan = plan
+	p, err := GetAssignmentStrategy(StrategyDefault).Pick(nil, nil, judge, projects, NewComparisons(), op)
+	if err != nil || p.Location != 2 {
+		t.Errorf("expected the track judge to be sent to table 2, got %v (%v)", p, err)
+	}
+}
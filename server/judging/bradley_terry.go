@@ -0,0 +1,101 @@
+package judging
+
+import (
+	"math"
+	"server/models"
+	"slices"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	btPrior     = 1.0  // Number of virtual wins and losses against an average project (keeps estimates finite)
+	btMaxIters  = 1000 // Maximum number of iterations when fitting the model
+	btTolerance = 1e-9 // Stop fitting once no strength changes by more than this
+	btZ         = 1.96 // Z-score for the 95% confidence interval
+)
+
+// BTScore is the Bradley-Terry strength estimate of a project.
+// Strengths are on a log scale, where 0 is the strength of an average project
+// and a difference of 1 means the stronger project wins ~73% of the time.
+type BTScore struct {
+	ProjectId   primitive.ObjectID `json:"project_id"`
+	Strength    float64            `json:"strength"`
+	Lower       float64            `json:"lower"`       // Lower bound of the 95% confidence interval
+	Upper       float64            `json:"upper"`       // Upper bound of the 95% confidence interval
+	Comparisons int64              `json:"comparisons"` // Number of pairwise preferences involving this project
+}
+
+// ComputeBradleyTerry fits a Bradley-Terry model over all pairwise preferences implied by
+// the judges' weighted rankings (see newPairwise). Each project is also given a small number of virtual
+// wins and losses against an average project (as in Crowd-BT) so that projects that have won
+// or lost every comparison still get a finite strength. The results are sorted by strength.
+func ComputeBradleyTerry(judges []*models.Judge) []*BTScore {
+	pw := newPairwise(judges)
+	n := len(pw.ids)
+
+	// Get the total (weighted) wins for each project
+	wins := make([]float64, n)
+	for i := 0; i < n; i++ {
+		wins[i] = btPrior
+		for j := 0; j < n; j++ {
+			wins[i] += pw.pref[i][j]
+		}
+	}
+
+	// Fit the strengths using the minorization-maximization algorithm (Hunter, 2004).
+	// The virtual opponent always has a strength of 1.
+	pi := make([]float64, n)
+	for i := range pi {
+		pi[i] = 1
+	}
+	for iter := 0; iter < btMaxIters; iter++ {
+		next := make([]float64, n)
+		maxChange := 0.0
+		for i := 0; i < n; i++ {
+			denom := 2 * btPrior / (pi[i] + 1)
+			for j := 0; j < n; j++ {
+				if nij := pw.pref[i][j] + pw.pref[j][i]; nij > 0 {
+					denom += nij / (pi[i] + pi[j])
+				}
+			}
+			next[i] = wins[i] / denom
+			maxChange = max(maxChange, math.Abs(math.Log(next[i])-math.Log(pi[i])))
+		}
+		pi = next
+		if maxChange < btTolerance {
+			break
+		}
+	}
+
+	// Calculate the confidence interval of each strength from the Fisher information
+	out := make([]*BTScore, 0, n)
+	for i, id := range pw.ids {
+		info := 2 * btPrior * pi[i] / ((pi[i] + 1) * (pi[i] + 1))
+		for j := 0; j < n; j++ {
+			if nij := pw.pref[i][j] + pw.pref[j][i]; nij > 0 {
+				info += nij * pi[i] * pi[j] / ((pi[i] + pi[j]) * (pi[i] + pi[j]))
+			}
+		}
+		strength := math.Log(pi[i])
+		margin := btZ / math.Sqrt(info)
+		out = append(out, &BTScore{
+			ProjectId:   id,
+			Strength:    strength,
+			Lower:       strength - margin,
+			Upper:       strength + margin,
+			Comparisons: pw.comparisons[i],
+		})
+	}
+
+	slices.SortStableFunc(out, func(a, b *BTScore) int {
+		if a.Strength > b.Strength {
+			return -1
+		} else if a.Strength < b.Strength {
+			return 1
+		}
+		return 0
+	})
+
+	return out
+}
@@ -0,0 +1,33 @@
+package judging
+
+import (
+	"math"
+	"server/models"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestComputeBradleyTerry(t *testing.T) {
+	a, b, c := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+
+	// a always wins, so it should have the highest strength with a finite interval
+	judges := []*models.Judge{
+		rankingJudge([]primitive.ObjectID{a, b, c}, 3),
+		rankingJudge([]primitive.ObjectID{a, c, b}, 3),
+		rankingJudge([]primitive.ObjectID{a, b}, 1),
+	}
+
+	scores := ComputeBradleyTerry(judges)
+	if len(scores) != 3 {
+		t.Fatalf("expected 3 scores, got %d", len(scores))
+	}
+	if scores[0].ProjectId != a {
+		t.Errorf("expected a to have the highest strength, got %v", scores)
+	}
+	for _, s := range scores {
+		if math.IsInf(s.Upper, 0) || math.IsNaN(s.Upper) || s.Lower > s.Strength || s.Upper < s.Strength {
+			t.Errorf("invalid confidence interval %v", s)
+		}
+	}
+}
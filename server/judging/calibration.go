@@ -0,0 +1,182 @@
+package judging
+
+import (
+	"fmt"
+	"server/database"
+	"server/models"
+	"server/util"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	minJudgeWeight       = 0.25 // Weight of a judge that disagrees with the consensus on every comparison
+	calibrationMinPairs  = 5    // Judges with fewer pairwise preferences than this are not calibrated
+	calibrationShrinkage = 10.0 // Number of preferences at which a judge's weight is halfway to its agreement-based value
+)
+
+// JudgeWeight returns the weight of the judge's rankings.
+// Judges created before weights existed have a weight of 0 in the database, so they count as 1.
+func JudgeWeight(judge *models.Judge) float64 {
+	if judge.Weight <= 0 {
+		return 1
+	}
+	return judge.Weight
+}
+
+// Calibration is the result of calibrating a single judge
+type Calibration struct {
+	Weight      float64
+	Calibration models.JudgeCalibration
+}
+
+// CalibrateJudges computes an automatic weight for each judge based on how well their rankings
+// agree with everyone else's. The consensus for a judge is the Copeland score of every project
+// over all other judges, so a judge's own rankings never count towards their agreement.
+// Agreement is measured with Kendall tau over the judge's pairwise preferences (see newPairwise),
+// mapped from [-1, 1] to [minJudgeWeight, 1]. Judges with few preferences are shrunk towards 1,
+// since a couple of lucky or unlucky comparisons say little about the judge.
+func CalibrateJudges(judges []*models.Judge) map[primitive.ObjectID]*Calibration {
+	judges = withoutConflicts(judges)
+	consensus := newConsensus(judges)
+
+	out := make(map[primitive.ObjectID]*Calibration, len(judges))
+	for i, judge := range judges {
+		concordant, discordant := consensus.agreement(i, judge)
+		out[judge.Id] = calibrationFromCounts(concordant, discordant)
+	}
+
+	return out
+}
+
+// consensus holds each judge's Copeland scores and the totals across all judges,
+// so that the consensus of everyone but a single judge can be found quickly
+type consensus struct {
+	perJudge []map[primitive.ObjectID]float64
+	total    map[primitive.ObjectID]float64
+}
+
+// newConsensus calculates the Copeland scores of every judge
+func newConsensus(judges []*models.Judge) *consensus {
+	c := &consensus{
+		perJudge: make([]map[primitive.ObjectID]float64, len(judges)),
+		total:    make(map[primitive.ObjectID]float64),
+	}
+	for i, judge := range judges {
+		c.perJudge[i] = make(map[primitive.ObjectID]float64)
+		for _, agg := range AggregateRanking(judge, MethodCopeland) {
+			c.perJudge[i][agg.ProjectId] = agg.Score
+			c.total[agg.ProjectId] += agg.Score
+		}
+	}
+	return c
+}
+
+// without returns the consensus score of a project over all judges except the ith
+func (c *consensus) without(i int, id primitive.ObjectID) float64 {
+	return c.total[id] - c.perJudge[i][id]
+}
+
+// agreement compares every preference of the ith judge to the consensus of all other judges
+// and returns the number of preferences that agree and disagree (ties in the consensus are skipped)
+func (c *consensus) agreement(i int, judge *models.Judge) (concordant int64, discordant int64) {
+	// Get unranked projects
+	var unranked []primitive.ObjectID
+	for _, p := range judge.SeenProjects {
+		if !slices.Contains(judge.Rankings, p.ProjectId) {
+			unranked = append(unranked, p.ProjectId)
+		}
+	}
+
+	// Compare every preference of the judge to the consensus (ties are not preferences)
+	compare := func(a, b primitive.ObjectID) {
+		if c.without(i, a) > c.without(i, b) {
+			concordant++
+		} else if c.without(i, a) < c.without(i, b) {
+			discordant++
+		}
+	}
+	tiers := util.GetRankingTiers(judge)
+	for t, tier := range tiers {
+		for _, a := range tier {
+			for _, lower := range tiers[t+1:] {
+				for _, b := range lower {
+					compare(a, b)
+				}
+			}
+			for _, b := range unranked {
+				compare(a, b)
+			}
+		}
+	}
+	return concordant, discordant
+}
+
+// calibrationFromCounts converts the number of agreeing and disagreeing preferences into a weight
+func calibrationFromCounts(concordant int64, discordant int64) *Calibration {
+	pairs := concordant + discordant
+	if pairs < calibrationMinPairs {
+		return &Calibration{
+			Weight: 1,
+			Calibration: models.JudgeCalibration{
+				Concordant: concordant,
+				Discordant: discordant,
+				Reason:     fmt.Sprintf("Only %d comparable preferences (at least %d needed), so the default weight of 1 is used", pairs, calibrationMinPairs),
+			},
+		}
+	}
+
+	tau := float64(concordant-discordant) / float64(pairs)
+	raw := minJudgeWeight + (1-minJudgeWeight)*(1+tau)/2
+	confidence := float64(pairs) / (float64(pairs) + calibrationShrinkage)
+	weight := 1 + (raw-1)*confidence
+
+	return &Calibration{
+		Weight: weight,
+		Calibration: models.JudgeCalibration{
+			Agreement:  tau,
+			Concordant: concordant,
+			Discordant: discordant,
+			Reason: fmt.Sprintf(
+				"Agreed with the other judges on %d of %d comparable preferences (tau = %.2f), giving a weight of %.2f; with %d preferences the weight is %.0f%% of the way from 1 to that, so the final weight is %.2f",
+				concordant, pairs, tau, raw, pairs, confidence*100, weight,
+			),
+		},
+	}
+}
+
+// RecalibrateJudgeWeights recalculates the calibration of all general judges and updates
+// the weight of every judge whose weight was not set manually
+func RecalibrateJudgeWeights(db *mongo.Database) error {
+	return database.WithTransaction(db, func(sc mongo.SessionContext) error {
+		// Get all general judges (track judges do not rank projects)
+		judges, err := database.FindJudgesByTrack(db, sc, "")
+		if err != nil {
+			return err
+		}
+
+		// Early return if no judges exist
+		if len(judges) == 0 {
+			return nil
+		}
+
+		// Calibrate and update all judges with a bulk write
+		calibrations := CalibrateJudges(judges)
+		models := make([]mongo.WriteModel, 0, len(judges))
+		for _, judge := range judges {
+			c := calibrations[judge.Id]
+			update := gin.H{"calibration": c.Calibration}
+			if !judge.WeightManual {
+				update["weight"] = c.Weight
+			}
+			models = append(models, mongo.NewUpdateOneModel().SetFilter(gin.H{"_id": judge.Id}).SetUpdate(gin.H{"$set": update}))
+		}
+		opts := options.BulkWrite().SetOrdered(false)
+		_, err = db.Collection("judges").BulkWrite(sc, models, opts)
+		return err
+	})
+}
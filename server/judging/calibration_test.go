@@ -0,0 +1,38 @@
+package judging
+
+import (
+	"server/models"
+	"slices"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestCalibrateJudges(t *testing.T) {
+	ids := make([]primitive.ObjectID, 5)
+	for i := range ids {
+		ids[i] = primitive.NewObjectID()
+	}
+	reversed := slices.Clone(ids)
+	slices.Reverse(reversed)
+
+	// Three judges agree on the order and one ranks everything backwards
+	judges := []*models.Judge{
+		rankingJudge(ids, 5),
+		rankingJudge(ids, 5),
+		rankingJudge(ids, 5),
+		rankingJudge(reversed, 5),
+	}
+	for _, judge := range judges {
+		judge.Id = primitive.NewObjectID()
+	}
+
+	calibrations := CalibrateJudges(judges)
+	agree, disagree := calibrations[judges[0].Id], calibrations[judges[3].Id]
+	if agree.Weight <= disagree.Weight {
+		t.Errorf("expected agreeing judge to have a higher weight, got %f and %f", agree.Weight, disagree.Weight)
+	}
+	if agree.Calibration.Agreement != 1 || disagree.Calibration.Agreement != -1 {
+		t.Errorf("expected agreements of 1 and -1, got %f and %f", agree.Calibration.Agreement, disagree.Calibration.Agreement)
+	}
+}
@@ -0,0 +1,103 @@
+package judging
+
+import (
+	"server/models"
+	"sort"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// CombinedScore is a project's final score under the score formula, along with every
+// input to the formula so that the score can be reproduced by hand
+type CombinedScore struct {
+	ProjectId        primitive.ObjectID `json:"project_id"`
+	Name             string             `json:"name"`
+	Location         int64              `json:"location"`
+	Active           bool               `json:"active"`
+	Place            int64              `json:"place"` // Place among active projects by combined score (ties share the same place, 0 if inactive)
+	RankScore        float64            `json:"rank_score"`
+	Stars            int64              `json:"stars"`
+	Seen             int64              `json:"seen"`
+	RubricTotal      float64            `json:"rubric_total"`
+	NormalizedRank   float64            `json:"normalized_rank"`   // Rank score scaled from 0 (lowest active project) to 1 (highest active project)
+	StarsPerView     float64            `json:"stars_per_view"`    // Stars divided by views
+	NormalizedRubric float64            `json:"normalized_rubric"` // Rubric total scaled from 0 (all criteria at min) to 1 (all criteria at max)
+	Combined         float64            `json:"combined"`
+}
+
+// ComputeCombinedScores applies the score formula (see models.ScoreFormula) to the aggregated
+// scores of every project. Rank scores are normalized against the active projects only, so
+// hidden projects don't stretch the scale. The results are sorted by place, with inactive projects last.
+// Options from before the formula existed use the default formula (see models.ScoreFormulaOrDefault).
+func ComputeCombinedScores(projects []*models.Project, scores map[primitive.ObjectID]ProjectScores, formula models.ScoreFormula, criteria []models.Criterion) []*CombinedScore {
+	formula = models.ScoreFormulaOrDefault(formula)
+
+	// Get the range of the rank scores and the rubric totals
+	minRank, maxRank := 0.0, 0.0
+	first := true
+	for _, p := range projects {
+		if !p.Active {
+			continue
+		}
+		s := scores[p.Id].Score
+		if first || s < minRank {
+			minRank = s
+		}
+		if first || s > maxRank {
+			maxRank = s
+		}
+		first = false
+	}
+	var minRubric, maxRubric float64
+	for _, c := range criteria {
+		minRubric += float64(c.Min) * c.Weight
+		maxRubric += float64(c.Max) * c.Weight
+	}
+
+	out := make([]*CombinedScore, 0, len(projects))
+	for _, p := range projects {
+		score := scores[p.Id]
+		cs := &CombinedScore{
+			ProjectId:   p.Id,
+			Name:        p.Name,
+			Location:    p.Location,
+			Active:      p.Active,
+			RankScore:   score.Score,
+			Stars:       score.Stars,
+			Seen:        p.Seen,
+			RubricTotal: score.RubricTotal,
+		}
+		if maxRank > minRank {
+			cs.NormalizedRank = (score.Score - minRank) / (maxRank - minRank)
+		}
+		if p.Seen > 0 {
+			cs.StarsPerView = float64(score.Stars) / float64(p.Seen)
+		}
+		if maxRubric > minRubric && score.RubricScores != nil {
+			cs.NormalizedRubric = (score.RubricTotal - minRubric) / (maxRubric - minRubric)
+		}
+		cs.Combined = formula.RankWeight*cs.NormalizedRank + formula.StarWeight*cs.StarsPerView + formula.RubricWeight*cs.NormalizedRubric
+		out = append(out, cs)
+	}
+
+	// Calculate the place of each active project (ties share the same place)
+	for _, cs := range out {
+		if !cs.Active {
+			continue
+		}
+		cs.Place = 1
+		for _, other := range out {
+			if other.Active && other.Combined > cs.Combined {
+				cs.Place++
+			}
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Active != out[j].Active {
+			return out[i].Active
+		}
+		return out[i].Combined > out[j].Combined
+	})
+
+	return out
+}
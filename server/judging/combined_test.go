@@ -0,0 +1,50 @@
+package judging
+
+import (
+	"math"
+	"server/models"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestComputeCombinedScores(t *testing.T) {
+	projects := []*models.Project{
+		{Id: primitive.NewObjectID(), Location: 1, Active: true, Seen: 4},
+		{Id: primitive.NewObjectID(), Location: 2, Active: true, Seen: 2},
+		{Id: primitive.NewObjectID(), Location: 3, Active: true, Seen: 0},
+		{Id: primitive.NewObjectID(), Location: 4, Active: false, Seen: 5},
+	}
+	scores := map[primitive.ObjectID]ProjectScores{
+		projects[0].Id: {Score: 10, Stars: 1, RubricScores: map[string]float64{"Design": 3}, RubricTotal: 3},
+		projects[1].Id: {Score: 0, Stars: 2, RubricScores: map[string]float64{"Design": 5}, RubricTotal: 5},
+		projects[3].Id: {Score: 100}, // Inactive projects don't stretch the scale
+	}
+	criteria := []models.Criterion{{Name: "Design", Min: 1, Max: 5, Weight: 1}}
+	formula := models.ScoreFormula{RankWeight: 1, StarWeight: 2, RubricWeight: 1}
+
+	combined := ComputeCombinedScores(projects, scores, formula, criteria)
+	expected := map[primitive.ObjectID]float64{
+		projects[0].Id: 1 + 2*0.25 + 0.5,
+		projects[1].Id: 0 + 2*1 + 1,
+		projects[2].Id: 0,
+	}
+	places := map[primitive.ObjectID]int64{projects[0].Id: 2, projects[1].Id: 1, projects[2].Id: 3, projects[3].Id: 0}
+	for _, cs := range combined {
+		if e, ok := expected[cs.ProjectId]; ok && math.Abs(cs.Combined-e) > 1e-9 {
+			t.Errorf("expected combined score %g, got %g", e, cs.Combined)
+		}
+		if cs.Place != places[cs.ProjectId] {
+			t.Errorf("expected place %d, got %d", places[cs.ProjectId], cs.Place)
+		}
+	}
+	if combined[0].ProjectId != projects[1].Id || combined[3].ProjectId != projects[3].Id {
+		t.Errorf("expected results sorted by place with inactive projects last")
+	}
+
+	// Options from before the formula existed only use the rank score
+	combined = ComputeCombinedScores(projects, scores, models.ScoreFormula{}, criteria)
+	if combined[0].ProjectId != projects[0].Id || combined[0].Combined != 1 {
+		t.Errorf("expected the default formula to rank by score, got %+v", combined[0])
+	}
+}
@@ -5,52 +5,139 @@ import (
 	"errors"
 	"server/database"
 	"server/models"
+	"server/util"
 	"sync"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
+// Comparisons is the number of times each pair of projects has been seen by the same judge.
+// Only pairs that have been compared are stored, in both directions (Counts[a][b] == Counts[b][a]).
+// The counts are kept in the comparisons collection and updated incrementally along with it,
+// so they only need to be rebuilt from the judges when the judging data changes wholesale.
 type Comparisons struct {
-	Arr      [][]int                    `json:"arr"`
-	IdNumMap map[primitive.ObjectID]int `json:"id_num_map"`
-	Mutex    sync.Mutex                 `json:"mutex"`
+	Counts    map[primitive.ObjectID]map[primitive.ObjectID]int `json:"counts"`
+	Version   int64                                             `json:"version"` // Version of the stored comparisons these were loaded from
+	Synced    primitive.DateTime                                `json:"synced"`  // When the stored comparisons were last read
+	Strengths *Strengths                                        `json:"-"`       // Cached Bradley-Terry fit for adaptive mode, kept here since it is read by the same picks
+	Mutex     sync.Mutex                                        `json:"mutex"`
 }
 
-// CreateComparisons will create the comparisons array from a list of
-// judges and projects
-func CreateComparisons(projects []*models.Project, judges []*models.Judge) *Comparisons {
-	// Create comps object
-	comps := Comparisons{
-		Arr:      make([][]int, len(projects)),
-		IdNumMap: make(map[primitive.ObjectID]int),
-		Mutex:    sync.Mutex{},
-	}
+// syncOverlap is how far back each sync re-reads the stored comparisons, since a pair can be
+// updated by a transaction that only commits after a sync that started later has already run
+const syncOverlap = time.Minute
 
-	// Fill array
-	for i := range comps.Arr {
-		comps.Arr[i] = make([]int, len(projects))
+// NewComparisons creates an empty comparisons object
+func NewComparisons() *Comparisons {
+	return &Comparisons{
+		Counts:    make(map[primitive.ObjectID]map[primitive.ObjectID]int),
+		Strengths: NewStrengths(),
 	}
+}
 
-	// Loop through projects and create the map
-	for i, p := range projects {
-		comps.IdNumMap[p.Id] = i
-	}
+// CreateComparisons will create the comparisons from the projects
+// that each judge has seen
+func CreateComparisons(judges []*models.Judge) *Comparisons {
+	comps := NewComparisons()
 
-	// For each judge, add their comparisons
+	// For each judge, add every pair of projects they've seen
 	for _, j := range judges {
-		// Loop through list of judged projects and add each comparison to the matrix
 		for i, ap := range j.SeenProjects {
 			for _, bp := range j.SeenProjects[i+1:] {
-				aid := comps.IdNumMap[ap.ProjectId]
-				bid := comps.IdNumMap[bp.ProjectId]
-				comps.Arr[aid][bid]++
-				comps.Arr[bid][aid]++
+				comps.add(ap.ProjectId, bp.ProjectId, 1)
+			}
+		}
+	}
+
+	return comps
+}
+
+// add adds n to the count of a pair of projects, removing the pair if it drops to 0 or below.
+// Like the stored counts, a pair never goes negative. The mutex MUST be held by the caller.
+func (c *Comparisons) add(a primitive.ObjectID, b primitive.ObjectID, n int) {
+	if a == b {
+		return
+	}
+	for _, pair := range [][2]primitive.ObjectID{{a, b}, {b, a}} {
+		row, ok := c.Counts[pair[0]]
+		if !ok {
+			row = make(map[primitive.ObjectID]int)
+			c.Counts[pair[0]] = row
+		}
+		row[pair[1]] += n
+		if row[pair[1]] <= 0 {
+			delete(row, pair[1])
+		}
+		if len(row) == 0 {
+			delete(c.Counts, pair[0])
+		}
+	}
+}
+
+// set sets the count of a pair of projects. The mutex MUST be held by the caller.
+func (c *Comparisons) set(a primitive.ObjectID, b primitive.ObjectID, n int) {
+	c.add(a, b, n-c.Counts[a][b])
+}
+
+// Count returns the number of times a pair of projects has been compared
+func (c *Comparisons) Count(a primitive.ObjectID, b primitive.ObjectID) int {
+	c.Mutex.Lock()
+	defer c.Mutex.Unlock()
+
+	return c.Counts[a][b]
+}
+
+// Pairs returns every compared pair of projects, once each, to be stored in the database
+func (c *Comparisons) Pairs() []*models.Comparison {
+	c.Mutex.Lock()
+	defer c.Mutex.Unlock()
+
+	pairs := []*models.Comparison{}
+	for a, row := range c.Counts {
+		for b, count := range row {
+			comp := models.NewComparison(a, b, int64(count))
+			if comp.A == a {
+				pairs = append(pairs, comp)
 			}
 		}
 	}
+	return pairs
+}
 
-	return &comps
+// SeenComparisons returns the pairs of the new project with each of the previously seen
+// projects, each with the given count. Use 1 when a judge has just seen the project
+// and -1 to take the pairs back out.
+func SeenComparisons(prevSeen []models.JudgedProject, newProj primitive.ObjectID, count int64) []*models.Comparison {
+	pairs := make([]*models.Comparison, 0, len(prevSeen))
+	for _, v := range prevSeen {
+		if v.ProjectId == newProj {
+			continue
+		}
+		pairs = append(pairs, models.NewComparison(v.ProjectId, newProj, count))
+	}
+	return pairs
+}
+
+// JudgeComparisons returns every pair of projects the judge has seen, each with the given count
+func JudgeComparisons(judge *models.Judge, count int64) []*models.Comparison {
+	var pairs []*models.Comparison
+	for i, p := range judge.SeenProjects {
+		pairs = append(pairs, SeenComparisons(judge.SeenProjects[:i], p.ProjectId, count)...)
+	}
+	return pairs
+}
+
+// ApplyComparisons adds the counts of the given pairs, the same way as database.IncrementComparisons.
+// This should be called after the pairs are stored in the database.
+func (c *Comparisons) ApplyComparisons(pairs []*models.Comparison) {
+	c.Mutex.Lock()
+	defer c.Mutex.Unlock()
+
+	for _, p := range pairs {
+		c.add(p.A, p.B, int(p.Count))
+	}
 }
 
 func LoadComparisons(db *mongo.Database) (*Comparisons, error) {
@@ -73,131 +160,157 @@ func LoadComparisons(db *mongo.Database) (*Comparisons, error) {
 	return comps, nil
 }
 
-// LoadComparisonsWithTx will create the comparisons from the database
+// LoadComparisonsWithTx will load the comparisons stored in the database.
+// Databases from before the comparisons were stored have none, so if any judge has
+// seen more than one project the comparisons are rebuilt from the judges and stored.
 func LoadComparisonsWithTx(db *mongo.Database, ctx context.Context) (*Comparisons, error) {
-	// Get all judges
-	judges, err := database.FindAllJudges(db, ctx)
+	// Get the version before the comparisons, so a replacement in between is picked up by the next sync
+	synced := util.Now()
+	version, err := database.GetComparisonsVersion(db, ctx)
 	if err != nil {
 		return nil, err
 	}
-
-	// Get all project
-	projects, err := database.FindAllProjects(db, ctx)
+	stored, err := database.FindAllComparisons(db, ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	return CreateComparisons(projects, judges), nil
-}
-
-// AddProjectToComparisons will increase the size of the comparisons array when
-// adding a project to the database
-func (c *Comparisons) AddProjectToComparisons(p *models.Project) error {
-	c.Mutex.Lock()
-	defer c.Mutex.Unlock()
+	if len(stored) == 0 {
+		return rebuildComparisons(db, ctx)
+	}
 
-	// Add project to map
-	num := len(c.IdNumMap)
-	c.IdNumMap[p.Id] = num
+	comps := NewComparisons()
+	comps.ApplyComparisons(stored)
+	comps.Version = version
+	comps.Synced = synced
+	return comps, nil
+}
 
-	// Increase size of matrix
-	for i := range c.Arr {
-		c.Arr[i] = append(c.Arr[i], 0)
+// rebuildComparisons creates the comparisons from all judges and replaces the stored comparisons with them
+func rebuildComparisons(db *mongo.Database, ctx context.Context) (*Comparisons, error) {
+	synced := util.Now()
+	judges, err := database.FindAllJudges(db, ctx)
+	if err != nil {
+		return nil, err
 	}
-	c.Arr = append(c.Arr, make([]int, num+1))
 
-	return nil
+	comps := CreateComparisons(judges)
+	err = database.ReplaceComparisons(db, ctx, comps.Pairs())
+	if err != nil {
+		return nil, err
+	}
+	comps.Version, err = database.GetComparisonsVersion(db, ctx)
+	if err != nil {
+		return nil, err
+	}
+	comps.Synced = synced
+	return comps, nil
 }
 
-// RemoveProjectFromComparison removes a project from the comparisons array.
-// This is a really expensive function, but should only be run when projects are deleted,
-// which really shouldn't happen all that often.
-func (c *Comparisons) RemoveProjectFromComparison(id primitive.ObjectID) error {
-	c.Mutex.Lock()
-	defer c.Mutex.Unlock()
-
-	// Get project to remove
-	removeId := c.IdNumMap[id]
+// SyncComparisons brings the comparisons up to date with the stored comparisons, which may have been
+// changed by other instances of the server. Only the pairs updated since the last sync are read,
+// unless the stored comparisons have been replaced since, in which case all of them are reloaded.
+func SyncComparisons(db *mongo.Database, ctx context.Context, comps *Comparisons) error {
+	synced := util.Now()
+	version, err := database.GetComparisonsVersion(db, ctx)
+	if err != nil {
+		return err
+	}
 
-	// Remove project from each row
-	for i, v := range c.Arr {
-		c.Arr[i] = removeSliceItem(v, removeId)
+	comps.Mutex.Lock()
+	reload := version != comps.Version
+	since := primitive.NewDateTimeFromTime(comps.Synced.Time().Add(-syncOverlap))
+	comps.Mutex.Unlock()
+	if reload {
+		since = 0
 	}
 
-	// Remove the row itself
-	c.Arr = removeSliceItem(c.Arr, removeId)
+	stored, err := database.FindComparisonsUpdatedSince(db, ctx, since)
+	if err != nil {
+		return err
+	}
 
+	comps.Mutex.Lock()
+	defer comps.Mutex.Unlock()
+	if reload {
+		comps.Counts = make(map[primitive.ObjectID]map[primitive.ObjectID]int)
+	}
+	for _, p := range stored {
+		comps.set(p.A, p.B, int(p.Count))
+	}
+	comps.Version = version
+	comps.Synced = synced
 	return nil
 }
 
-// removeSliceItem removes an item from a slice
-func removeSliceItem[T any](slice []T, s int) []T {
-	return append(slice[:s], slice[s+1:]...)
-}
-
-// Once a judge has judged a project, add all pairs of that project to
-// the projects that the judge has judged to the array
-func (c *Comparisons) UpdateProjectComparisonCount(prevSeen []models.JudgedProject, newProj primitive.ObjectID) {
+// RemoveProjectFromComparison removes all pairs with a project from the comparisons.
+// The stored pairs should be removed with database.DeleteProjectComparisons.
+func (c *Comparisons) RemoveProjectFromComparison(id primitive.ObjectID) {
 	c.Mutex.Lock()
 	defer c.Mutex.Unlock()
 
-	// Increment the indices of the current project + all prev projects
-	newProjId := c.IdNumMap[newProj]
-	for _, v := range prevSeen {
-		vId := c.IdNumMap[v.ProjectId]
-		c.Arr[vId][newProjId]++
-		c.Arr[newProjId][vId]++
+	for other := range c.Counts[id] {
+		delete(c.Counts[other], id)
+		if len(c.Counts[other]) == 0 {
+			delete(c.Counts, other)
+		}
 	}
+	delete(c.Counts, id)
 }
 
 // FindLeastCompared finds the project that has been compared the LEAST
 // to all other projects. Projects param MUST not be empty.
 func (c *Comparisons) FindLeastCompared(projects []*models.Project, prevSeen []models.JudgedProject) *models.Project {
-	c.Mutex.Lock()
-	defer c.Mutex.Unlock()
-
-	if len(projects) == 0 {
+	least := c.LeastCompared(projects, prevSeen)
+	if len(least) == 0 {
 		return nil
 	}
+	return least[0]
+}
 
-	minProj := projects[0]
+// LeastCompared finds all projects that are tied for being compared the LEAST
+// to the previously seen projects, in the same order as the given projects
+func (c *Comparisons) LeastCompared(projects []*models.Project, prevSeen []models.JudgedProject) []*models.Project {
+	c.Mutex.Lock()
+	defer c.Mutex.Unlock()
+
+	var least []*models.Project
 	min := 0x7FFFFFFF
-	// Loop through all potential projects and find the one with the most seen
+	// Loop through all potential projects and find the ones with the least comparisons
 	for _, v := range projects {
 		curr := 0
-		vId := c.IdNumMap[v.Id]
+		row := c.Counts[v.Id]
 		for _, p := range prevSeen {
-			pId := c.IdNumMap[p.ProjectId]
-			curr += c.Arr[vId][pId]
+			curr += row[p.ProjectId]
 		}
 		if curr < min {
 			min = curr
-			minProj = v
+			least = []*models.Project{v}
+		} else if curr == min {
+			least = append(least, v)
 		}
 	}
 
-	return minProj
+	return least
 }
 
-// ReloadComparisons will reload the comparisons from the database
+// ReloadComparisons will rebuild the comparisons from the judges, replacing both the
+// stored comparisons and the given comparisons object. Use this when the seen projects of
+// many judges change at once, such as when closing a round or resetting the judging data.
 func ReloadComparisons(db *mongo.Database, ctx context.Context, comparisons *Comparisons) error {
-	new_comps, err := LoadComparisonsWithTx(db, ctx)
+	new_comps, err := rebuildComparisons(db, ctx)
 	if err != nil {
 		return err
 	}
 
-	// Remove all old comparisons
-	comparisons.Arr = make([][]int, len(new_comps.Arr))
-	comparisons.IdNumMap = make(map[primitive.ObjectID]int)
+	// The judging data changed wholesale, so the rankings have too
+	comparisons.Strengths.Invalidate()
 
-	// Add all new comparisons
-	for k, v := range new_comps.IdNumMap {
-		comparisons.IdNumMap[k] = v
-	}
-	for i, v := range new_comps.Arr {
-		comparisons.Arr[i] = make([]int, len(v))
-		copy(comparisons.Arr[i], v)
-	}
+	comparisons.Mutex.Lock()
+	defer comparisons.Mutex.Unlock()
+	comparisons.Counts = new_comps.Counts
+	comparisons.Version = new_comps.Version
+	comparisons.Synced = new_comps.Synced
 
 	return nil
 }
@@ -0,0 +1,103 @@
+package judging
+
+import (
+	"server/models"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestSparseComparisons(t *testing.T) {
+	projects := make([]*models.Project, 4)
+	for i := range projects {
+		projects[i] = &models.Project{Id: primitive.NewObjectID()}
+	}
+	seen := func(ps ...*models.Project) []models.JudgedProject {
+		out := []models.JudgedProject{}
+		for _, p := range ps {
+			out = append(out, models.JudgedProject{ProjectId: p.Id})
+		}
+		return out
+	}
+
+	// Two judges both compared projects 0 and 1, one also compared them to project 2
+	a := models.NewJudge("a", "", "", "", 0)
+	a.SeenProjects = seen(projects[0], projects[1], projects[2])
+	b := models.NewJudge("b", "", "", "", 0)
+	b.SeenProjects = seen(projects[1], projects[0])
+	comps := CreateComparisons([]*models.Judge{a, b})
+	if comps.Count(projects[0].Id, projects[1].Id) != 2 || comps.Count(projects[1].Id, projects[0].Id) != 2 {
+		t.Errorf("expected projects 0 and 1 to be compared twice in both directions")
+	}
+	if comps.Count(projects[0].Id, projects[3].Id) != 0 || len(comps.Counts[projects[3].Id]) != 0 {
+		t.Errorf("expected no comparisons to be stored for project 3")
+	}
+	if pairs := comps.Pairs(); len(pairs) != 3 {
+		t.Errorf("expected 3 stored pairs, got %d", len(pairs))
+	}
+
+	// Project 3 has never been compared to project 0
+	least := comps.LeastCompared(projects[2:], seen(projects[0]))
+	if len(least) != 1 || least[0] != projects[3] {
+		t.Errorf("expected project 3 to be the least compared")
+	}
+
+	// Judge b sees project 3, then is removed
+	pairs := SeenComparisons(b.SeenProjects, projects[3].Id, 1)
+	comps.ApplyComparisons(pairs)
+	if comps.Count(projects[3].Id, projects[1].Id) != 1 {
+		t.Errorf("expected project 3 to be compared with project 1 once")
+	}
+	b.SeenProjects = append(b.SeenProjects, seen(projects[3])...)
+	comps.ApplyComparisons(JudgeComparisons(b, -1))
+	if comps.Count(projects[0].Id, projects[1].Id) != 1 || len(comps.Counts[projects[3].Id]) != 0 {
+		t.Errorf("expected only judge a's comparisons to be left")
+	}
+
+	// Deleting project 1 removes it from its neighbours too
+	comps.RemoveProjectFromComparison(projects[1].Id)
+	if _, ok := comps.Counts[projects[0].Id][projects[1].Id]; ok {
+		t.Errorf("expected project 1 to be removed from project 0's comparisons")
+	}
+	if comps.Count(projects[0].Id, projects[2].Id) != 1 {
+		t.Errorf("expected the other comparisons to be kept")
+	}
+}
+
+func TestDeleteProjectThenJudgeComparisons(t *testing.T) {
+	p1, p2, p3 := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	judge := models.NewJudge("a", "", "", "", 0)
+	judge.SeenProjects = []models.JudgedProject{{ProjectId: p1}, {ProjectId: p2}, {ProjectId: p3}}
+	comps := CreateComparisons([]*models.Judge{judge})
+
+	// Project 1 is deleted, then the judge that saw it is deleted
+	comps.RemoveProjectFromComparison(p1)
+	comps.ApplyComparisons(JudgeComparisons(judge, -1))
+	if len(comps.Counts) != 0 {
+		t.Errorf("expected no comparisons to be left, got %v", comps.Counts)
+	}
+
+	// The pairs with project 1 start again from 0 rather than from -1
+	comps.ApplyComparisons(SeenComparisons(judge.SeenProjects[:1], p2, 1))
+	if comps.Count(p1, p2) != 1 || comps.Count(p2, p1) != 1 {
+		t.Errorf("expected projects 1 and 2 to be compared once, got %d", comps.Count(p1, p2))
+	}
+}
+
+func TestSetComparisonCounts(t *testing.T) {
+	a, b := primitive.NewObjectID(), primitive.NewObjectID()
+	comps := NewComparisons()
+
+	// Synced counts are absolute, so applying the same stored pair twice doesn't double count
+	comps.set(a, b, 3)
+	comps.set(b, a, 3)
+	if comps.Count(a, b) != 3 || comps.Count(b, a) != 3 {
+		t.Errorf("expected the pair to be compared 3 times, got %d", comps.Count(a, b))
+	}
+
+	// A pair stored with a count of 0 was removed by another instance
+	comps.set(a, b, 0)
+	if len(comps.Counts) != 0 {
+		t.Errorf("expected the pair to be removed, got %v", comps.Counts)
+	}
+}
@@ -0,0 +1,110 @@
+package judging
+
+import (
+	"fmt"
+	"server/models"
+	"slices"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// publicEmailDomains are email providers that anyone can sign up for,
+// so sharing one of them is not a conflict of interest
+var publicEmailDomains = []string{
+	"gmail.com", "googlemail.com", "yahoo.com", "outlook.com", "hotmail.com", "live.com",
+	"msn.com", "icloud.com", "me.com", "aol.com", "protonmail.com", "proton.me", "mail.com",
+}
+
+// WithoutConflicts returns a copy of the judge with every project the judge has a conflict of
+// interest with removed from their seen projects and rankings, so none of it counts towards results.
+// The judge is returned as is if they have no conflicts.
+func WithoutConflicts(judge *models.Judge) *models.Judge {
+	if len(judge.Conflicts) == 0 {
+		return judge
+	}
+
+	out := *judge
+	out.SeenProjects = make([]models.JudgedProject, 0, len(judge.SeenProjects))
+	for _, p := range judge.SeenProjects {
+		if !slices.Contains(judge.Conflicts, p.ProjectId) {
+			out.SeenProjects = append(out.SeenProjects, p)
+		}
+	}
+	out.Rankings = make([]primitive.ObjectID, 0, len(judge.Rankings))
+	for _, id := range judge.Rankings {
+		if !slices.Contains(judge.Conflicts, id) {
+			out.Rankings = append(out.Rankings, id)
+		}
+	}
+	out.RankingTiers = make([][]primitive.ObjectID, 0, len(judge.RankingTiers))
+	for _, tier := range judge.RankingTiers {
+		var kept []primitive.ObjectID
+		for _, id := range tier {
+			if !slices.Contains(judge.Conflicts, id) {
+				kept = append(kept, id)
+			}
+		}
+		if len(kept) > 0 {
+			out.RankingTiers = append(out.RankingTiers, kept)
+		}
+	}
+	return &out
+}
+
+// withoutConflicts removes the conflicted projects from every judge (see WithoutConflicts)
+func withoutConflicts(judges []*models.Judge) []*models.Judge {
+	out := make([]*models.Judge, len(judges))
+	for i, judge := range judges {
+		out[i] = WithoutConflicts(judge)
+	}
+	return out
+}
+
+// MatchConflicts finds conflicts of interest between judges and projects automatically.
+// A judge is conflicted with a project if their email domain matches the email domain of a team
+// member (ignoring public email providers), or if one of their affiliations matches one of
+// the team's universities (ignoring case).
+func MatchConflicts(judges []*models.Judge, projects []*models.Project) []*models.Conflict {
+	out := []*models.Conflict{}
+	for _, judge := range judges {
+		domain := emailDomain(judge.Email)
+		if slices.Contains(publicEmailDomains, domain) {
+			domain = ""
+		}
+
+		for _, project := range projects {
+			if slices.Contains(judge.Conflicts, project.Id) {
+				continue
+			}
+
+			if domain != "" && slices.ContainsFunc(project.TeamEmails, func(email string) bool { return emailDomain(email) == domain }) {
+				out = append(out, models.NewConflict(judge, project, "email-domain", fmt.Sprintf("Judge and a team member both have %s emails", domain)))
+				continue
+			}
+
+			for _, affiliation := range judge.Affiliations {
+				if strings.TrimSpace(affiliation) == "" {
+					continue
+				}
+				idx := slices.IndexFunc(project.Universities, func(u string) bool {
+					return strings.EqualFold(strings.TrimSpace(u), strings.TrimSpace(affiliation))
+				})
+				if idx != -1 {
+					out = append(out, models.NewConflict(judge, project, "university", fmt.Sprintf("Judge and the team are both from %s", project.Universities[idx])))
+					break
+				}
+			}
+		}
+	}
+	return out
+}
+
+// emailDomain returns the lowercase domain of an email address, or "" if there is none
+func emailDomain(email string) string {
+	at := strings.LastIndex(email, "@")
+	if at == -1 {
+		return ""
+	}
+	return strings.ToLower(strings.TrimSpace(email[at+1:]))
+}
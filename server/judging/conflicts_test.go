@@ -0,0 +1,58 @@
+package judging
+
+import (
+	"server/models"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestMatchConflicts(t *testing.T) {
+	judge := models.NewJudge("Test Judge", "judge@utdallas.edu", "", "", 0)
+	judge.Id = primitive.NewObjectID()
+	judge.Affiliations = []string{"Rice University"}
+	public := models.NewJudge("Public Judge", "judge@gmail.com", "", "", 0)
+	public.Id = primitive.NewObjectID()
+
+	sameDomain := &models.Project{Id: primitive.NewObjectID(), TeamEmails: []string{"student@UTDallas.edu"}}
+	sameUniversity := &models.Project{Id: primitive.NewObjectID(), TeamEmails: []string{"student@gmail.com"}, Universities: []string{" rice university"}}
+	unrelated := &models.Project{Id: primitive.NewObjectID(), TeamEmails: []string{"student@gmail.com"}, Universities: []string{""}}
+
+	conflicts := MatchConflicts([]*models.Judge{judge, public}, []*models.Project{sameDomain, sameUniversity, unrelated})
+	if len(conflicts) != 2 {
+		t.Fatalf("expected 2 conflicts, got %d", len(conflicts))
+	}
+	if conflicts[0].ProjectId != sameDomain.Id || conflicts[0].Source != "email-domain" {
+		t.Errorf("expected email domain conflict, got %+v", conflicts[0])
+	}
+	if conflicts[1].ProjectId != sameUniversity.Id || conflicts[1].Source != "university" {
+		t.Errorf("expected university conflict, got %+v", conflicts[1])
+	}
+
+	// Existing conflicts aren't matched again
+	judge.Conflicts = []primitive.ObjectID{sameDomain.Id, sameUniversity.Id}
+	if conflicts := MatchConflicts([]*models.Judge{judge}, []*models.Project{sameDomain, sameUniversity}); len(conflicts) != 0 {
+		t.Errorf("expected no new conflicts, got %d", len(conflicts))
+	}
+}
+
+func TestAggregateRankingConflicts(t *testing.T) {
+	a, b, c := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	judge := rankingJudge([]primitive.ObjectID{a, b, c}, 3)
+	judge.Conflicts = []primitive.ObjectID{a}
+
+	// The conflicted project is thrown out entirely
+	agg := AggregateRanking(judge, MethodCopeland)
+	expected := map[primitive.ObjectID]float64{b: 1, c: -1}
+	if len(agg) != 2 {
+		t.Fatalf("expected 2 ranked projects, got %d", len(agg))
+	}
+	for _, r := range agg {
+		if r.Score != expected[r.ProjectId] {
+			t.Errorf("expected score %g, got %g", expected[r.ProjectId], r.Score)
+		}
+	}
+	if len(judge.Rankings) != 3 {
+		t.Errorf("expected the judge's own rankings to be untouched")
+	}
+}
@@ -0,0 +1,202 @@
+package judging
+
+import (
+	"context"
+	"server/database"
+	"server/models"
+	"server/util"
+	"slices"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ScoreExplanation is all of the evidence behind a project's score
+type ScoreExplanation struct {
+	ProjectId   primitive.ObjectID `json:"project_id"`
+	Name        string             `json:"name"`
+	Location    int64              `json:"location"`
+	Method      string             `json:"method"` // Ranking method used for the score
+	Score       float64            `json:"score"`
+	Place       int64              `json:"place"` // Place among all projects by score (ties share the same place)
+	Stars       int64              `json:"stars"`
+	TrackScores map[string]float64 `json:"track_scores"`
+	TrackStars  map[string]int64   `json:"track_stars"`
+	Judges      []*JudgeEvidence   `json:"judges"`
+	Matchups    []*Matchup         `json:"matchups"`
+	Flags       []*models.Flag     `json:"flags"`
+}
+
+// JudgeEvidence is how a single judge that saw the project judged it
+type JudgeEvidence struct {
+	JudgeId   primitive.ObjectID   `json:"judge_id"`
+	JudgeName string               `json:"judge_name"`
+	Track     string               `json:"track"`
+	Weight    float64              `json:"weight"`
+	Rank      int64                `json:"rank"`  // Tier the project was ranked in, starting at 1 (0 if unranked)
+	Tiers     int64                `json:"tiers"` // Number of tiers the judge ranked
+	Seen      int64                `json:"seen"`  // Number of projects the judge has seen
+	TiedWith  []primitive.ObjectID `json:"tied_with"`
+	Score     float64              `json:"score"` // Score the judge gave the project with the ranking method (before weighting)
+	Starred   bool                 `json:"starred"`
+	Notes     string               `json:"notes"`
+}
+
+// Matchup is the head-to-head record of the project against another project, over all
+// general judges that saw both. This follows the same rules as the pairwise matrix
+// (see newPairwise): unranked projects lose to ranked ones and are not compared to each other.
+type Matchup struct {
+	OpponentId       primitive.ObjectID `json:"opponent_id"`
+	OpponentName     string             `json:"opponent_name"`
+	OpponentLocation int64              `json:"opponent_location"`
+	Wins             int64              `json:"wins"`
+	Losses           int64              `json:"losses"`
+	Draws            int64              `json:"draws"`
+	WeightedMargin   float64            `json:"weighted_margin"` // Weighted wins minus weighted losses
+}
+
+// ExplainScore collects every judge that saw the project, where they ranked it,
+// its head-to-head record against every other project, and its stars and flags
+func ExplainScore(db *mongo.Database, ctx context.Context, project *models.Project) (*ScoreExplanation, error) {
+	// Get the options for the ranking method
+	op, err := database.GetOptions(db, ctx)
+	if err != nil {
+		return nil, err
+	}
+	method := NormalizeRankingMethod(op.RankingMethod)
+
+	out := &ScoreExplanation{
+		ProjectId:   project.Id,
+		Name:        project.Name,
+		Location:    project.Location,
+		Method:      method,
+		TrackScores: map[string]float64{},
+		TrackStars:  map[string]int64{},
+		Judges:      []*JudgeEvidence{},
+		Matchups:    []*Matchup{},
+	}
+
+	// Get the final scores and the place of the project
+	scores, err := AggregateScores(db, ctx)
+	if err != nil {
+		return nil, err
+	}
+	if s, ok := scores[project.Id]; ok {
+		out.Score = s.Score
+		out.Stars = s.Stars
+		if s.TrackScores != nil {
+			out.TrackScores = s.TrackScores
+		}
+		if s.TrackStars != nil {
+			out.TrackStars = s.TrackStars
+		}
+	}
+	out.Place = 1
+	for id, s := range scores {
+		if id != project.Id && s.Score > out.Score {
+			out.Place++
+		}
+	}
+
+	// Get the flags
+	out.Flags, err = database.FindFlagsByProject(db, ctx, &project.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Go through every judge that saw the project
+	judges, err := database.FindAllJudges(db, ctx)
+	if err != nil {
+		return nil, err
+	}
+	matchups := make(map[primitive.ObjectID]*Matchup)
+	for _, judge := range judges {
+		// Conflicted judges don't count towards the score
+		judge = WithoutConflicts(judge)
+		idx := util.FindSeenProjectIndex(judge, project.Id)
+		if idx == -1 {
+			continue
+		}
+
+		tiers := util.GetRankingTiers(judge)
+		tierOf := rankingTierIndex(tiers)
+		evidence := &JudgeEvidence{
+			JudgeId:   judge.Id,
+			JudgeName: judge.Name,
+			Track:     judge.Track,
+			Weight:    JudgeWeight(judge),
+			Tiers:     int64(len(tiers)),
+			Seen:      int64(len(judge.SeenProjects)),
+			TiedWith:  []primitive.ObjectID{},
+			Starred:   judge.SeenProjects[idx].Starred,
+			Notes:     judge.SeenProjects[idx].Notes,
+		}
+		if t, ok := tierOf[project.Id]; ok {
+			evidence.Rank = int64(t + 1)
+			for _, id := range tiers[t] {
+				if id != project.Id {
+					evidence.TiedWith = append(evidence.TiedWith, id)
+				}
+			}
+		}
+		for _, agg := range AggregateRanking(judge, method) {
+			if agg.ProjectId == project.Id {
+				evidence.Score = agg.Score
+			}
+		}
+		out.Judges = append(out.Judges, evidence)
+
+		// Only general judges count towards the head-to-head record
+		if judge.Track != "" {
+			continue
+		}
+		t, ranked := tierOf[project.Id]
+		for _, other := range judge.SeenProjects {
+			if other.ProjectId == project.Id {
+				continue
+			}
+			u, otherRanked := tierOf[other.ProjectId]
+			if !ranked && !otherRanked {
+				continue
+			}
+
+			m, ok := matchups[other.ProjectId]
+			if !ok {
+				m = &Matchup{OpponentId: other.ProjectId, OpponentName: other.Name, OpponentLocation: other.Location}
+				matchups[other.ProjectId] = m
+			}
+
+			switch {
+			case ranked && (!otherRanked || t < u):
+				m.Wins++
+				m.WeightedMargin += evidence.Weight
+			case otherRanked && (!ranked || u < t):
+				m.Losses++
+				m.WeightedMargin -= evidence.Weight
+			default:
+				m.Draws++
+			}
+		}
+	}
+
+	// Sort the matchups by table number
+	for _, m := range matchups {
+		out.Matchups = append(out.Matchups, m)
+	}
+	slices.SortFunc(out.Matchups, func(a, b *Matchup) int {
+		return int(a.OpponentLocation - b.OpponentLocation)
+	})
+
+	return out, nil
+}
+
+// rankingTierIndex maps each ranked project to the index of its tier
+func rankingTierIndex(tiers [][]primitive.ObjectID) map[primitive.ObjectID]int {
+	out := make(map[primitive.ObjectID]int)
+	for i, tier := range tiers {
+		for _, id := range tier {
+			out[id] = i
+		}
+	}
+	return out
+}
@@ -8,21 +8,22 @@ import (
 	"server/models"
 	"server/util"
 	"slices"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
-func SkipCurrentProject(db *mongo.Database, judge *models.Judge, comps *Comparisons, reason string, getNew bool) error {
+func SkipCurrentProject(db *mongo.Database, judge *models.Judge, comps *Comparisons, reason string, getNew bool, clock int64) error {
 	return database.WithTransaction(db, func(sc mongo.SessionContext) error {
-		return SkipCurrentProjectWithTx(db, sc, judge, comps, reason, getNew)
+		return SkipCurrentProjectWithTx(db, sc, judge, comps, reason, getNew, clock)
 	})
 }
 
 // SkipCurrentProjectWithTx skips the current project for a judge.
 // This is in the judging module instead of the database module to avoid dependency cycles.
-// This should be run in a transaction.
-func SkipCurrentProjectWithTx(db *mongo.Database, ctx context.Context, judge *models.Judge, comps *Comparisons, reason string, getNew bool) error {
+// This should be run in a transaction. clock is the current judging clock time in ms, used for the time budget.
+func SkipCurrentProjectWithTx(db *mongo.Database, ctx context.Context, judge *models.Judge, comps *Comparisons, reason string, getNew bool, clock int64) error {
 	// Get skipped project from database
 	skippedProject, err := database.FindProject(db, ctx, judge.Current)
 	if err != nil {
@@ -60,7 +61,7 @@ func SkipCurrentProjectWithTx(db *mongo.Database, ctx context.Context, judge *mo
 		return err
 	}
 
-	// Hide the project if it has been skipped for absent more than 3 times
+	// Hide the project if it has been skipped for absent too many times
 	err = HideAbsentProject(db, ctx, skippedProject)
 	if err != nil {
 		return errors.New("error hiding absent project: " + err.Error())
@@ -72,7 +73,7 @@ func SkipCurrentProjectWithTx(db *mongo.Database, ctx context.Context, judge *mo
 	}
 
 	// Get a new project
-	project, err := PickNextProject(db, ctx, judge, comps)
+	project, err := PickNextProject(NewDbStore(db), ctx, judge, comps, clock)
 	if err != nil {
 		return err
 	}
@@ -82,21 +83,33 @@ func SkipCurrentProjectWithTx(db *mongo.Database, ctx context.Context, judge *mo
 	}
 
 	// Update the judge
-	return database.UpdateAfterPicked(db, ctx, project, judge)
+	options, err := database.GetOptions(db, ctx)
+	if err != nil {
+		return err
+	}
+	return database.UpdateAfterPicked(db, ctx, project, judge, LeaseExpiry(options, time.Now()), clock)
 }
 
-// HideAbsentProject hides a project if it has been absent more than 3 times.
+// HideAbsentProject hides a project if it has been absent as many times as the absent limit option.
+// Absences while the team was away for a known reason (see models.AwayWindow) don't count.
+// The absent flags are kept as the project's absence history, but stop counting once it is hidden.
 func HideAbsentProject(db *mongo.Database, ctx context.Context, project *models.Project) error {
 	projectId := &project.Id
 
+	// Get the absent limit
+	options, err := database.GetOptions(db, ctx)
+	if err != nil {
+		return errors.New("Error getting options: " + err.Error())
+	}
+
 	// Get absent count
-	absent, err := database.GetProjectAbsentCount(db, ctx, projectId)
+	absent, err := database.GetProjectAbsentCount(db, ctx, project)
 	if err != nil {
 		return errors.New("Error getting absent count: " + err.Error())
 	}
 
-	// If no more than 3 absences, don't do anything
-	if absent < 3 {
+	// If fewer absences than the limit, don't do anything
+	if int64(absent) < AbsentLimit(options) {
 		return nil
 	}
 
@@ -106,10 +119,10 @@ func HideAbsentProject(db *mongo.Database, ctx context.Context, project *models.
 		return errors.New("Error hiding project: " + err.Error())
 	}
 
-	// Delete all absent flags
-	err = database.DeleteAbsentFlags(db, ctx, projectId)
+	// Start counting absences again from now
+	err = database.SetProjectAbsentReset(db, ctx, projectId, util.Now(), false)
 	if err != nil {
-		return errors.New("Error deleting absent flags: " + err.Error())
+		return errors.New("Error resetting absences: " + err.Error())
 	}
 
 	// Add a flag to notate that we are automatically hiding the project
@@ -128,9 +141,9 @@ func HideAbsentProject(db *mongo.Database, ctx context.Context, project *models.
 // MoveJudgeGroup will increment the count of projects they've seen in the current group,
 // then moves a judge to a new group if they've seen n projects in their current group,
 // where n is either the count or the proportion of projects in the group.
-func MoveJudgeGroup(db *mongo.Database, ctx context.Context, judge *models.Judge, options *models.Options) error {
+func MoveJudgeGroup(store Store, ctx context.Context, judge *models.Judge, options *models.Options) error {
 	// Get the number of projects in the group
-	numProjects, err := database.GetNumProjectsInGroup(db, ctx, judge.Group)
+	numProjects, err := store.GetNumProjectsInGroup(ctx, judge.Group)
 	if err != nil {
 		return errors.New("error getting number of projects in group: " + err.Error())
 	}
@@ -149,90 +162,70 @@ func MoveJudgeGroup(db *mongo.Database, ctx context.Context, judge *models.Judge
 
 // PickNextProject - Picks the next project for the judge to judge.
 // To do this:
-//  1. Get all available projects
-//  2. If judging a track, simply pick the next project in order
-//  3. If any project is prioritized and on the list, return that
-//  4. Shuffle projects
-//  5. If any projects seen less than min views (set in admin side), only select from that list
-//  6. Otherwise, pick the project with the minimum number of comparisons with every other project
-func PickNextProject(db *mongo.Database, ctx context.Context, judge *models.Judge, comps *Comparisons) (*models.Project, error) {
-	// Get items
-	items, err := FindAvailableItems(db, ctx, judge)
+//  1. If the judge has reached their quota, return nil (see QuotaReached)
+//  2. If schedule mode is on and the judge has an itinerary, return the next stop on it (see NextScheduledProject)
+//  3. Get all available projects
+//  4. Prefer the projects matching the judge's expertise tags (see PreferTagMatches)
+//  5. If there is only one, return it
+//  6. Otherwise, let the assignment strategy set in the options pick one (see AssignmentStrategy)
+//
+// clock is the current judging clock time in ms, used for the time budget.
+func PickNextProject(store Store, ctx context.Context, judge *models.Judge, comps *Comparisons, clock int64) (*models.Project, error) {
+	// Get options from the store
+	options, err := store.GetOptions(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	// If there are no items, return nil
-	if len(items) == 0 {
+	// Judges who have reached their quota wrap up and rank instead of getting more projects
+	if QuotaReached(judge, clock) {
 		return nil, nil
 	}
 
-	// If there is only one item, return that
-	if len(items) == 1 {
-		return items[0], nil
-	}
-
-	// Get options from the db
-	options, err := database.GetOptions(db, ctx)
-	if err != nil {
-		return nil, err
-	}
-
-	// If judging a track, simply pick the next project
-	if judge.Track != "" {
-		return GetNextFreeProject(judge.LastLocation, items)
+	// Follow the judge's itinerary in schedule mode
+	if options.ScheduleMode && len(judge.Schedule) > 0 {
+		return NextScheduledProject(store, ctx, judge, options)
 	}
 
-	// Get prioritized projects
-	prioritizedProjects, err := database.GetPrioritizedProjects(db, ctx)
+	// Get items
+	items, err := FindAvailableItems(store, ctx, judge)
 	if err != nil {
 		return nil, err
 	}
 
-	// If any prioritized projects are in the list, return the first one
-	for _, proj := range prioritizedProjects {
-		if slices.ContainsFunc(items, func(p *models.Project) bool {
-			return p.Id == proj.Id
-		}) {
-			return proj, nil
-		}
-	}
-
-	// Shuffle items
-	for i := range items {
-		j := rand.Intn(i + 1)
-		items[i], items[j] = items[j], items[i]
+	// If there are no items, return nil
+	if len(items) == 0 {
+		return nil, nil
 	}
 
-	// Stable sort by the number of views
-	slices.SortStableFunc(items, func(a, b *models.Project) int {
-		return int(a.Seen - b.Seen)
-	})
+	// Prefer projects that match the judge's expertise
+	items = PreferTagMatches(judge, items, options)
 
-	// If any items have not been seen minViews times, return that
-	// This will be a random item due to shuffling + stable sort
-	if items[0].Seen < options.MinViews {
+	// If there is only one item, return that
+	if len(items) == 1 {
 		return items[0], nil
 	}
 
-	// Otherwise, pick the project that has been compared to other projects the least
-	return comps.FindLeastCompared(items, judge.SeenProjects), nil
+	// Let the assignment strategy pick the project
+	return GetAssignmentStrategy(options.AssignStrategy).Pick(store, ctx, judge, items, comps, options)
 }
 
 // FindAvailableItems - List of projects to pick from for the judge.
+// Judges that aren't in the current round's pool get no projects.
 // Find all projects that are higher priority with the following heuristic:
-//  1. Ignore all projects that are inactive
+//  1. Ignore all projects that are inactive, weren't promoted into the current round, or whose team is away right now
 //  2. Filter out all projects that the judge has already seen
 //  3. Filter out all projects that the judge has flagged (except for busy projects)
 //  4. Filter out all projects that is not in the judge's track (if tracks are enabled and the user has a track)
 //  5. If tracks are enabled, filter out all track projects that have been seen >track_views[track] times
 //  6. Filter out projects that are currently being judged (if no projects remain after filter, ignore step)
-//  7. If judging a track, return at this point (ignore last 2 conditions)
+//  7. If judging a track, return at this point (ignore last condition)
 //  8. Filter out projects not in the judge's group (if no projects remain after filter, try subsequent groups until a project is found OR all projects have been judged)
-//  9. Filter out all projects that have less than the minimum number of views (if no projects remain after filter, ignore step)
-func FindAvailableItems(db *mongo.Database, ctx context.Context, judge *models.Judge) ([]*models.Project, error) {
+//
+// Which of these projects is picked (e.g. balancing the number of views) is up to the assignment strategy.
+func FindAvailableItems(store Store, ctx context.Context, judge *models.Judge) ([]*models.Project, error) {
 	// Get the list of all active projects
-	projects, err := database.FindActiveProjects(db, ctx)
+	projects, err := store.FindActiveProjects(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -243,18 +236,24 @@ func FindAvailableItems(db *mongo.Database, ctx context.Context, judge *models.J
 	}
 
 	// Get all flags for the judge
-	flags, err := database.FindFlagsByJudge(db, ctx, judge)
+	flags, err := store.FindFlagsByJudge(ctx, judge)
 	if err != nil {
 		return nil, err
 	}
 
 	// Get the options
-	options, err := database.GetOptions(db, ctx)
+	options, err := store.GetOptions(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	// Create a set of voted, skipped, and flagged projects
+	// Judges outside of the current round's pool have nothing to judge
+	round := CurrentRound(options)
+	if !JudgeInRound(judge, round) {
+		return []*models.Project{}, nil
+	}
+
+	// Create a set of voted, skipped, flagged, and conflicted projects
 	done := make(map[string]bool)
 	for _, proj := range judge.SeenProjects {
 		done[proj.ProjectId.Hex()] = true
@@ -265,18 +264,23 @@ func FindAvailableItems(db *mongo.Database, ctx context.Context, judge *models.J
 	for _, flag := range flags {
 		done[flag.ProjectId.Hex()] = true
 	}
+	for _, c := range judge.Conflicts {
+		done[c.Hex()] = true
+	}
 
-	// Filter out all projects that the judge has skipped or voted on
+	// Filter out all projects that the judge has skipped or voted on, that are not in the round,
+	// or whose team is away (so judges don't find an empty table and mark them absent)
+	now := util.Now()
 	var filteredProjects []*models.Project
 	for _, proj := range projects {
-		if !done[proj.Id.Hex()] {
+		if !done[proj.Id.Hex()] && ProjectInRound(proj, round) && proj.AvailableAt(now) {
 			filteredProjects = append(filteredProjects, proj)
 		}
 	}
 	projects = filteredProjects
 
 	// Get all projects currently being judged
-	busyProjects, err := database.FindBusyProjects(db, ctx)
+	busyProjects, err := store.FindBusyProjects(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -354,25 +358,6 @@ func FindAvailableItems(db *mongo.Database, ctx context.Context, judge *models.J
 		}
 	}
 
-	// Get the minimum number of views of the remaining projects
-	minSeen := projects[0].Seen
-	for _, proj := range projects {
-		if proj.Seen < minSeen {
-			minSeen = proj.Seen
-		}
-	}
-
-	// Filter out projects that have more than the minimum number of views
-	var minViewProjects []*models.Project
-	for _, proj := range projects {
-		if proj.Seen == minSeen {
-			minViewProjects = append(minViewProjects, proj)
-		}
-	}
-	if len(minViewProjects) > 0 {
-		projects = minViewProjects
-	}
-
 	return projects, nil
 }
 
@@ -0,0 +1,111 @@
+package judging
+
+import (
+	"context"
+	"server/database"
+	"server/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// defaultLeaseGrace is the lease grace period in seconds used when none is set,
+// eg. in databases from before the option existed
+const defaultLeaseGrace = 300
+
+// LeaseDuration is how long a judge can hold their current project before it is released
+// for other judges: the judging timer plus the lease grace period.
+// Without a judging timer there is no lease, so this returns 0 and projects are never released.
+func LeaseDuration(op *models.Options) time.Duration {
+	if op.JudgingTimer <= 0 {
+		return 0
+	}
+	grace := op.LeaseGrace
+	if grace <= 0 {
+		grace = defaultLeaseGrace
+	}
+	return time.Duration(op.JudgingTimer+grace) * time.Second
+}
+
+// LeaseExpiry returns when a lease on a project picked (or renewed) at the given time expires,
+// or 0 if there is no lease
+func LeaseExpiry(op *models.Options, picked time.Time) primitive.DateTime {
+	lease := LeaseDuration(op)
+	if lease == 0 {
+		return 0
+	}
+	return primitive.NewDateTimeFromTime(picked.Add(lease))
+}
+
+// LeaseExpired returns true if the judge is holding a project past the end of its lease.
+// Projects picked before leases existed are leased from the judge's last activity.
+func LeaseExpired(judge *models.Judge, now time.Time, lease time.Duration) bool {
+	if judge.Current == nil || lease == 0 {
+		return false
+	}
+	if judge.LeaseExpiry == 0 {
+		return judge.LastActivity.Time().Add(lease).Before(now)
+	}
+	return judge.LeaseExpiry.Time().Before(now)
+}
+
+// ReleaseExpiredLeases clears the current project of every judge whose lease has expired,
+// so the project is no longer treated as busy and can be assigned to other judges.
+// Nothing is recorded for the released project; the judge simply gets a new one next time.
+// Returns the released judges, with the project they were holding still set as their current project.
+func ReleaseExpiredLeases(db *mongo.Database, ctx context.Context) ([]*models.Judge, error) {
+	op, err := database.GetOptions(db, ctx)
+	if err != nil {
+		return nil, err
+	}
+	judges, err := database.FindJudgesWithCurrent(db, ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	lease := LeaseDuration(op)
+	released := []*models.Judge{}
+	if lease == 0 {
+		return released, nil
+	}
+	for _, judge := range judges {
+		if !LeaseExpired(judge, now, lease) {
+			continue
+		}
+
+		// The judge may have finished the project or renewed the lease since they were fetched
+		ok, err := database.ReleaseJudgeLease(db, ctx, judge)
+		if err != nil {
+			return released, err
+		}
+		if ok {
+			released = append(released, judge)
+		}
+	}
+	return released, nil
+}
+
+// RenewLease extends the lease on the judge's current project from now.
+// Does nothing if the judge has no current project.
+func RenewLease(db *mongo.Database, ctx context.Context, judge *models.Judge) error {
+	if judge.Current == nil {
+		return nil
+	}
+	op, err := database.GetOptions(db, ctx)
+	if err != nil {
+		return err
+	}
+	return database.RenewJudgeLease(db, ctx, &judge.Id, LeaseExpiry(op, time.Now()))
+}
+
+// RenewAllLeases extends the leases of all judges with a current project from now.
+// Used when the clock is resumed, so judging time lost to the pause doesn't count against the leases.
+func RenewAllLeases(db *mongo.Database, ctx context.Context) error {
+	op, err := database.GetOptions(db, ctx)
+	if err != nil {
+		return err
+	}
+	return database.RenewAllJudgeLeases(db, ctx, LeaseExpiry(op, time.Now()))
+}
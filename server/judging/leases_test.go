@@ -0,0 +1,58 @@
+package judging
+
+import (
+	"server/models"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestLeaseExpired(t *testing.T) {
+	op := models.NewOptions()
+	op.JudgingTimer = 300
+	op.LeaseGrace = 120
+	lease := LeaseDuration(op)
+	if lease != 7*time.Minute {
+		t.Fatalf("expected a 7 minute lease, got %s", lease)
+	}
+
+	now := time.Now()
+	judge := models.NewJudge("judge", "", "", "", 0)
+	if LeaseExpired(judge, now, lease) {
+		t.Errorf("expected a judge without a current project to have no lease")
+	}
+
+	current := primitive.NewObjectID()
+	judge.Current = &current
+	judge.LeaseExpiry = LeaseExpiry(op, now.Add(-6*time.Minute))
+	if LeaseExpired(judge, now, lease) {
+		t.Errorf("expected the lease to still be held after 6 minutes")
+	}
+	judge.LeaseExpiry = LeaseExpiry(op, now.Add(-8*time.Minute))
+	if !LeaseExpired(judge, now, lease) {
+		t.Errorf("expected the lease to expire after 8 minutes")
+	}
+
+	// Projects picked before leases existed are leased from the last activity
+	judge.LeaseExpiry = 0
+	judge.LastActivity = primitive.NewDateTimeFromTime(now.Add(-time.Hour))
+	if !LeaseExpired(judge, now, lease) {
+		t.Errorf("expected a project picked an hour ago without a lease to be released")
+	}
+
+	// A missing grace period falls back to the default
+	op.LeaseGrace = 0
+	if LeaseDuration(op) != 10*time.Minute {
+		t.Errorf("expected the default grace period to be used, got %s", LeaseDuration(op))
+	}
+
+	// Without a judging timer there is no lease
+	op.JudgingTimer = 0
+	if LeaseDuration(op) != 0 || LeaseExpiry(op, now) != 0 {
+		t.Errorf("expected no lease without a judging timer")
+	}
+	if LeaseExpired(judge, now, LeaseDuration(op)) {
+		t.Errorf("expected projects to never be released without a judging timer")
+	}
+}
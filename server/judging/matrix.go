@@ -0,0 +1,50 @@
+package judging
+
+import (
+	"server/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// PairwiseMatrix is the pairwise preference matrix built from judges' rankings.
+// All matrices are indexed in the order of Projects.
+type PairwiseMatrix struct {
+	Projects    []*MatrixProject `json:"projects"`
+	Wins        [][]float64      `json:"wins"`        // Wins[a][b] = total weight of the judges that placed a above b (draws count as half)
+	RawWins     [][]float64      `json:"raw_wins"`    // RawWins[a][b] = number of judges that placed a above b, ignoring weights (draws count as half)
+	Comparisons [][]int64        `json:"comparisons"` // Comparisons[a][b] = number of judges that compared a and b
+}
+
+// MatrixProject is a row/column of the pairwise matrix
+type MatrixProject struct {
+	ProjectId primitive.ObjectID `json:"project_id"`
+	Name      string             `json:"name"`
+	Location  int64              `json:"location"`
+}
+
+// ComputePairwiseMatrix builds the pairwise preference matrix from the judges' rankings,
+// using the same rules as the pairwise ranking methods (see newPairwise).
+// Projects are used to fill in names and table numbers.
+func ComputePairwiseMatrix(judges []*models.Judge, projects []*models.Project) *PairwiseMatrix {
+	pw := newPairwise(judges)
+
+	byId := make(map[primitive.ObjectID]*models.Project, len(projects))
+	for _, p := range projects {
+		byId[p.Id] = p
+	}
+
+	out := &PairwiseMatrix{
+		Projects:    make([]*MatrixProject, len(pw.ids)),
+		Wins:        pw.pref,
+		RawWins:     pw.raw,
+		Comparisons: pw.pairs,
+	}
+	for i, id := range pw.ids {
+		out.Projects[i] = &MatrixProject{ProjectId: id}
+		if p, ok := byId[id]; ok {
+			out.Projects[i].Name = p.Name
+			out.Projects[i].Location = p.Location
+		}
+	}
+	return out
+}
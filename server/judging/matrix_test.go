@@ -0,0 +1,38 @@
+package judging
+
+import (
+	"server/models"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestComputePairwiseMatrix(t *testing.T) {
+	a, b, c := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+
+	// a = b > c with weight 2, then b > a with c unranked
+	first := rankingJudge([]primitive.ObjectID{a, b, c}, 3)
+	first.RankingTiers = [][]primitive.ObjectID{{a, b}, {c}}
+	first.Weight = 2
+	second := rankingJudge([]primitive.ObjectID{b, a, c}, 2)
+
+	matrix := ComputePairwiseMatrix([]*models.Judge{first, second}, []*models.Project{{Id: a, Name: "A", Location: 1}})
+	index := make(map[primitive.ObjectID]int)
+	for i, p := range matrix.Projects {
+		index[p.ProjectId] = i
+	}
+	ai, bi, ci := index[a], index[b], index[c]
+
+	if matrix.Projects[ai].Name != "A" || matrix.Projects[ai].Location != 1 {
+		t.Errorf("expected project A at table 1, got %s at table %d", matrix.Projects[ai].Name, matrix.Projects[ai].Location)
+	}
+	if matrix.Wins[ai][bi] != 1 || matrix.Wins[bi][ai] != 2 {
+		t.Errorf("expected weighted a-b record 1-2, got %g-%g", matrix.Wins[ai][bi], matrix.Wins[bi][ai])
+	}
+	if matrix.RawWins[ai][bi] != 0.5 || matrix.RawWins[bi][ai] != 1.5 {
+		t.Errorf("expected raw a-b record 0.5-1.5, got %g-%g", matrix.RawWins[ai][bi], matrix.RawWins[bi][ai])
+	}
+	if matrix.Comparisons[ai][bi] != 2 || matrix.Comparisons[ai][ci] != 2 {
+		t.Errorf("expected 2 comparisons each, got %d and %d", matrix.Comparisons[ai][bi], matrix.Comparisons[ai][ci])
+	}
+}
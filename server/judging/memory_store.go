@@ -0,0 +1,227 @@
+package judging
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"server/models"
+	"server/util"
+	"slices"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryStore keeps the data for picking projects in memory, for running the judging flow
+// without a database (see Simulate). The updates mirror the database functions of the same
+// name. Items are copied in and out, so changes to them only apply once they're saved back.
+// It is not safe for concurrent use.
+type MemoryStore struct {
+	options  *models.Options
+	projects []*models.Project
+	judges   []*models.Judge
+	flags    []*models.Flag
+}
+
+// NewMemoryStore creates an in-memory store with the given options, projects, and judges
+func NewMemoryStore(op *models.Options, projects []*models.Project, judges []*models.Judge) *MemoryStore {
+	s := &MemoryStore{options: op}
+	for _, p := range projects {
+		s.projects = append(s.projects, copyProject(p))
+	}
+	for _, j := range judges {
+		s.judges = append(s.judges, copyJudge(j))
+	}
+	return s
+}
+
+// copyProject copies a project, including the fields that the store updates in place
+func copyProject(p *models.Project) *models.Project {
+	out := *p
+	out.TrackSeen = maps.Clone(p.TrackSeen)
+	return &out
+}
+
+// copyJudge copies a judge, including the fields that the store updates in place
+func copyJudge(j *models.Judge) *models.Judge {
+	out := *j
+	out.SeenProjects = slices.Clone(j.SeenProjects)
+	return &out
+}
+
+func (s *MemoryStore) GetOptions(ctx context.Context) (*models.Options, error) {
+	return s.options, nil
+}
+
+func (s *MemoryStore) FindActiveProjects(ctx context.Context) ([]*models.Project, error) {
+	projects := make([]*models.Project, 0, len(s.projects))
+	for _, p := range s.projects {
+		if p.Active {
+			projects = append(projects, copyProject(p))
+		}
+	}
+	return projects, nil
+}
+
+func (s *MemoryStore) FindBusyProjects(ctx context.Context) (map[primitive.ObjectID]string, error) {
+	output := make(map[primitive.ObjectID]string)
+	for _, j := range s.judges {
+		if j.Active && j.Current != nil {
+			output[*j.Current] = j.Track
+		}
+	}
+	return output, nil
+}
+
+func (s *MemoryStore) GetPrioritizedProjects(ctx context.Context) ([]*models.Project, error) {
+	projects := make([]*models.Project, 0)
+	for _, p := range s.projects {
+		if p.Prioritized {
+			projects = append(projects, copyProject(p))
+		}
+	}
+	return projects, nil
+}
+
+func (s *MemoryStore) GetNumProjectsInGroup(ctx context.Context, group int64) (int64, error) {
+	count := int64(0)
+	for _, p := range s.projects {
+		if p.Group == group && p.Active {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (s *MemoryStore) FindFlagsByJudge(ctx context.Context, judge *models.Judge) ([]*models.Flag, error) {
+	flags := make([]*models.Flag, 0)
+	for _, f := range s.flags {
+		if f.JudgeId != nil && *f.JudgeId == judge.Id {
+			out := *f
+			flags = append(flags, &out)
+		}
+	}
+	return flags, nil
+}
+
+func (s *MemoryStore) FindJudgesByTrack(ctx context.Context, track string) ([]*models.Judge, error) {
+	judges := make([]*models.Judge, 0)
+	for _, j := range s.judges {
+		if j.Track == track {
+			judges = append(judges, copyJudge(j))
+		}
+	}
+	return judges, nil
+}
+
+// FindAllProjects returns a copy of every project
+func (s *MemoryStore) FindAllProjects() []*models.Project {
+	projects := make([]*models.Project, len(s.projects))
+	for i, p := range s.projects {
+		projects[i] = copyProject(p)
+	}
+	return projects
+}
+
+// FindAllJudges returns a copy of every judge
+func (s *MemoryStore) FindAllJudges() []*models.Judge {
+	judges := make([]*models.Judge, len(s.judges))
+	for i, j := range s.judges {
+		judges[i] = copyJudge(j)
+	}
+	return judges
+}
+
+// FindJudge returns a copy of the judge with the given ID
+func (s *MemoryStore) FindJudge(id primitive.ObjectID) (*models.Judge, error) {
+	judge := s.judge(id)
+	if judge == nil {
+		return nil, errors.New("judge not found: " + id.Hex())
+	}
+	return copyJudge(judge), nil
+}
+
+// FindProject returns a copy of the project with the given ID
+func (s *MemoryStore) FindProject(id primitive.ObjectID) (*models.Project, error) {
+	project := s.project(id)
+	if project == nil {
+		return nil, errors.New("project not found: " + id.Hex())
+	}
+	return copyProject(project), nil
+}
+
+// UpdateAfterPicked de-prioritizes the project and makes it the judge's current project
+func (s *MemoryStore) UpdateAfterPicked(project *models.Project, judge *models.Judge, leaseExpiry primitive.DateTime, clock int64) error {
+	p := s.project(project.Id)
+	j := s.judge(judge.Id)
+	if p == nil || j == nil {
+		return errors.New("project or judge not found")
+	}
+
+	p.Prioritized = false
+	j.LastLocation = p.Location
+	j.Current = &p.Id
+	j.LeaseExpiry = leaseExpiry
+	j.LastActivity = util.Now()
+	if j.Started == 0 {
+		j.Started = util.Now()
+		j.StartedClock = clock
+	}
+	return nil
+}
+
+// UpdateAfterSeen adds the project to the judge's seen projects and counts the view on the project
+func (s *MemoryStore) UpdateAfterSeen(judge *models.Judge, seenProject *models.JudgedProject) error {
+	j := s.judge(judge.Id)
+	p := s.project(seenProject.ProjectId)
+	if p == nil || j == nil {
+		return errors.New("project or judge not found")
+	}
+
+	j.SeenProjects = append(j.SeenProjects, *seenProject)
+	j.Seen++
+	j.Current = nil
+	j.Group = judge.Group
+	j.GroupSeen = judge.GroupSeen
+	j.LastActivity = util.Now()
+
+	if judge.Track != "" {
+		if p.TrackSeen == nil {
+			p.TrackSeen = make(map[string]int64)
+		}
+		p.TrackSeen[judge.Track]++
+	} else {
+		p.Seen++
+	}
+	p.LastActivity = util.Now()
+	return nil
+}
+
+// UpdateJudgeRanking sets the rankings of the judge
+func (s *MemoryStore) UpdateJudgeRanking(id primitive.ObjectID, rankings []primitive.ObjectID, tiers [][]primitive.ObjectID, rankingsAgg []models.AggRanking) error {
+	j := s.judge(id)
+	if j == nil {
+		return errors.New("judge not found: " + id.Hex())
+	}
+	j.Rankings = rankings
+	j.RankingTiers = tiers
+	j.RankingsAgg = rankingsAgg
+	return nil
+}
+
+func (s *MemoryStore) judge(id primitive.ObjectID) *models.Judge {
+	for _, j := range s.judges {
+		if j.Id == id {
+			return j
+		}
+	}
+	return nil
+}
+
+func (s *MemoryStore) project(id primitive.ObjectID) *models.Project {
+	for _, p := range s.projects {
+		if p.Id == id {
+			return p
+		}
+	}
+	return nil
+}
@@ -0,0 +1,387 @@
+package judging
+
+import (
+	"bytes"
+	"cmp"
+	"server/models"
+	"server/util"
+	"slices"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Ranking aggregation methods that can be selected in the options
+const (
+	MethodCopeland    = "copeland"
+	MethodBorda       = "borda"
+	MethodSchulze     = "schulze"
+	MethodRankedPairs = "ranked-pairs"
+	MethodKemeny      = "kemeny"
+)
+
+// RankingMethods is the list of all valid ranking aggregation methods
+var RankingMethods = []string{MethodCopeland, MethodBorda, MethodSchulze, MethodRankedPairs, MethodKemeny}
+
+// IsValidRankingMethod returns true if the method is one of the supported aggregation methods
+func IsValidRankingMethod(method string) bool {
+	return slices.Contains(RankingMethods, method)
+}
+
+// NormalizeRankingMethod returns the method to use, defaulting to Copeland
+// for databases created before the ranking method option existed
+func NormalizeRankingMethod(method string) string {
+	if !IsValidRankingMethod(method) {
+		return MethodCopeland
+	}
+	return method
+}
+
+// isPairwiseMethod returns true if the method can only be resolved by looking at
+// all judges' ballots at once, instead of summing up each judge's scores
+func isPairwiseMethod(method string) bool {
+	return method == MethodSchulze || method == MethodRankedPairs || method == MethodKemeny
+}
+
+// ComputeMethodScores runs the rankings of all given judges through the aggregation method.
+// Higher scores are better. Copeland and Borda are the weighted sum of each judge's aggregated ranking;
+// Schulze, Ranked Pairs, and Kemeny are resolved from the weighted pairwise preferences of all judges.
+func ComputeMethodScores(judges []*models.Judge, method string) map[primitive.ObjectID]float64 {
+	method = NormalizeRankingMethod(method)
+
+	// Additive methods can simply sum the scores of each judge
+	if !isPairwiseMethod(method) {
+		out := make(map[primitive.ObjectID]float64)
+		for _, judge := range judges {
+			weight := JudgeWeight(judge)
+			for _, agg := range AggregateRanking(judge, method) {
+				out[agg.ProjectId] += agg.Score * weight
+			}
+		}
+		return out
+	}
+
+	pw := newPairwise(judges)
+	switch method {
+	case MethodSchulze:
+		return pw.schulzeScores()
+	case MethodRankedPairs:
+		return pw.rankedPairsScores()
+	default:
+		return pw.kemenyScores()
+	}
+}
+
+// pairwise holds the (weighted) number of judges that preferred one project over another
+type pairwise struct {
+	ids         []primitive.ObjectID
+	index       map[primitive.ObjectID]int
+	pref        [][]float64 // pref[a][b] = total weight of the judges that placed a above b
+	raw         [][]float64 // raw[a][b] = number of judges that placed a above b, ignoring weights
+	pairs       [][]int64   // pairs[a][b] = number of judges that compared a and b
+	comparisons []int64     // comparisons[a] = number of preferences involving a, ignoring weights
+}
+
+// newPairwise creates the pairwise preference matrix from the judges' rankings.
+// A ranked project is preferred over all projects in lower tiers and over all
+// unranked projects that the judge has seen. Projects in the same tier are a draw,
+// which counts as half a preference each way. Unranked projects are not compared.
+// Each preference counts for the weight of the judge.
+func newPairwise(judges []*models.Judge) *pairwise {
+	pw := &pairwise{index: make(map[primitive.ObjectID]int)}
+	judges = withoutConflicts(judges)
+
+	// Index all projects that show up in any judge's seen list
+	for _, judge := range judges {
+		for _, p := range judge.SeenProjects {
+			pw.add(p.ProjectId)
+		}
+		for _, p := range judge.Rankings {
+			pw.add(p)
+		}
+	}
+
+	// Sort the IDs so that tie-breaking is deterministic
+	slices.SortFunc(pw.ids, func(a, b primitive.ObjectID) int {
+		return bytes.Compare(a[:], b[:])
+	})
+	for i, id := range pw.ids {
+		pw.index[id] = i
+	}
+
+	// Fill the preference matrix
+	pw.pref = make([][]float64, len(pw.ids))
+	pw.raw = make([][]float64, len(pw.ids))
+	pw.pairs = make([][]int64, len(pw.ids))
+	for i := range pw.pref {
+		pw.pref[i] = make([]float64, len(pw.ids))
+		pw.raw[i] = make([]float64, len(pw.ids))
+		pw.pairs[i] = make([]int64, len(pw.ids))
+	}
+	pw.comparisons = make([]int64, len(pw.ids))
+	for _, judge := range judges {
+		weight := JudgeWeight(judge)
+		unranked := make([]int, 0, len(judge.SeenProjects))
+		for _, p := range judge.SeenProjects {
+			if !slices.Contains(judge.Rankings, p.ProjectId) {
+				unranked = append(unranked, pw.index[p.ProjectId])
+			}
+		}
+
+		tiers := util.GetRankingTiers(judge)
+		for t, tier := range tiers {
+			for i, a := range tier {
+				ai := pw.index[a]
+				for _, b := range tier[i+1:] {
+					pw.draw(ai, pw.index[b], weight)
+				}
+				for _, lower := range tiers[t+1:] {
+					for _, b := range lower {
+						pw.prefer(ai, pw.index[b], weight)
+					}
+				}
+				for _, bi := range unranked {
+					pw.prefer(ai, bi, weight)
+				}
+			}
+		}
+	}
+
+	return pw
+}
+
+// add adds a project to the ID list if it hasn't been added yet
+func (pw *pairwise) add(id primitive.ObjectID) {
+	if _, ok := pw.index[id]; ok {
+		return
+	}
+	pw.index[id] = len(pw.ids)
+	pw.ids = append(pw.ids, id)
+}
+
+// prefer records that a judge with the given weight placed a above b
+func (pw *pairwise) prefer(a int, b int, weight float64) {
+	pw.pref[a][b] += weight
+	pw.raw[a][b]++
+	pw.pairs[a][b]++
+	pw.pairs[b][a]++
+	pw.comparisons[a]++
+	pw.comparisons[b]++
+}
+
+// draw records that a judge with the given weight tied a and b
+func (pw *pairwise) draw(a int, b int, weight float64) {
+	pw.pref[a][b] += weight / 2
+	pw.pref[b][a] += weight / 2
+	pw.raw[a][b] += 0.5
+	pw.raw[b][a] += 0.5
+	pw.pairs[a][b]++
+	pw.pairs[b][a]++
+	pw.comparisons[a]++
+	pw.comparisons[b]++
+}
+
+// toScores converts a list of scores in matrix order to a map
+func (pw *pairwise) toScores(scores []int64) map[primitive.ObjectID]float64 {
+	out := make(map[primitive.ObjectID]float64, len(pw.ids))
+	for i, id := range pw.ids {
+		out[id] = float64(scores[i])
+	}
+	return out
+}
+
+// schulzeScores computes the strongest paths between every pair of projects.
+// The score of a project is the number of projects it beats (by strongest path)
+// minus the number of projects that beat it.
+func (pw *pairwise) schulzeScores() map[primitive.ObjectID]float64 {
+	n := len(pw.ids)
+
+	// Initialize direct path strengths with the winning pairwise counts
+	p := make([][]float64, n)
+	for i := range p {
+		p[i] = make([]float64, n)
+		for j := range p[i] {
+			if i != j && pw.pref[i][j] > pw.pref[j][i] {
+				p[i][j] = pw.pref[i][j]
+			}
+		}
+	}
+
+	// Floyd-Warshall style widest path computation
+	for k := 0; k < n; k++ {
+		for i := 0; i < n; i++ {
+			if i == k || p[i][k] == 0 {
+				continue
+			}
+			for j := 0; j < n; j++ {
+				if j == i || j == k {
+					continue
+				}
+				p[i][j] = max(p[i][j], min(p[i][k], p[k][j]))
+			}
+		}
+	}
+
+	scores := make([]int64, n)
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			if p[i][j] > p[j][i] {
+				scores[i]++
+				scores[j]--
+			}
+		}
+	}
+	return pw.toScores(scores)
+}
+
+// rankedPairsScores locks in pairwise victories from the largest margin to the smallest,
+// skipping any that would create a cycle. The score of a project is the number of projects
+// below it in the locked graph minus the number of projects above it.
+func (pw *pairwise) rankedPairsScores() map[primitive.ObjectID]float64 {
+	n := len(pw.ids)
+
+	// List all pairs with a winner
+	type pair struct {
+		winner, loser int
+		margin, votes float64
+	}
+	var pairs []pair
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			switch {
+			case pw.pref[i][j] > pw.pref[j][i]:
+				pairs = append(pairs, pair{i, j, pw.pref[i][j] - pw.pref[j][i], pw.pref[i][j]})
+			case pw.pref[j][i] > pw.pref[i][j]:
+				pairs = append(pairs, pair{j, i, pw.pref[j][i] - pw.pref[i][j], pw.pref[j][i]})
+			}
+		}
+	}
+
+	// Sort by margin, then by the number of winning votes
+	slices.SortStableFunc(pairs, func(a, b pair) int {
+		if a.margin != b.margin {
+			return cmp.Compare(b.margin, a.margin)
+		}
+		return cmp.Compare(b.votes, a.votes)
+	})
+
+	// Lock in each pair unless the loser can already reach the winner
+	locked := make([][]int, n)
+	for _, p := range pairs {
+		if !reachable(locked, p.loser, p.winner) {
+			locked[p.winner] = append(locked[p.winner], p.loser)
+		}
+	}
+
+	// Count the projects each project is locked above
+	scores := make([]int64, n)
+	for i := 0; i < n; i++ {
+		seen := make([]bool, n)
+		stack := []int{i}
+		seen[i] = true
+		for len(stack) > 0 {
+			curr := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			for _, next := range locked[curr] {
+				if !seen[next] {
+					seen[next] = true
+					stack = append(stack, next)
+					scores[i]++
+					scores[next]--
+				}
+			}
+		}
+	}
+	return pw.toScores(scores)
+}
+
+// reachable returns true if there is a path from one node to another in the graph
+func reachable(graph [][]int, from int, to int) bool {
+	seen := make(map[int]bool)
+	stack := []int{from}
+	for len(stack) > 0 {
+		curr := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if curr == to {
+			return true
+		}
+		if seen[curr] {
+			continue
+		}
+		seen[curr] = true
+		stack = append(stack, graph[curr]...)
+	}
+	return false
+}
+
+// kemenyScores approximates the Kemeny-Young ranking, which is the ordering that agrees
+// with the most pairwise preferences. Finding the exact ordering is NP-hard, so this starts
+// from the Copeland ordering and moves single projects until no move improves the agreement.
+// The score of a project is the number of projects below it minus the number above it.
+func (pw *pairwise) kemenyScores() map[primitive.ObjectID]float64 {
+	n := len(pw.ids)
+
+	// Start with the ordering by pairwise wins minus losses
+	copeland := make([]int64, n)
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			if pw.pref[i][j] > pw.pref[j][i] {
+				copeland[i]++
+			} else if pw.pref[i][j] < pw.pref[j][i] {
+				copeland[i]--
+			}
+		}
+	}
+	order := make([]int, n)
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		return int(copeland[b] - copeland[a])
+	})
+
+	// Keep moving single projects to their best position while that improves the agreement.
+	// Each pass is bounded so that a pathological input can't loop forever.
+	for pass := 0; pass < n; pass++ {
+		improved := false
+		for i := 0; i < n; i++ {
+			item := order[i]
+			best, bestDelta := i, 0.0
+
+			// Try moving the item up
+			delta := 0.0
+			for j := i - 1; j >= 0; j-- {
+				other := order[j]
+				delta += pw.pref[item][other] - pw.pref[other][item]
+				if delta > bestDelta {
+					best, bestDelta = j, delta
+				}
+			}
+
+			// Try moving the item down
+			delta = 0
+			for j := i + 1; j < n; j++ {
+				other := order[j]
+				delta += pw.pref[other][item] - pw.pref[item][other]
+				if delta > bestDelta {
+					best, bestDelta = j, delta
+				}
+			}
+
+			if best == i {
+				continue
+			}
+			improved = true
+			order = slices.Delete(order, i, i+1)
+			order = slices.Insert(order, best, item)
+		}
+		if !improved {
+			break
+		}
+	}
+
+	scores := make([]int64, n)
+	for pos, i := range order {
+		scores[i] = int64(n - 1 - 2*pos)
+	}
+	return pw.toScores(scores)
+}
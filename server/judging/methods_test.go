@@ -0,0 +1,88 @@
+package judging
+
+import (
+	"server/models"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// rankingJudge creates a judge that has seen the given projects and ranked the first n of them
+func rankingJudge(seen []primitive.ObjectID, n int) *models.Judge {
+	judge := models.NewJudge("Test Judge", "test@example.com", "", "", 0)
+	for _, id := range seen {
+		judge.SeenProjects = append(judge.SeenProjects, models.JudgedProject{ProjectId: id})
+	}
+	judge.Rankings = seen[:n]
+	return judge
+}
+
+func TestAggregateRankingCopeland(t *testing.T) {
+	a, b, c := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	agg := AggregateRanking(rankingJudge([]primitive.ObjectID{a, b, c}, 2), MethodCopeland)
+
+	expected := map[primitive.ObjectID]float64{a: 2, b: 0, c: -2}
+	for _, r := range agg {
+		if r.Score != expected[r.ProjectId] {
+			t.Errorf("expected score %g, got %g", expected[r.ProjectId], r.Score)
+		}
+	}
+}
+
+func TestAggregateRankingTies(t *testing.T) {
+	a, b, c, d := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+
+	// a > b = c, d unranked
+	judge := rankingJudge([]primitive.ObjectID{a, b, c, d}, 3)
+	judge.RankingTiers = [][]primitive.ObjectID{{a}, {b, c}}
+
+	tests := map[string]map[primitive.ObjectID]float64{
+		MethodCopeland: {a: 3, b: 0, c: 0, d: -3},
+		MethodBorda:    {a: 3, b: 1.5, c: 1.5, d: 0},
+	}
+	for method, expected := range tests {
+		for _, r := range AggregateRanking(judge, method) {
+			if r.Score != expected[r.ProjectId] {
+				t.Errorf("%s: expected score %g, got %g", method, expected[r.ProjectId], r.Score)
+			}
+		}
+	}
+}
+
+func TestComputeMethodScoresCondorcetWinner(t *testing.T) {
+	a, b, c := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+
+	// a beats b and c in 2 of 3 rankings, so every method should put a first
+	judges := []*models.Judge{
+		rankingJudge([]primitive.ObjectID{a, b, c}, 3),
+		rankingJudge([]primitive.ObjectID{a, c, b}, 3),
+		rankingJudge([]primitive.ObjectID{b, c, a}, 3),
+	}
+
+	for _, method := range RankingMethods {
+		scores := ComputeMethodScores(judges, method)
+		if scores[a] <= scores[b] || scores[a] <= scores[c] {
+			t.Errorf("%s: expected a to have the highest score, got %v", method, scores)
+		}
+	}
+}
+
+func TestComputeMethodScoresCycle(t *testing.T) {
+	a, b, c := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+
+	// Condorcet cycle where a > b is the strongest preference: a > b (4-1), b > c (3-2), c > a (3-2)
+	judges := []*models.Judge{
+		rankingJudge([]primitive.ObjectID{a, b, c}, 3),
+		rankingJudge([]primitive.ObjectID{a, b, c}, 3),
+		rankingJudge([]primitive.ObjectID{c, a, b}, 3),
+		rankingJudge([]primitive.ObjectID{c, a, b}, 3),
+		rankingJudge([]primitive.ObjectID{b, c, a}, 3),
+	}
+
+	for _, method := range []string{MethodSchulze, MethodRankedPairs, MethodKemeny} {
+		scores := ComputeMethodScores(judges, method)
+		if scores[a] <= scores[b] {
+			t.Errorf("%s: expected the strongest preference a > b to hold, got %v", method, scores)
+		}
+	}
+}
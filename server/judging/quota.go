@@ -0,0 +1,27 @@
+package judging
+
+import (
+	"server/models"
+)
+
+// JudgeQuotaProgress calculates how far the judge is toward their quota of projects
+// and their time budget. The time budget starts when the judge is given their first project
+// and is measured with the judging clock (clock is its current time in ms), so it doesn't run while judging is paused.
+func JudgeQuotaProgress(judge *models.Judge, clock int64) models.QuotaProgress {
+	progress := models.QuotaProgress{
+		Seen:   judge.Seen,
+		Quota:  judge.Quota,
+		Budget: judge.TimeBudget,
+	}
+	if judge.Started != 0 {
+		progress.Minutes = max(0, clock-judge.StartedClock) / 60000
+	}
+	progress.Reached = (progress.Quota > 0 && progress.Seen >= progress.Quota) ||
+		(progress.Budget > 0 && progress.Minutes >= progress.Budget)
+	return progress
+}
+
+// QuotaReached returns true if the judge has judged their quota of projects or used up their time budget
+func QuotaReached(judge *models.Judge, clock int64) bool {
+	return JudgeQuotaProgress(judge, clock).Reached
+}
@@ -0,0 +1,46 @@
+package judging
+
+import (
+	"server/models"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestJudgeQuotaProgress(t *testing.T) {
+	now := int64(90 * 60000)
+	judge := models.NewJudge("judge", "", "", "", 0)
+	judge.Seen = 4
+	if QuotaReached(judge, now) {
+		t.Errorf("expected a judge without a quota to never reach it")
+	}
+
+	judge.Quota = 5
+	if QuotaReached(judge, now) {
+		t.Errorf("expected the quota not to be reached after 4 of 5 projects")
+	}
+	judge.Seen = 5
+	if !QuotaReached(judge, now) {
+		t.Errorf("expected the quota to be reached after 5 of 5 projects")
+	}
+
+	// The time budget starts with the first project
+	judge.Quota = 0
+	judge.TimeBudget = 60
+	if QuotaReached(judge, now) {
+		t.Errorf("expected the time budget not to start before the first project")
+	}
+	judge.Started = primitive.NewDateTimeFromTime(time.Now().Add(-2 * time.Hour))
+	judge.StartedClock = now - 61*60000
+	progress := JudgeQuotaProgress(judge, now)
+	if !progress.Reached || progress.Minutes != 61 {
+		t.Errorf("expected the time budget to be used up after 61 minutes, got %+v", progress)
+	}
+
+	// Time spent paused doesn't count, since the judging clock doesn't advance
+	judge.StartedClock = now - 30*60000
+	if progress := JudgeQuotaProgress(judge, now); progress.Reached || progress.Minutes != 30 {
+		t.Errorf("expected only 30 minutes of judging clock time to be used, got %+v", progress)
+	}
+}
@@ -0,0 +1,193 @@
+package judging
+
+import (
+	"context"
+	"server/database"
+	"server/models"
+	"sort"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// CurrentRound returns the current judging round, treating options from before rounds existed as round 1
+func CurrentRound(op *models.Options) int64 {
+	if op.Round < 1 {
+		return 1
+	}
+	return op.Round
+}
+
+// JudgeInRound returns true if the judge is in the pool of the given round.
+// Judges that aren't pinned to a round judge whichever round is current.
+func JudgeInRound(judge *models.Judge, round int64) bool {
+	return judge.Round == 0 || judge.Round == round
+}
+
+// ProjectInRound returns true if the project has been promoted into the given round.
+// Every project is in the first round.
+func ProjectInRound(project *models.Project, round int64) bool {
+	return round <= 1 || project.Round >= round
+}
+
+// PickFinalists returns the top n active projects of the round by aggregated score.
+// Ties are broken by table number so the same projects are always picked.
+func PickFinalists(projects []*models.Project, scores map[primitive.ObjectID]ProjectScores, round int64, n int64) []*models.Project {
+	var candidates []*models.Project
+	for _, p := range projects {
+		if p.Active && ProjectInRound(p, round) {
+			candidates = append(candidates, p)
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := scores[candidates[i].Id].Score, scores[candidates[j].Id].Score
+		if a != b {
+			return a > b
+		}
+		return candidates[i].Location < candidates[j].Location
+	})
+
+	if int64(len(candidates)) > n {
+		candidates = candidates[:n]
+	}
+	return candidates
+}
+
+// CloseRound closes the current judging round. The results of the round are saved in a snapshot,
+// every judge's rankings and seen projects are moved into their past rounds, the flags and
+// comparison counts are moved into the past flags and past comparisons, and the top
+// promote projects are moved into the next round. The given judges make up the pool of the
+// next round; all other judges stay in the pool of the closed round.
+// This should be run in a transaction, and the comparisons should be reloaded once it commits.
+func CloseRound(db *mongo.Database, ctx context.Context, promote int64, judgeIds []primitive.ObjectID) (*models.Round, error) {
+	op, err := database.GetOptions(db, ctx)
+	if err != nil {
+		return nil, err
+	}
+	round := CurrentRound(op)
+
+	// Pick the finalists from the results of the round
+	projects, err := database.FindAllProjects(db, ctx)
+	if err != nil {
+		return nil, err
+	}
+	scores, err := AggregateScores(db, ctx)
+	if err != nil {
+		return nil, err
+	}
+	finalists := PickFinalists(projects, scores, round, promote)
+	promoted := make([]primitive.ObjectID, len(finalists))
+	for i, p := range finalists {
+		promoted[i] = p.Id
+	}
+
+	// Count the comparisons of the round before the judges' seen projects are cleared
+	judges, err := database.FindAllJudges(db, ctx)
+	if err != nil {
+		return nil, err
+	}
+	comparisons := RoundComparisons(judges, round)
+
+	// Save the results of the round before clearing them
+	snapshot, err := TakeSnapshot(db, ctx, "round")
+	if err != nil {
+		return nil, err
+	}
+	err = database.ArchiveJudgingRound(db, ctx, round)
+	if err != nil {
+		return nil, err
+	}
+	err = database.ArchiveRoundFlags(db, ctx, round)
+	if err != nil {
+		return nil, err
+	}
+	err = database.InsertPastComparisons(db, ctx, comparisons)
+	if err != nil {
+		return nil, err
+	}
+
+	// Move the finalists and the new judge pool into the next round
+	err = database.PromoteProjects(db, ctx, promoted, round+1)
+	if err != nil {
+		return nil, err
+	}
+	if len(judgeIds) > 0 {
+		err = database.SetJudgesRound(db, ctx, judgeIds, round+1)
+		if err != nil {
+			return nil, err
+		}
+	}
+	err = database.UpdateRound(db, ctx, round+1)
+	if err != nil {
+		return nil, err
+	}
+
+	// Record the round
+	if judgeIds == nil {
+		judgeIds = []primitive.ObjectID{}
+	}
+	record := models.NewRound(round, snapshot.Version, promoted, judgeIds)
+	err = database.InsertRound(db, ctx, record)
+	if err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// RoundComparisons counts how many times each pair of projects was seen by the same judge
+// in the current round, from the projects each judge has seen
+func RoundComparisons(judges []*models.Judge, round int64) []*models.PastComparison {
+	counts := make(map[[2]primitive.ObjectID]int64)
+	var order [][2]primitive.ObjectID
+	for _, j := range judges {
+		for i, ap := range j.SeenProjects {
+			for _, bp := range j.SeenProjects[i+1:] {
+				if ap.ProjectId == bp.ProjectId {
+					continue
+				}
+				pair := models.NewPastComparison(ap.ProjectId, bp.ProjectId, 0, round)
+				key := [2]primitive.ObjectID{pair.A, pair.B}
+				if _, ok := counts[key]; !ok {
+					order = append(order, key)
+				}
+				counts[key]++
+			}
+		}
+	}
+
+	out := make([]*models.PastComparison, 0, len(order))
+	for _, key := range order {
+		out = append(out, models.NewPastComparison(key[0], key[1], counts[key], round))
+	}
+	return out
+}
+
+// JudgesInRound returns the judges with the judging data they had in the given round.
+// For the current round, the judges are returned as is. For a closed round, each judge that
+// judged in it is copied with their seen projects and rankings from that round.
+func JudgesInRound(judges []*models.Judge, round int64, current int64) []*models.Judge {
+	if round == current {
+		return judges
+	}
+
+	var out []*models.Judge
+	for _, judge := range judges {
+		for _, past := range judge.PastRounds {
+			if past.Round != round {
+				continue
+			}
+			j := *judge
+			j.Seen = past.Seen
+			j.SeenProjects = past.SeenProjects
+			j.Rankings = past.Rankings
+			j.RankingTiers = past.RankingTiers
+			j.Flagged = past.Flagged
+			j.RankingsAgg = []models.AggRanking{}
+			j.Current = nil
+			out = append(out, &j)
+			break
+		}
+	}
+	return out
+}
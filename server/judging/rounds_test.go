@@ -0,0 +1,82 @@
+package judging
+
+import (
+	"server/models"
+	"slices"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestPickFinalists(t *testing.T) {
+	projects := []*models.Project{
+		{Id: primitive.NewObjectID(), Location: 1, Active: true, Round: 1},
+		{Id: primitive.NewObjectID(), Location: 2, Active: true, Round: 2},
+		{Id: primitive.NewObjectID(), Location: 3, Active: true, Round: 2},
+		{Id: primitive.NewObjectID(), Location: 4, Active: false, Round: 2},
+		{Id: primitive.NewObjectID(), Location: 5, Active: true, Round: 2},
+	}
+	scores := map[primitive.ObjectID]ProjectScores{
+		projects[0].Id: {Score: 100}, // Eliminated in round 1
+		projects[1].Id: {Score: 5},
+		projects[2].Id: {Score: 5},
+		projects[3].Id: {Score: 50}, // Inactive
+		projects[4].Id: {Score: 10},
+	}
+
+	// Ties are broken by table number
+	finalists := PickFinalists(projects, scores, 2, 2)
+	if len(finalists) != 2 || finalists[0] != projects[4] || finalists[1] != projects[1] {
+		t.Errorf("unexpected finalists %v", finalists)
+	}
+
+	// Every project is in the first round
+	if finalists := PickFinalists(projects, scores, 1, 10); len(finalists) != 4 || finalists[0] != projects[0] {
+		t.Errorf("unexpected first round finalists %v", finalists)
+	}
+}
+
+func TestJudgesInRound(t *testing.T) {
+	a, b := primitive.NewObjectID(), primitive.NewObjectID()
+	judge := rankingJudge([]primitive.ObjectID{b}, 1)
+	judge.PastRounds = []models.JudgeRound{{
+		Round:        1,
+		SeenProjects: []models.JudgedProject{{ProjectId: a}},
+		Rankings:     []primitive.ObjectID{a},
+	}}
+	fresh := rankingJudge([]primitive.ObjectID{b}, 1)
+
+	past := JudgesInRound([]*models.Judge{judge, fresh}, 1, 2)
+	if len(past) != 1 || !slices.Equal(past[0].Rankings, []primitive.ObjectID{a}) {
+		t.Errorf("expected only the first round rankings, got %v", past)
+	}
+	if !slices.Equal(judge.Rankings, []primitive.ObjectID{b}) {
+		t.Errorf("expected the judge's current rankings to be untouched")
+	}
+	if current := JudgesInRound([]*models.Judge{judge, fresh}, 2, 2); len(current) != 2 {
+		t.Errorf("expected all judges in the current round, got %d", len(current))
+	}
+}
+
+func TestRoundComparisons(t *testing.T) {
+	a, b, c := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	judges := []*models.Judge{
+		rankingJudge([]primitive.ObjectID{a, b, c}, 0),
+		rankingJudge([]primitive.ObjectID{b, a}, 0),
+		rankingJudge([]primitive.ObjectID{c}, 0),
+	}
+
+	comparisons := RoundComparisons(judges, 2)
+	if len(comparisons) != 3 {
+		t.Fatalf("expected 3 compared pairs, got %d", len(comparisons))
+	}
+	for _, comp := range comparisons {
+		if comp.Round != 2 || comp.A.Hex() > comp.B.Hex() {
+			t.Errorf("unexpected pair %v", comp)
+		}
+		ab := (comp.A == a && comp.B == b) || (comp.A == b && comp.B == a)
+		if ab && comp.Count != 2 || !ab && comp.Count != 1 {
+			t.Errorf("unexpected count %d for pair %v", comp.Count, comp)
+		}
+	}
+}
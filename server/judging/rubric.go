@@ -0,0 +1,63 @@
+package judging
+
+import (
+	"server/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RubricScore is the aggregated rubric result of a single project
+type RubricScore struct {
+	Criteria map[string]float64 `json:"criteria"` // Average score of each criterion
+	Total    float64            `json:"total"`    // Sum of each criterion's average times its weight
+	Judges   int64              `json:"judges"`   // Number of judges that scored at least one criterion
+}
+
+// ComputeRubricScores averages the judges' rubric scores for each project and criterion.
+// Each criterion is averaged only over the judges that scored it, so judges that skip
+// a criterion don't pull its average down. Scores for criteria that no longer exist are ignored.
+func ComputeRubricScores(judges []*models.Judge, criteria []models.Criterion) map[primitive.ObjectID]*RubricScore {
+	out := make(map[primitive.ObjectID]*RubricScore)
+	if len(criteria) == 0 {
+		return out
+	}
+
+	judges = withoutConflicts(judges)
+	sums := make(map[primitive.ObjectID]map[string]float64)
+	counts := make(map[primitive.ObjectID]map[string]int64)
+	for _, judge := range judges {
+		for _, p := range judge.SeenProjects {
+			scored := false
+			for _, c := range criteria {
+				score, ok := p.Scores[c.Name]
+				if !ok {
+					continue
+				}
+				if _, ok := sums[p.ProjectId]; !ok {
+					sums[p.ProjectId] = make(map[string]float64)
+					counts[p.ProjectId] = make(map[string]int64)
+					out[p.ProjectId] = &RubricScore{Criteria: make(map[string]float64)}
+				}
+				sums[p.ProjectId][c.Name] += float64(score)
+				counts[p.ProjectId][c.Name]++
+				scored = true
+			}
+			if scored {
+				out[p.ProjectId].Judges++
+			}
+		}
+	}
+
+	// Average each criterion and add up the weighted total
+	for id, rs := range out {
+		for _, c := range criteria {
+			if counts[id][c.Name] == 0 {
+				continue
+			}
+			avg := sums[id][c.Name] / float64(counts[id][c.Name])
+			rs.Criteria[c.Name] = avg
+			rs.Total += avg * c.Weight
+		}
+	}
+	return out
+}
@@ -0,0 +1,32 @@
+package judging
+
+import (
+	"server/models"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestComputeRubricScores(t *testing.T) {
+	a, b := primitive.NewObjectID(), primitive.NewObjectID()
+	criteria := []models.Criterion{
+		{Name: "Innovation", Min: 1, Max: 5, Weight: 2},
+		{Name: "Design", Min: 1, Max: 5, Weight: 1},
+	}
+
+	first := rankingJudge([]primitive.ObjectID{a, b}, 0)
+	first.SeenProjects[0].Scores = map[string]int64{"Innovation": 5, "Design": 2}
+	first.SeenProjects[1].Scores = map[string]int64{"Old": 5}
+	second := rankingJudge([]primitive.ObjectID{a}, 0)
+	second.SeenProjects[0].Scores = map[string]int64{"Innovation": 3}
+
+	scores := ComputeRubricScores([]*models.Judge{first, second}, criteria)
+
+	// Innovation averages to 4 and Design to 2 (only one judge scored it)
+	if rs := scores[a]; rs == nil || rs.Criteria["Innovation"] != 4 || rs.Criteria["Design"] != 2 || rs.Total != 10 || rs.Judges != 2 {
+		t.Errorf("unexpected rubric score for a: %+v", rs)
+	}
+	if _, ok := scores[b]; ok {
+		t.Errorf("expected scores for removed criteria to be ignored")
+	}
+}
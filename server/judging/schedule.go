@@ -0,0 +1,430 @@
+package judging
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"server/models"
+	"server/util"
+	"slices"
+	"sort"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ScheduleLimits are the limits that the itineraries are planned under
+type ScheduleLimits struct {
+	Length   int64 `json:"length"`    // Stops on each itinerary (0 = just enough for every project to reach its min views)
+	MaxPairs int64 `json:"max_pairs"` // Most itineraries any two projects can share, so no pair is compared too often (0 = no limit)
+}
+
+// ScheduleSummary describes how well the judges' itineraries cover the projects
+type ScheduleSummary struct {
+	Judges     int64                `json:"judges"`      // Judges with an itinerary
+	Stops      int64                `json:"stops"`       // Stops on all itineraries combined
+	MinPlanned int64                `json:"min_planned"` // Fewest views any project will have once every itinerary is finished
+	MaxPlanned int64                `json:"max_planned"` // Most views any project will have once every itinerary is finished
+	MaxPairs   int64                `json:"max_pairs"`   // Most itineraries any two projects share
+	Short      []primitive.ObjectID `json:"short"`       // Projects that won't reach their min views (or track views)
+}
+
+// schedulePool is a set of judges that judge the same projects, e.g. the judges of a track
+type schedulePool struct {
+	track    string
+	judges   []*models.Judge
+	projects []*models.Project
+	target   int64 // Views every project of the pool should get
+}
+
+// views returns how many times the project has already been seen by the pool's judges
+func (p *schedulePool) views(project *models.Project) int64 {
+	if p.track != "" {
+		return project.TrackSeen[p.track]
+	}
+	return project.Seen
+}
+
+// projectPair is an unordered pair of projects
+type projectPair [2]primitive.ObjectID
+
+func newProjectPair(a primitive.ObjectID, b primitive.ObjectID) projectPair {
+	if a.Hex() > b.Hex() {
+		a, b = b, a
+	}
+	return projectPair{a, b}
+}
+
+// ComputeSchedules plans a full itinerary for every active judge in the current round, so judges
+// can follow a fixed route without picking projects on the fly (e.g. at venues without reliable Wi-Fi).
+// Judges are split into pools of judges that judge the same projects: each track's judges (if
+// track judging is on), each group's general judges (if multi-group is on), or all general judges.
+//
+// The itineraries are planned one stop at a time, with each judge of a pool in turn picking the project with:
+//  1. The fewest views so far, counting the stops already planned, so every project reaches its min views (or track views)
+//  2. No other judge at it in the same time slot
+//  3. The fewest itineraries in common with the judge's other stops, so comparisons are spread evenly
+//  4. The shortest walk from the judge's previous stop (on the floor plan, or else by table number)
+//
+// Any project still short of its views afterwards is added to the end of the shortest itineraries it can go on.
+// Projects the judge has seen, flagged, or has a conflict with are never planned, and no stop is planned that would
+// put two projects on more than limits.MaxPairs itineraries together. Projects that are left short are listed in the summary.
+func ComputeSchedules(judges []*models.Judge, projects []*models.Project, op *models.Options, limits ScheduleLimits) (map[primitive.ObjectID][]models.ScheduleStop, *ScheduleSummary) {
+	schedules := make(map[primitive.ObjectID][]models.ScheduleStop)
+	pools := schedulePools(judges, projects, op)
+
+	for _, pool := range pools {
+		// Start from the views the projects already have
+		planned := make(map[primitive.ObjectID]int64)
+		for _, p := range pool.projects {
+			planned[p.Id] = pool.views(p)
+		}
+		pairs := make(map[projectPair]int64)
+		done := make(map[primitive.ObjectID]map[primitive.ObjectID]bool)
+		for _, judge := range pool.judges {
+			done[judge.Id] = judgeDoneProjects(judge)
+		}
+
+		// Plan just enough stops for every project to reach the target, unless a length is given
+		length := limits.Length
+		if length <= 0 {
+			var needed int64
+			for _, p := range pool.projects {
+				needed += max(0, pool.target-planned[p.Id])
+			}
+			length = (needed + int64(len(pool.judges)) - 1) / int64(len(pool.judges))
+		}
+
+		// Shuffle so that ties are broken at random
+		candidates := slices.Clone(pool.projects)
+		shuffleProjects(candidates)
+
+		stops := make(map[primitive.ObjectID][]*models.Project)
+		add := func(judge *models.Judge, p *models.Project) {
+			for _, s := range stops[judge.Id] {
+				pairs[newProjectPair(s.Id, p.Id)]++
+			}
+			stops[judge.Id] = append(stops[judge.Id], p)
+			planned[p.Id]++
+		}
+		for slot := int64(0); slot < length; slot++ {
+			// Rotate which judge picks first so no judge always gets the leftovers
+			taken := make(map[primitive.ObjectID]bool)
+			progress := false
+			for i := range pool.judges {
+				judge := pool.judges[(i+int(slot))%len(pool.judges)]
+				best := pickScheduleStop(judge, candidates, stops[judge.Id], done[judge.Id], planned, pairs, taken, op, limits.MaxPairs)
+				if best == nil {
+					continue
+				}
+
+				add(judge, best)
+				taken[best.Id] = true
+				progress = true
+			}
+			if !progress {
+				break
+			}
+		}
+
+		// Projects can be left short when the only judges that could still see them have no stops left,
+		// so add them to the end of the shortest itineraries that they can go on
+		for _, p := range candidates {
+			for planned[p.Id] < pool.target {
+				var shortest *models.Judge
+				for _, judge := range pool.judges {
+					if shortest != nil && len(stops[judge.Id]) >= len(stops[shortest.Id]) {
+						continue
+					}
+					if pickScheduleStop(judge, []*models.Project{p}, stops[judge.Id], done[judge.Id], planned, pairs, nil, op, limits.MaxPairs) != nil {
+						shortest = judge
+					}
+				}
+				if shortest == nil {
+					break
+				}
+				add(shortest, p)
+			}
+		}
+
+		for _, judge := range pool.judges {
+			schedules[judge.Id] = []models.ScheduleStop{}
+			for _, p := range stops[judge.Id] {
+				schedules[judge.Id] = append(schedules[judge.Id], *models.NewScheduleStop(p))
+			}
+		}
+	}
+
+	// Summarize the plan with the itineraries filled in
+	withSchedules := make([]*models.Judge, len(judges))
+	for i, judge := range judges {
+		j := *judge
+		j.Schedule = schedules[judge.Id]
+		withSchedules[i] = &j
+	}
+	return schedules, SummarizeSchedules(withSchedules, projects, op)
+}
+
+// pickScheduleStop picks the judge's next stop out of the candidates (see ComputeSchedules).
+// Returns nil if no candidate can be added to the judge's itinerary.
+func pickScheduleStop(
+	judge *models.Judge,
+	candidates []*models.Project,
+	stops []*models.Project,
+	done map[primitive.ObjectID]bool,
+	planned map[primitive.ObjectID]int64,
+	pairs map[projectPair]int64,
+	taken map[primitive.ObjectID]bool,
+	op *models.Options,
+	maxPairs int64,
+) *models.Project {
+	from := judge.LastLocation
+	if len(stops) > 0 {
+		from = stops[len(stops)-1].Location
+	}
+
+	var best *models.Project
+	var bestKey [4]float64
+	for _, c := range candidates {
+		if done[c.Id] || slices.Contains(stops, c) {
+			continue
+		}
+
+		// Count the itineraries the candidate shares with each of the judge's stops
+		var shared int64
+		tooMany := false
+		for _, s := range stops {
+			n := pairs[newProjectPair(s.Id, c.Id)]
+			if maxPairs > 0 && n >= maxPairs {
+				tooMany = true
+				break
+			}
+			shared += n
+		}
+		if tooMany {
+			continue
+		}
+
+		busy := 0.0
+		if taken[c.Id] {
+			busy = 1
+		}
+		key := [4]float64{float64(planned[c.Id]), busy, float64(shared), walkDistance(from, c.Location, op)}
+		if best == nil || slices.Compare(key[:], bestKey[:]) < 0 {
+			best = c
+			bestKey = key
+		}
+	}
+	return best
+}
+
+// walkDistance is the distance between two tables on the floor plan. Without a floor plan (or if
+// either table isn't on it), tables with close numbers are assumed to be close together.
+func walkDistance(from int64, to int64, op *models.Options) float64 {
+	if from == -1 {
+		return 0
+	}
+	if dist, ok := op.FloorPlan.Distance(from, to); ok {
+		return dist
+	}
+	return math.Abs(float64(to - from))
+}
+
+// SummarizeSchedules describes how well the judges' itineraries cover the projects.
+// Stops that a judge has already seen are counted in the projects' views, so the summary
+// stays correct while judges work through their itineraries.
+func SummarizeSchedules(judges []*models.Judge, projects []*models.Project, op *models.Options) *ScheduleSummary {
+	summary := &ScheduleSummary{Short: []primitive.ObjectID{}}
+	first := true
+	for _, pool := range schedulePools(judges, projects, op) {
+		planned := make(map[primitive.ObjectID]int64)
+		for _, p := range pool.projects {
+			planned[p.Id] = pool.views(p)
+		}
+		pairs := make(map[projectPair]int64)
+		for _, judge := range pool.judges {
+			if len(judge.Schedule) == 0 {
+				continue
+			}
+			summary.Judges++
+			summary.Stops += int64(len(judge.Schedule))
+
+			seen := judgeDoneProjects(judge)
+			for i, s := range judge.Schedule {
+				if !seen[s.ProjectId] {
+					planned[s.ProjectId]++
+				}
+				for _, t := range judge.Schedule[i+1:] {
+					pair := newProjectPair(s.ProjectId, t.ProjectId)
+					pairs[pair]++
+					summary.MaxPairs = max(summary.MaxPairs, pairs[pair])
+				}
+			}
+		}
+
+		for _, p := range pool.projects {
+			if first {
+				summary.MinPlanned, summary.MaxPlanned = planned[p.Id], planned[p.Id]
+				first = false
+			}
+			summary.MinPlanned = min(summary.MinPlanned, planned[p.Id])
+			summary.MaxPlanned = max(summary.MaxPlanned, planned[p.Id])
+			if planned[p.Id] < pool.target && !slices.Contains(summary.Short, p.Id) {
+				summary.Short = append(summary.Short, p.Id)
+			}
+		}
+	}
+	return summary
+}
+
+// schedulePools splits the active judges of the current round into pools of judges that judge the
+// same projects (see ComputeSchedules). Only active projects in the current round are included.
+// Pools are returned in a fixed order, with the judges of each pool in the order given.
+func schedulePools(judges []*models.Judge, projects []*models.Project, op *models.Options) []*schedulePool {
+	round := CurrentRound(op)
+	var available []*models.Project
+	for _, p := range projects {
+		if p.Active && ProjectInRound(p, round) {
+			available = append(available, p)
+		}
+	}
+
+	pools := make(map[string]*schedulePool)
+	var keys []string
+	for _, judge := range judges {
+		if !judge.Active || !JudgeInRound(judge, round) {
+			continue
+		}
+
+		// Find the judge's pool, creating it if this is its first judge
+		key := ""
+		if op.JudgeTracks && judge.Track != "" {
+			key = "track:" + judge.Track
+		} else if op.MultiGroup {
+			key = fmt.Sprintf("group:%d", judge.Group)
+		}
+		pool, ok := pools[key]
+		if !ok {
+			pool = newSchedulePool(judge, available, op)
+			if pool == nil {
+				continue
+			}
+			pools[key] = pool
+			keys = append(keys, key)
+		}
+		pool.judges = append(pool.judges, judge)
+	}
+
+	sort.Strings(keys)
+	out := make([]*schedulePool, 0, len(keys))
+	for _, key := range keys {
+		if len(pools[key].projects) > 0 {
+			out = append(out, pools[key])
+		}
+	}
+	return out
+}
+
+// newSchedulePool creates the (empty) pool the judge belongs to, with the projects that the pool judges.
+// Returns nil if the judge's track doesn't exist.
+func newSchedulePool(judge *models.Judge, available []*models.Project, op *models.Options) *schedulePool {
+	// Track judges judge the projects in their track
+	if op.JudgeTracks && judge.Track != "" {
+		idx := slices.Index(op.Tracks, judge.Track)
+		if idx == -1 || idx >= len(op.TrackViews) {
+			return nil
+		}
+		pool := &schedulePool{track: judge.Track, target: op.TrackViews[idx]}
+		for _, p := range available {
+			if slices.Contains(p.ChallengeList, judge.Track) {
+				pool.projects = append(pool.projects, p)
+			}
+		}
+		return pool
+	}
+
+	// General judges judge the projects in their group, or every project if the group is empty
+	pool := &schedulePool{target: op.MinViews}
+	if op.MultiGroup {
+		for _, p := range available {
+			if p.Group == judge.Group {
+				pool.projects = append(pool.projects, p)
+			}
+		}
+	}
+	if len(pool.projects) == 0 {
+		pool.projects = available
+	}
+	return pool
+}
+
+// judgeDoneProjects returns the set of projects the judge has seen, flagged, or has a conflict with
+func judgeDoneProjects(judge *models.Judge) map[primitive.ObjectID]bool {
+	done := make(map[primitive.ObjectID]bool)
+	for _, p := range judge.SeenProjects {
+		done[p.ProjectId] = true
+	}
+	for _, id := range judge.Flagged {
+		done[id] = true
+	}
+	for _, id := range judge.Conflicts {
+		done[id] = true
+	}
+	return done
+}
+
+// NextScheduledProject returns the first stop on the judge's itinerary that they haven't seen,
+// skipped, or flagged yet. Stops that were hidden, removed from the round, or that the judge has
+// a conflict with are passed over, and stops skipped because they were busy are revisited once
+// the rest of the itinerary is done. Returns nil once the judge has finished their itinerary.
+func NextScheduledProject(store Store, ctx context.Context, judge *models.Judge, op *models.Options) (*models.Project, error) {
+	// Judges outside of the current round's pool have nothing to judge
+	round := CurrentRound(op)
+	if !JudgeInRound(judge, round) {
+		return nil, nil
+	}
+
+	projects, err := store.FindActiveProjects(ctx)
+	if err != nil {
+		return nil, err
+	}
+	flags, err := store.FindFlagsByJudge(ctx, judge)
+	if err != nil {
+		return nil, err
+	}
+
+	// Stops whose team is away are put off until they're back
+	now := util.Now()
+	projects = slices.DeleteFunc(projects, func(p *models.Project) bool { return !p.AvailableAt(now) })
+
+	return nextScheduledProject(judge, projects, flags, round), nil
+}
+
+// nextScheduledProject picks the judge's next stop out of the active projects (see NextScheduledProject)
+func nextScheduledProject(judge *models.Judge, projects []*models.Project, flags []*models.Flag, round int64) *models.Project {
+	done := judgeDoneProjects(judge)
+	busy := make(map[primitive.ObjectID]bool)
+	for _, flag := range flags {
+		if flag.ProjectId == nil {
+			continue
+		}
+		if flag.Reason == "busy" {
+			busy[*flag.ProjectId] = true
+		} else {
+			done[*flag.ProjectId] = true
+		}
+	}
+
+	// Go down the itinerary, then come back to the stops that were busy
+	for _, revisit := range []bool{false, true} {
+		for _, stop := range judge.Schedule {
+			if done[stop.ProjectId] || busy[stop.ProjectId] != revisit {
+				continue
+			}
+			idx := slices.IndexFunc(projects, func(p *models.Project) bool { return p.Id == stop.ProjectId })
+			if idx != -1 && ProjectInRound(projects[idx], round) {
+				return projects[idx]
+			}
+		}
+	}
+	return nil
+}
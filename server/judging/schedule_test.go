@@ -0,0 +1,65 @@
+package judging
+
+import (
+	"server/models"
+	"slices"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestComputeSchedules(t *testing.T) {
+	var projects []*models.Project
+	for i := int64(1); i <= 10; i++ {
+		projects = append(projects, &models.Project{Id: primitive.NewObjectID(), Location: i, Active: true})
+	}
+	var judges []*models.Judge
+	for range 5 {
+		judge := models.NewJudge("judge", "", "", "", 0)
+		judge.Id = primitive.NewObjectID()
+		judges = append(judges, judge)
+	}
+	judges[0].Conflicts = []primitive.ObjectID{projects[0].Id}
+	op := models.NewOptions()
+
+	// Every project reaches min views, no judge sees a project twice or one they're conflicted with
+	schedules, summary := ComputeSchedules(judges, projects, op, ScheduleLimits{})
+	if len(summary.Short) != 0 || summary.MinPlanned < op.MinViews {
+		t.Errorf("expected every project to reach %d views, got %+v", op.MinViews, summary)
+	}
+	for _, judge := range judges {
+		stops := schedules[judge.Id]
+		if len(stops) < 6 || len(stops) > 7 {
+			t.Errorf("expected 6 stops (7 with a top-up) for each judge, got %d", len(stops))
+		}
+		seen := make(map[primitive.ObjectID]bool)
+		for _, s := range stops {
+			if seen[s.ProjectId] || slices.Contains(judge.Conflicts, s.ProjectId) {
+				t.Errorf("judge was scheduled to see project %s twice or despite a conflict", s.ProjectId.Hex())
+			}
+			seen[s.ProjectId] = true
+		}
+	}
+
+	// No pair of projects shares more itineraries than the limit
+	_, summary = ComputeSchedules(judges, projects, op, ScheduleLimits{Length: 4, MaxPairs: 1})
+	if summary.MaxPairs > 1 {
+		t.Errorf("expected no pair of projects on more than 1 itinerary, got %d", summary.MaxPairs)
+	}
+
+	// Judges follow their itinerary, coming back to busy stops at the end
+	judge := judges[1]
+	judge.Schedule = schedules[judge.Id]
+	judge.SeenProjects = []models.JudgedProject{{ProjectId: judge.Schedule[0].ProjectId}}
+	busy := judge.Schedule[1].ProjectId
+	flags := []*models.Flag{{ProjectId: &busy, Reason: "busy"}}
+	if p := nextScheduledProject(judge, projects, flags, 1); p == nil || p.Id != judge.Schedule[2].ProjectId {
+		t.Errorf("expected the judge to skip the seen and busy stops")
+	}
+	for _, s := range judge.Schedule[2:] {
+		judge.SeenProjects = append(judge.SeenProjects, models.JudgedProject{ProjectId: s.ProjectId})
+	}
+	if p := nextScheduledProject(judge, projects, flags, 1); p == nil || p.Id != busy {
+		t.Errorf("expected the judge to come back to the busy stop")
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"server/database"
 	"server/models"
+	"server/util"
 	"slices"
 
 	"github.com/gin-gonic/gin"
@@ -13,9 +14,15 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-// AggregateRanking will take the ranking array from the judge and create
-// the aggregated ranking model using the Copeland counting method
-func AggregateRanking(judge *models.Judge) []models.AggRanking {
+// AggregateRanking will take the ranking tiers from the judge and create
+// the aggregated ranking model using the given aggregation method.
+// Projects in the same tier are tied, which counts as a draw between them.
+// Schulze, Ranked Pairs, and Kemeny can only be resolved across all judges,
+// so each judge stores their Copeland (pairwise wins minus losses) scores for those.
+func AggregateRanking(judge *models.Judge, method string) []models.AggRanking {
+	judge = WithoutConflicts(judge)
+	tiers := util.GetRankingTiers(judge)
+
 	// Get unranked projects
 	var unranked []primitive.ObjectID
 	for _, p := range judge.SeenProjects {
@@ -28,20 +35,33 @@ func AggregateRanking(judge *models.Judge) []models.AggRanking {
 	rc := len(judge.Rankings)
 	uc := len(unranked)
 	out := make([]models.AggRanking, 0, rc+uc)
+	borda := NormalizeRankingMethod(method) == MethodBorda
 
-	// Calculate scores for ranked projects
-	// score = won - loss + unranked count
-	// score = (rc - i - 1) - i + uc = rc + uc - 1 - 2i
-	// c = rc + uc - 1; score = c - 2i
-	c := rc + uc - 1
-	for i, p := range judge.Rankings {
-		out = append(out, *models.NewAggRanking(p, int64(c-2*i)))
+	// Calculate scores for ranked projects, tier by tier
+	above := 0
+	for _, tier := range tiers {
+		below := rc - above - len(tier) + uc
+		for _, p := range tier {
+			if borda {
+				// Borda count: a point for every project below and half a point for every tie
+				out = append(out, *models.NewAggRanking(p, float64(below)+0.5*float64(len(tier)-1)))
+			} else {
+				// score = won - loss (ties cancel out)
+				out = append(out, *models.NewAggRanking(p, float64(below-above)))
+			}
+		}
+		above += len(tier)
 	}
 
 	// Calculate scores for unranked projects
-	// score = -rc (beaten by all ranked projects)
+	// Borda: 0, nothing is below them
+	// Copeland: -rc (beaten by all ranked projects)
 	for _, p := range unranked {
-		out = append(out, *models.NewAggRanking(p, int64(-rc)))
+		if borda {
+			out = append(out, *models.NewAggRanking(p, 0))
+		} else {
+			out = append(out, *models.NewAggRanking(p, float64(-rc)))
+		}
 	}
 
 	return out
@@ -62,10 +82,16 @@ func InitAggregateRankings(db *mongo.Database) error {
 			return nil
 		}
 
+		// Get the ranking method from the options
+		op, err := database.GetOptions(db, sc)
+		if err != nil {
+			return err
+		}
+
 		// Use bulk write to update all judges' aggregated rankings
 		models := make([]mongo.WriteModel, 0, len(judges))
 		for _, judge := range judges {
-			agg := AggregateRanking(judge)
+			agg := AggregateRanking(judge, op.RankingMethod)
 			models = append(models, mongo.NewUpdateOneModel().SetFilter(gin.H{"_id": judge.Id}).SetUpdate(gin.H{"$set": gin.H{"rankings_agg": agg}}))
 		}
 		opts := options.BulkWrite().SetOrdered(false)
@@ -74,46 +100,69 @@ func InitAggregateRankings(db *mongo.Database) error {
 	})
 }
 
+// judgeWeightExpr is the aggregation expression for a judge's weight,
+// treating judges created before weights existed (missing or 0) as 1
+var judgeWeightExpr = bson.D{{Key: "$cond", Value: bson.A{
+	bson.D{{Key: "$gt", Value: bson.A{bson.D{{Key: "$ifNull", Value: bson.A{"$weight", 0}}}, 0}}},
+	"$weight",
+	1,
+}}}
+
+// notConflictedExpr is the aggregation expression that is true if the unwound seen project
+// is not one the judge has a conflict of interest with
+var notConflictedExpr = gin.H{"$not": bson.A{
+	gin.H{"$in": bson.A{"$seen_projects.project_id", gin.H{"$ifNull": bson.A{"$conflicts", bson.A{}}}}},
+}}
+
 type ProjectScores struct {
-	Score      int64            `bson:"score" json:"score"`
-	Stars      int64            `bson:"stars" json:"stars"`
-	TrackStars map[string]int64 `bson:"track_stars" json:"track_stars"`
+	Score             float64            `bson:"score" json:"score"`
+	Stars             int64              `bson:"stars" json:"stars"`
+	TrackStars        map[string]int64   `bson:"track_stars" json:"track_stars"`
+	TrackScores       map[string]float64 `bson:"track_scores" json:"track_scores"`
+	RubricScores      map[string]float64 `bson:"-" json:"rubric_scores"`       // Filled in from ComputeRubricScores, not the pipeline
+	RubricTotal       float64            `bson:"-" json:"rubric_total"`        // Filled in from ComputeRubricScores, not the pipeline
+	TrackRubricTotals map[string]float64 `bson:"-" json:"track_rubric_totals"` // Filled in from ComputeRubricScores, not the pipeline
 }
 
 type ProjectScoresWithId struct {
-	ProjectId  primitive.ObjectID `bson:"_id" json:"_id"`
-	Score      int64              `bson:"score" json:"score"`
-	Stars      int64              `bson:"stars" json:"stars"`
-	TrackStars map[string]int64   `bson:"track_stars" json:"track_stars"`
+	ProjectId   primitive.ObjectID `bson:"_id" json:"_id"`
+	Score       float64            `bson:"score" json:"score"`
+	Stars       int64              `bson:"stars" json:"stars"`
+	TrackStars  map[string]int64   `bson:"track_stars" json:"track_stars"`
+	TrackScores map[string]float64 `bson:"track_scores" json:"track_scores"`
 }
 
 func removeId(scoresWithId *ProjectScoresWithId) *ProjectScores {
 	return &ProjectScores{
-		Score:      scoresWithId.Score,
-		Stars:      scoresWithId.Stars,
-		TrackStars: scoresWithId.TrackStars,
+		Score:       scoresWithId.Score,
+		Stars:       scoresWithId.Stars,
+		TrackStars:  scoresWithId.TrackStars,
+		TrackScores: scoresWithId.TrackScores,
 	}
 }
 
-// AggregateScores takes the scores and stars from judges and aggregates them to form a final ranking
+// AggregateScores takes the scores and stars from judges and aggregates them to form a final ranking.
+// Each judge's ranking scores are multiplied by the judge's weight. Track judges' rankings
+// are aggregated separately for each track into TrackScores. If rubric criteria are set, the
+// rubric scores of general judges and the rubric totals of each track are filled in as well.
 func AggregateScores(db *mongo.Database, ctx context.Context) (map[primitive.ObjectID]ProjectScores, error) {
 	pipeline := mongo.Pipeline{
-		// === Pipeline 1: Aggregate all general judges' ranking scores ===
-		bson.D{{"$match", bson.D{{"track", ""}}}},
+		// === Pipeline 1: Aggregate all general judges' weighted ranking scores ===
+		bson.D{{Key: "$match", Value: bson.D{{Key: "track", Value: ""}}}},
 
-		bson.D{{"$unwind", "$rankings_agg"}},
-		bson.D{{"$group", bson.D{
-			{"_id", "$rankings_agg.project_id"},
-			{"score", bson.D{{"$sum", "$rankings_agg.score"}}},
+		bson.D{{Key: "$unwind", Value: "$rankings_agg"}},
+		bson.D{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: "$rankings_agg.project_id"},
+			{Key: "score", Value: bson.D{{Key: "$sum", Value: bson.D{{Key: "$multiply", Value: bson.A{"$rankings_agg.score", judgeWeightExpr}}}}}},
 		}}},
 
 		// === Pipeline 2: Aggregate all general judges' stars ===
-		bson.D{{"$unionWith", gin.H{
+		bson.D{{Key: "$unionWith", Value: gin.H{
 			"coll": "judges",
 			"pipeline": []gin.H{
 				{"$match": gin.H{"track": ""}},
 				{"$unwind": "$seen_projects"},
-				{"$match": gin.H{"seen_projects.starred": true}},
+				{"$match": gin.H{"seen_projects.starred": true, "$expr": notConflictedExpr}},
 				{"$group": gin.H{
 					"_id":   "$seen_projects.project_id",
 					"stars": gin.H{"$sum": 1},
@@ -122,19 +171,19 @@ func AggregateScores(db *mongo.Database, ctx context.Context) (map[primitive.Obj
 		}}},
 
 		// === Combine scores and stars (first stage) ===
-		bson.D{{"$group", bson.D{
-			{"_id", "$_id"},
-			{"score", bson.D{{"$sum", "$score"}}},
-			{"stars", bson.D{{"$sum", "$stars"}}},
+		bson.D{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: "$_id"},
+			{Key: "score", Value: bson.D{{Key: "$sum", Value: "$score"}}},
+			{Key: "stars", Value: bson.D{{Key: "$sum", Value: "$stars"}}},
 		}}},
 
 		// // === Pipeline 3: Aggregate all track judges' stars, grouped by project and track ===
-		bson.D{{"$unionWith", gin.H{
+		bson.D{{Key: "$unionWith", Value: gin.H{
 			"coll": "judges",
 			"pipeline": []gin.H{
 				{"$match": gin.H{"track": gin.H{"$ne": ""}}},
 				{"$unwind": "$seen_projects"},
-				{"$match": gin.H{"seen_projects.starred": true}},
+				{"$match": gin.H{"seen_projects.starred": true, "$expr": notConflictedExpr}},
 				{"$group": gin.H{
 					"_id": gin.H{
 						"projectId": "$seen_projects.project_id",
@@ -159,12 +208,43 @@ func AggregateScores(db *mongo.Database, ctx context.Context) (map[primitive.Obj
 			},
 		}}},
 
+		// === Pipeline 4: Aggregate all track judges' weighted ranking scores, grouped by project and track ===
+		bson.D{{Key: "$unionWith", Value: gin.H{
+			"coll": "judges",
+			"pipeline": []gin.H{
+				{"$match": gin.H{"track": gin.H{"$ne": ""}}},
+				{"$unwind": "$rankings_agg"},
+				{"$group": gin.H{
+					"_id": gin.H{
+						"projectId": "$rankings_agg.project_id",
+						"track":     "$track",
+					},
+					"score": gin.H{"$sum": gin.H{"$multiply": bson.A{"$rankings_agg.score", judgeWeightExpr}}},
+				}},
+				{"$group": gin.H{
+					"_id": "$_id.projectId",
+					"track_scores": gin.H{
+						"$push": gin.H{
+							"k": "$_id.track",
+							"v": "$score",
+						},
+					},
+				}},
+				{"$addFields": gin.H{
+					"track_scores": gin.H{
+						"$arrayToObject": "$track_scores",
+					},
+				}},
+			},
+		}}},
+
 		// // === Final merge of all fields ===
-		bson.D{{"$group", bson.D{
-			{"_id", "$_id"},
-			{"score", bson.D{{"$sum", "$score"}}},
-			{"stars", bson.D{{"$sum", "$stars"}}},
-			{"track_stars", bson.D{{"$mergeObjects", "$track_stars"}}},
+		bson.D{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: "$_id"},
+			{Key: "score", Value: bson.D{{Key: "$sum", Value: "$score"}}},
+			{Key: "stars", Value: bson.D{{Key: "$sum", Value: "$stars"}}},
+			{Key: "track_stars", Value: bson.D{{Key: "$mergeObjects", Value: "$track_stars"}}},
+			{Key: "track_scores", Value: bson.D{{Key: "$mergeObjects", Value: "$track_scores"}}},
 		}}},
 	}
 
@@ -184,5 +264,62 @@ func AggregateScores(db *mongo.Database, ctx context.Context) (map[primitive.Obj
 		out[p.ProjectId] = *removeId(&p)
 	}
 
+	// Pairwise methods and rubric scores can't be summed per judge, so calculate them from all judges
+	op, err := database.GetOptions(db, ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !isPairwiseMethod(op.RankingMethod) && len(op.Criteria) == 0 {
+		return out, nil
+	}
+	judges, err := database.FindAllJudges(db, ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Split the judges by track ("" is the general track)
+	byTrack := make(map[string][]*models.Judge)
+	for _, judge := range judges {
+		byTrack[judge.Track] = append(byTrack[judge.Track], judge)
+	}
+
+	if isPairwiseMethod(op.RankingMethod) {
+		methodScores := ComputeMethodScores(byTrack[""], op.RankingMethod)
+		trackMethodScores := make(map[string]map[primitive.ObjectID]float64)
+		for track, trackJudges := range byTrack {
+			if track != "" {
+				trackMethodScores[track] = ComputeMethodScores(trackJudges, op.RankingMethod)
+			}
+		}
+
+		for id, p := range out {
+			p.Score = methodScores[id]
+			for track, scores := range trackMethodScores {
+				if _, ok := p.TrackScores[track]; ok {
+					p.TrackScores[track] = scores[id]
+				}
+			}
+			out[id] = p
+		}
+	}
+
+	if len(op.Criteria) > 0 {
+		for track, trackJudges := range byTrack {
+			for id, rs := range ComputeRubricScores(trackJudges, op.Criteria) {
+				p := out[id]
+				if track == "" {
+					p.RubricScores = rs.Criteria
+					p.RubricTotal = rs.Total
+				} else {
+					if p.TrackRubricTotals == nil {
+						p.TrackRubricTotals = make(map[string]float64)
+					}
+					p.TrackRubricTotals[track] = rs.Total
+				}
+				out[id] = p
+			}
+		}
+	}
+
 	return out, nil
 }
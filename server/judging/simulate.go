@@ -0,0 +1,284 @@
+package judging
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"server/models"
+	"server/util"
+	"slices"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SimulationConfig describes the synthetic event run by Simulate
+type SimulationConfig struct {
+	Projects int     // Number of synthetic projects
+	Judges   int     // Number of synthetic judges
+	Views    int     // Number of projects each judge judges (set as their quota)
+	Noise    float64 // Standard deviation of a judge's error when perceiving a project (true quality is standard normal)
+	TopN     int     // Number of top places to compare between the true and recovered rankings
+	Seed     int64   // Seed for the synthetic projects and judges (assignment itself still uses the global rand)
+}
+
+// SimulationReport is how well the assignment and ranking settings did in a simulated event
+type SimulationReport struct {
+	Projects     int           `json:"projects"`
+	Judges       int           `json:"judges"`
+	TotalViews   int64         `json:"total_views"`
+	MinViews     int64         `json:"min_views"`
+	MaxViews     int64         `json:"max_views"`
+	MeanViews    float64       `json:"mean_views"`
+	ViewCounts   map[int64]int `json:"view_counts"`   // Number of projects with each number of views
+	Unseen       int           `json:"unseen"`        // Projects no judge saw
+	BelowMin     int           `json:"below_min"`     // Projects seen fewer than the min views option
+	PairCoverage float64       `json:"pair_coverage"` // Fraction of project pairs that at least one judge compared
+	Connected    float64       `json:"connected"`     // Fraction of projects in the largest connected part of the comparison graph
+	Spearman     float64       `json:"spearman"`      // Rank correlation between the true and recovered rankings of the seen projects
+	TopN         int           `json:"top_n"`
+	TopOverlap   int           `json:"top_overlap"` // Number of the true top N projects that are also in the recovered top N
+}
+
+// Simulate runs synthetic judges and projects through the real judging flow (PickNextProject,
+// the group switching, and AggregateRanking) to see how the given options would perform at an event.
+// Each project has a hidden true quality, and each judge ranks the projects they've seen by
+// their noisy perception of that quality. In every step, all judges pick a project before any of them
+// finish, so projects held by other judges are busy like at a real event.
+//
+// The event is kept in a MemoryStore, so no database is needed.
+func Simulate(ctx context.Context, op *models.Options, cfg *SimulationConfig) (*SimulationReport, error) {
+	rng := rand.New(rand.NewSource(cfg.Seed))
+
+	// Create the projects, each with a hidden quality
+	projects := make([]*models.Project, cfg.Projects)
+	quality := make(map[primitive.ObjectID]float64, cfg.Projects)
+	for i := range projects {
+		table := int64(i + 1)
+		projects[i] = models.NewProject(fmt.Sprintf("Project %d", table), table, util.GroupFromTable(op, table), "", "", "", "", []string{})
+		projects[i].Id = primitive.NewObjectID()
+		quality[projects[i].Id] = rng.NormFloat64()
+	}
+
+	// Create the judges, spread evenly across the groups
+	judges := make([]*models.Judge, cfg.Judges)
+	for i := range judges {
+		group := int64(0)
+		if op.MultiGroup && op.NumGroups > 0 {
+			group = int64(i) % op.NumGroups
+		}
+		judges[i] = models.NewJudge(fmt.Sprintf("Judge %d", i+1), "", "", "", group)
+		judges[i].Id = primitive.NewObjectID()
+		judges[i].Quota = int64(cfg.Views)
+	}
+
+	store := NewMemoryStore(op, projects, judges)
+	comps := NewComparisons()
+	perceived := make(map[primitive.ObjectID]map[primitive.ObjectID]float64, cfg.Judges)
+	active := make([]primitive.ObjectID, cfg.Judges)
+	for i, judge := range judges {
+		active[i] = judge.Id
+		perceived[judge.Id] = make(map[primitive.ObjectID]float64)
+	}
+
+	for len(active) > 0 {
+		// Every judge picks a project first, so that the projects of other judges are busy
+		var holding []primitive.ObjectID
+		for _, id := range active {
+			judge, err := store.FindJudge(id)
+			if err != nil {
+				return nil, err
+			}
+			project, err := PickNextProject(store, ctx, judge, comps, 0)
+			if err != nil {
+				return nil, fmt.Errorf("error picking next project: %s", err.Error())
+			}
+			if project == nil {
+				continue
+			}
+			err = store.UpdateAfterPicked(project, judge, LeaseExpiry(op, time.Now()), 0)
+			if err != nil {
+				return nil, err
+			}
+			holding = append(holding, id)
+		}
+		active = holding
+
+		// Then every judge finishes their project and re-ranks everything they've seen
+		for _, id := range active {
+			err := simulateFinish(store, ctx, op, id, comps, quality, perceived[id], rng, cfg.Noise)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// Get the final state of the simulated event
+	return simulationReport(store.FindAllProjects(), store.FindAllJudges(), comps, quality, op, cfg.TopN), nil
+}
+
+// simulateFinish finishes the judge's current project the same way as POST /judge/finish,
+// then ranks all of the judge's projects by how good they seemed to the judge like POST /judge/rank
+func simulateFinish(store *MemoryStore, ctx context.Context, op *models.Options, id primitive.ObjectID, comps *Comparisons, quality map[primitive.ObjectID]float64, perceived map[primitive.ObjectID]float64, rng *rand.Rand, noise float64) error {
+	judge, err := store.FindJudge(id)
+	if err != nil {
+		return err
+	}
+	project, err := store.FindProject(*judge.Current)
+	if err != nil {
+		return err
+	}
+	judged := models.JudgeProjectFromProject(project, "", false)
+
+	// Move the judge to the next group if needed
+	if op.MultiGroup && op.SwitchingMode == "auto" {
+		err = MoveJudgeGroup(store, ctx, judge, op)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Update the judge, project, and comparisons
+	err = store.UpdateAfterSeen(judge, judged)
+	if err != nil {
+		return err
+	}
+	comps.ApplyComparisons(SeenComparisons(judge.SeenProjects, project.Id, 1))
+	judge.SeenProjects = append(judge.SeenProjects, *judged)
+
+	// Rank every seen project by the judge's perception of it
+	perceived[project.Id] = quality[project.Id] + rng.NormFloat64()*noise
+	ranking := make([]primitive.ObjectID, len(judge.SeenProjects))
+	for i, p := range judge.SeenProjects {
+		ranking[i] = p.ProjectId
+	}
+	sort.SliceStable(ranking, func(a, b int) bool {
+		return perceived[ranking[a]] > perceived[ranking[b]]
+	})
+	tiers := make([][]primitive.ObjectID, len(ranking))
+	for i, p := range ranking {
+		tiers[i] = []primitive.ObjectID{p}
+	}
+	judge.Rankings = ranking
+	judge.RankingTiers = tiers
+	return store.UpdateJudgeRanking(judge.Id, ranking, tiers, AggregateRanking(judge, op.RankingMethod))
+}
+
+// simulationReport measures the views, comparison coverage, and ranking recovery of a finished simulation
+func simulationReport(projects []*models.Project, judges []*models.Judge, comps *Comparisons, quality map[primitive.ObjectID]float64, op *models.Options, topN int) *SimulationReport {
+	report := &SimulationReport{
+		Projects:   len(projects),
+		Judges:     len(judges),
+		ViewCounts: make(map[int64]int),
+		TopN:       min(topN, len(projects)),
+	}
+	if len(projects) == 0 {
+		return report
+	}
+
+	// Views of each project
+	report.MinViews = projects[0].Seen
+	for _, p := range projects {
+		report.TotalViews += p.Seen
+		report.MinViews = min(report.MinViews, p.Seen)
+		report.MaxViews = max(report.MaxViews, p.Seen)
+		report.ViewCounts[p.Seen]++
+		if p.Seen == 0 {
+			report.Unseen++
+		}
+		if p.Seen < op.MinViews {
+			report.BelowMin++
+		}
+	}
+	report.MeanViews = float64(report.TotalViews) / float64(len(projects))
+	report.PairCoverage, report.Connected = comparisonCoverage(comps, projects)
+
+	// How well the recovered ranking of the seen projects matches the true ranking
+	scores := ComputeMethodScores(judges, op.RankingMethod)
+	var truth, recovered []float64
+	for _, p := range projects {
+		if p.Seen > 0 {
+			truth = append(truth, quality[p.Id])
+			recovered = append(recovered, scores[p.Id])
+		}
+	}
+	if rho, ok := spearman(truth, recovered); ok {
+		report.Spearman = rho
+	}
+
+	// How many of the true top projects are placed in the top, counting unseen projects as last
+	recoveredScore := func(p *models.Project) float64 {
+		if p.Seen == 0 {
+			return math.Inf(-1)
+		}
+		return scores[p.Id]
+	}
+	trueTop := topProjects(projects, report.TopN, func(p *models.Project) float64 { return quality[p.Id] })
+	for _, id := range topProjects(projects, report.TopN, recoveredScore) {
+		if slices.Contains(trueTop, id) {
+			report.TopOverlap++
+		}
+	}
+
+	return report
+}
+
+// comparisonCoverage returns the fraction of project pairs that have been compared at least once,
+// and the fraction of projects in the largest connected part of the comparison graph.
+// Projects in separate parts were never compared, even indirectly, so their order is a guess.
+func comparisonCoverage(comps *Comparisons, projects []*models.Project) (float64, float64) {
+	n := len(projects)
+	if n < 2 {
+		return 1, 1
+	}
+
+	comps.Mutex.Lock()
+	defer comps.Mutex.Unlock()
+
+	compared := 0
+	for _, row := range comps.Counts {
+		compared += len(row)
+	}
+	compared /= 2
+
+	// Find the largest connected component
+	visited := make(map[primitive.ObjectID]bool, n)
+	largest := 0
+	for _, p := range projects {
+		if visited[p.Id] {
+			continue
+		}
+		visited[p.Id] = true
+		size := 0
+		queue := []primitive.ObjectID{p.Id}
+		for len(queue) > 0 {
+			a := queue[0]
+			queue = queue[1:]
+			size++
+			for b := range comps.Counts[a] {
+				if !visited[b] {
+					visited[b] = true
+					queue = append(queue, b)
+				}
+			}
+		}
+		largest = max(largest, size)
+	}
+
+	return float64(compared) / float64(n*(n-1)/2), float64(largest) / float64(n)
+}
+
+// topProjects returns the IDs of the n projects with the highest keys
+func topProjects(projects []*models.Project, n int, key func(*models.Project) float64) []primitive.ObjectID {
+	sorted := slices.Clone(projects)
+	sort.SliceStable(sorted, func(a, b int) bool {
+		return key(sorted[a]) > key(sorted[b])
+	})
+	ids := make([]primitive.ObjectID, min(n, len(sorted)))
+	for i := range ids {
+		ids[i] = sorted[i].Id
+	}
+	return ids
+}
@@ -0,0 +1,63 @@
+package judging
+
+import (
+	"math"
+	"server/models"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestSimulationReport(t *testing.T) {
+	projects := make([]*models.Project, 4)
+	quality := make(map[primitive.ObjectID]float64)
+	for i := range projects {
+		projects[i] = &models.Project{Id: primitive.NewObjectID(), Seen: 1}
+		quality[projects[i].Id] = float64(len(projects) - i)
+	}
+	projects[3].Seen = 0
+
+	// One judge compared the first three projects and ranked them in the true order
+	judge := models.NewJudge("judge", "", "", "", 0)
+	for _, p := range projects[:3] {
+		judge.SeenProjects = append(judge.SeenProjects, models.JudgedProject{ProjectId: p.Id})
+		judge.Rankings = append(judge.Rankings, p.Id)
+	}
+	judge.RankingsAgg = AggregateRanking(judge, MethodCopeland)
+	comps := CreateComparisons([]*models.Judge{judge})
+
+	op := models.NewOptions()
+	op.MinViews = 1
+	report := simulationReport(projects, []*models.Judge{judge}, comps, quality, op, 2)
+	if report.Unseen != 1 || report.BelowMin != 1 || report.MinViews != 0 || report.MaxViews != 1 {
+		t.Errorf("unexpected view counts: %+v", report)
+	}
+	if math.Abs(report.PairCoverage-0.5) > 1e-9 || math.Abs(report.Connected-0.75) > 1e-9 {
+		t.Errorf("expected half of the pairs and 3 of 4 projects to be covered, got %.2f and %.2f", report.PairCoverage, report.Connected)
+	}
+	if math.Abs(report.Spearman-1) > 1e-9 || report.TopOverlap != 2 {
+		t.Errorf("expected the true ranking to be recovered, got spearman %.2f and top overlap %d", report.Spearman, report.TopOverlap)
+	}
+}
+
+func TestSimulate(t *testing.T) {
+	op := models.NewOptions()
+	op.MinViews = 1
+	cfg := &SimulationConfig{Projects: 20, Judges: 6, Views: 5, Noise: 0, TopN: 3, Seed: 1}
+
+	report, err := Simulate(nil, op, cfg)
+	if err != nil {
+		t.Fatalf("error running simulation: %s", err.Error())
+	}
+
+	// Every judge reaches their quota, and views are spread evenly
+	if report.TotalViews != int64(cfg.Judges*cfg.Views) {
+		t.Errorf("expected %d views, got %d", cfg.Judges*cfg.Views, report.TotalViews)
+	}
+	if report.MaxViews-report.MinViews > 1 {
+		t.Errorf("expected views to be spread evenly, got min %d and max %d", report.MinViews, report.MaxViews)
+	}
+	if report.Spearman <= 0 {
+		t.Errorf("expected judges without noise to recover the true ranking, got spearman %.2f", report.Spearman)
+	}
+}
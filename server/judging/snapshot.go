@@ -0,0 +1,300 @@
+package judging
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"server/database"
+	"server/models"
+	"server/util"
+	"slices"
+	"sort"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// TakeSnapshot records the current aggregated scores, stars, rankings, and options as a new snapshot.
+// This should be run in a transaction so the snapshot is consistent.
+func TakeSnapshot(db *mongo.Database, ctx context.Context, reason string) (*models.Snapshot, error) {
+	// Get the version number of the new snapshot
+	version, err := database.GetNextSnapshotVersion(db, ctx)
+	if err != nil {
+		return nil, err
+	}
+	snapshot := models.NewSnapshot(version, reason)
+
+	// Get the options
+	op, err := database.GetOptions(db, ctx)
+	if err != nil {
+		return nil, err
+	}
+	snapshot.Options = *op
+
+	// Get all projects and their scores
+	projects, err := database.FindAllProjects(db, ctx)
+	if err != nil {
+		return nil, err
+	}
+	scores, err := AggregateScores(db, ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range projects {
+		sp := models.SnapshotProject{
+			ProjectId:   p.Id,
+			Name:        p.Name,
+			Location:    p.Location,
+			Active:      p.Active,
+			TrackScores: map[string]float64{},
+			TrackStars:  map[string]int64{},
+		}
+		if score, ok := scores[p.Id]; ok {
+			sp.Score = score.Score
+			sp.Stars = score.Stars
+			if score.TrackScores != nil {
+				sp.TrackScores = score.TrackScores
+			}
+			if score.TrackStars != nil {
+				sp.TrackStars = score.TrackStars
+			}
+		}
+		snapshot.Projects = append(snapshot.Projects, sp)
+	}
+
+	// Calculate the place of each project (ties share the same place)
+	for i := range snapshot.Projects {
+		place := int64(1)
+		for _, other := range snapshot.Projects {
+			if other.Score > snapshot.Projects[i].Score {
+				place++
+			}
+		}
+		snapshot.Projects[i].Place = place
+	}
+	sort.SliceStable(snapshot.Projects, func(i, j int) bool {
+		return snapshot.Projects[i].Place < snapshot.Projects[j].Place
+	})
+
+	// Get all judges' rankings
+	judges, err := database.FindAllJudges(db, ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, judge := range judges {
+		snapshot.Rankings = append(snapshot.Rankings, models.SnapshotRanking{
+			JudgeId:   judge.Id,
+			JudgeName: judge.Name,
+			Track:     judge.Track,
+			Weight:    JudgeWeight(judge),
+			Tiers:     util.GetRankingTiers(judge),
+		})
+	}
+
+	// Save the snapshot
+	err = database.InsertSnapshot(db, ctx, snapshot)
+	if err != nil {
+		return nil, err
+	}
+
+	return snapshot, nil
+}
+
+// SnapshotDiff is the difference between two snapshots
+type SnapshotDiff struct {
+	From     int64                `json:"from"` // Version of the older snapshot
+	To       int64                `json:"to"`   // Version of the newer snapshot
+	Projects []*ProjectDiff       `json:"projects"`
+	Rankings []*RankingDiff       `json:"rankings"`
+	Options  []*OptionDiff        `json:"options"`
+	Summary  map[string]int64     `json:"summary"` // Number of changed projects, rankings, and options
+	Added    []primitive.ObjectID `json:"added"`   // Projects that only exist in the newer snapshot
+	Removed  []primitive.ObjectID `json:"removed"` // Projects that only exist in the older snapshot
+}
+
+// ProjectDiff is a project whose results changed between two snapshots
+type ProjectDiff struct {
+	ProjectId   primitive.ObjectID    `json:"project_id"`
+	Name        string                `json:"name"`
+	Location    int64                 `json:"location"`
+	ScoreBefore float64               `json:"score_before"`
+	ScoreAfter  float64               `json:"score_after"`
+	PlaceBefore int64                 `json:"place_before"`
+	PlaceAfter  int64                 `json:"place_after"`
+	StarsBefore int64                 `json:"stars_before"`
+	StarsAfter  int64                 `json:"stars_after"`
+	TrackScores map[string][2]float64 `json:"track_scores"` // Changed track scores as [before, after]
+	TrackStars  map[string][2]int64   `json:"track_stars"`  // Changed track stars as [before, after]
+}
+
+// RankingDiff is a judge whose ranking changed between two snapshots
+type RankingDiff struct {
+	JudgeId   primitive.ObjectID     `json:"judge_id"`
+	JudgeName string                 `json:"judge_name"`
+	Before    [][]primitive.ObjectID `json:"before"`
+	After     [][]primitive.ObjectID `json:"after"`
+}
+
+// OptionDiff is an option that changed between two snapshots
+type OptionDiff struct {
+	Name   string `json:"name"`
+	Before any    `json:"before"`
+	After  any    `json:"after"`
+}
+
+// ignoredOptionDiffs are options that change on their own and would only add noise to a diff
+var ignoredOptionDiffs = []string{"id", "clock", "manual_switches"}
+
+// DiffSnapshots compares two snapshots and returns everything that changed from one to the other
+func DiffSnapshots(from *models.Snapshot, to *models.Snapshot) *SnapshotDiff {
+	diff := &SnapshotDiff{
+		From:     from.Version,
+		To:       to.Version,
+		Projects: []*ProjectDiff{},
+		Rankings: []*RankingDiff{},
+		Options:  []*OptionDiff{},
+		Added:    []primitive.ObjectID{},
+		Removed:  []primitive.ObjectID{},
+	}
+
+	// Compare projects
+	before := make(map[primitive.ObjectID]models.SnapshotProject, len(from.Projects))
+	for _, p := range from.Projects {
+		before[p.ProjectId] = p
+	}
+	seen := make(map[primitive.ObjectID]bool, len(to.Projects))
+	for _, a := range to.Projects {
+		seen[a.ProjectId] = true
+		b, ok := before[a.ProjectId]
+		if !ok {
+			diff.Added = append(diff.Added, a.ProjectId)
+			continue
+		}
+		if pd := diffProject(b, a); pd != nil {
+			diff.Projects = append(diff.Projects, pd)
+		}
+	}
+	for _, p := range from.Projects {
+		if !seen[p.ProjectId] {
+			diff.Removed = append(diff.Removed, p.ProjectId)
+		}
+	}
+
+	// Compare rankings
+	beforeRanks := make(map[primitive.ObjectID]models.SnapshotRanking, len(from.Rankings))
+	for _, r := range from.Rankings {
+		beforeRanks[r.JudgeId] = r
+	}
+	for _, a := range to.Rankings {
+		b := beforeRanks[a.JudgeId]
+		if !tiersEqual(b.Tiers, a.Tiers) {
+			diff.Rankings = append(diff.Rankings, &RankingDiff{
+				JudgeId:   a.JudgeId,
+				JudgeName: a.JudgeName,
+				Before:    b.Tiers,
+				After:     a.Tiers,
+			})
+		}
+	}
+
+	// Compare options
+	diff.Options = diffOptions(&from.Options, &to.Options)
+
+	diff.Summary = map[string]int64{
+		"projects": int64(len(diff.Projects)),
+		"rankings": int64(len(diff.Rankings)),
+		"options":  int64(len(diff.Options)),
+		"added":    int64(len(diff.Added)),
+		"removed":  int64(len(diff.Removed)),
+	}
+	return diff
+}
+
+// diffProject returns the changes to a project, or nil if nothing changed
+func diffProject(b models.SnapshotProject, a models.SnapshotProject) *ProjectDiff {
+	pd := &ProjectDiff{
+		ProjectId:   a.ProjectId,
+		Name:        a.Name,
+		Location:    a.Location,
+		ScoreBefore: b.Score,
+		ScoreAfter:  a.Score,
+		PlaceBefore: b.Place,
+		PlaceAfter:  a.Place,
+		StarsBefore: b.Stars,
+		StarsAfter:  a.Stars,
+		TrackScores: map[string][2]float64{},
+		TrackStars:  map[string][2]int64{},
+	}
+
+	for track := range mergeKeys(b.TrackScores, a.TrackScores) {
+		if b.TrackScores[track] != a.TrackScores[track] {
+			pd.TrackScores[track] = [2]float64{b.TrackScores[track], a.TrackScores[track]}
+		}
+	}
+	for track := range mergeKeys(b.TrackStars, a.TrackStars) {
+		if b.TrackStars[track] != a.TrackStars[track] {
+			pd.TrackStars[track] = [2]int64{b.TrackStars[track], a.TrackStars[track]}
+		}
+	}
+
+	if b.Score == a.Score && b.Place == a.Place && b.Stars == a.Stars && len(pd.TrackScores) == 0 && len(pd.TrackStars) == 0 {
+		return nil
+	}
+	return pd
+}
+
+// diffOptions compares every option by its JSON name
+func diffOptions(from *models.Options, to *models.Options) []*OptionDiff {
+	out := []*OptionDiff{}
+
+	b, errB := toJSONMap(from)
+	a, errA := toJSONMap(to)
+	if errB != nil || errA != nil {
+		return out
+	}
+
+	keys := make([]string, 0, len(a))
+	for k := range mergeKeys(b, a) {
+		if !slices.Contains(ignoredOptionDiffs, k) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		if !reflect.DeepEqual(b[k], a[k]) {
+			out = append(out, &OptionDiff{Name: k, Before: b[k], After: a[k]})
+		}
+	}
+	return out
+}
+
+// toJSONMap converts a struct to a map using its JSON field names
+func toJSONMap(v any) (map[string]any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]any)
+	err = json.Unmarshal(data, &out)
+	return out, err
+}
+
+// mergeKeys returns the set of keys in either map
+func mergeKeys[V any](a map[string]V, b map[string]V) map[string]bool {
+	out := make(map[string]bool, len(a)+len(b))
+	for k := range a {
+		out[k] = true
+	}
+	for k := range b {
+		out[k] = true
+	}
+	return out
+}
+
+// tiersEqual returns true if two lists of ranking tiers are identical
+func tiersEqual(a [][]primitive.ObjectID, b [][]primitive.ObjectID) bool {
+	return slices.EqualFunc(a, b, func(x, y []primitive.ObjectID) bool {
+		return slices.Equal(x, y)
+	})
+}
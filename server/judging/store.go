@@ -0,0 +1,61 @@
+package judging
+
+import (
+	"context"
+	"server/database"
+	"server/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Store is the data that picking the next project reads. The server reads it from the
+// database (see DbStore), while the simulator keeps it in memory (see MemoryStore).
+type Store interface {
+	GetOptions(ctx context.Context) (*models.Options, error)
+	FindActiveProjects(ctx context.Context) ([]*models.Project, error)
+	FindBusyProjects(ctx context.Context) (map[primitive.ObjectID]string, error)
+	GetPrioritizedProjects(ctx context.Context) ([]*models.Project, error)
+	GetNumProjectsInGroup(ctx context.Context, group int64) (int64, error)
+	FindFlagsByJudge(ctx context.Context, judge *models.Judge) ([]*models.Flag, error)
+	FindJudgesByTrack(ctx context.Context, track string) ([]*models.Judge, error)
+}
+
+// DbStore reads the data for picking projects from the database.
+// Pass a session context to read it inside of a transaction.
+type DbStore struct {
+	Db *mongo.Database
+}
+
+// NewDbStore creates a store that reads from the given database
+func NewDbStore(db *mongo.Database) *DbStore {
+	return &DbStore{Db: db}
+}
+
+func (s *DbStore) GetOptions(ctx context.Context) (*models.Options, error) {
+	return database.GetOptions(s.Db, ctx)
+}
+
+func (s *DbStore) FindActiveProjects(ctx context.Context) ([]*models.Project, error) {
+	return database.FindActiveProjects(s.Db, ctx)
+}
+
+func (s *DbStore) FindBusyProjects(ctx context.Context) (map[primitive.ObjectID]string, error) {
+	return database.FindBusyProjects(s.Db, ctx)
+}
+
+func (s *DbStore) GetPrioritizedProjects(ctx context.Context) ([]*models.Project, error) {
+	return database.GetPrioritizedProjects(s.Db, ctx)
+}
+
+func (s *DbStore) GetNumProjectsInGroup(ctx context.Context, group int64) (int64, error) {
+	return database.GetNumProjectsInGroup(s.Db, ctx, group)
+}
+
+func (s *DbStore) FindFlagsByJudge(ctx context.Context, judge *models.Judge) ([]*models.Flag, error) {
+	return database.FindFlagsByJudge(s.Db, ctx, judge)
+}
+
+func (s *DbStore) FindJudgesByTrack(ctx context.Context, track string) ([]*models.Judge, error) {
+	return database.FindJudgesByTrack(s.Db, ctx, track)
+}
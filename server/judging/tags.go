@@ -0,0 +1,42 @@
+package judging
+
+import (
+	"math/rand"
+	"server/models"
+	"slices"
+)
+
+// MatchesTags returns true if the project has any of the judge's expertise tags
+func MatchesTags(judge *models.Judge, project *models.Project) bool {
+	tags := models.NormalizeTags(judge.Tags)
+	if len(tags) == 0 {
+		return false
+	}
+	for _, tag := range project.ExpertiseTags() {
+		if slices.Contains(tags, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// PreferTagMatches narrows the items down to the projects matching the judge's expertise tags
+// with a chance of the tag weight. Otherwise, or if the judge has no tags or no items match,
+// the items are returned as is. Keeping some chance of unmatched projects means that
+// projects outside of any judge's expertise still get seen.
+func PreferTagMatches(judge *models.Judge, items []*models.Project, op *models.Options) []*models.Project {
+	if len(judge.Tags) == 0 || op.TagWeight <= 0 || rand.Float64() >= op.TagWeight {
+		return items
+	}
+
+	var matched []*models.Project
+	for _, proj := range items {
+		if MatchesTags(judge, proj) {
+			matched = append(matched, proj)
+		}
+	}
+	if len(matched) == 0 {
+		return items
+	}
+	return matched
+}
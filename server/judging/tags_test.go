@@ -0,0 +1,39 @@
+package judging
+
+import (
+	"server/models"
+	"slices"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestPreferTagMatches(t *testing.T) {
+	hardware := &models.Project{Id: primitive.NewObjectID(), ChallengeList: []string{"Hardware"}}
+	tagged := &models.Project{Id: primitive.NewObjectID(), Tags: []string{"ml"}}
+	webApp := &models.Project{Id: primitive.NewObjectID(), ChallengeList: []string{"Best Web App"}}
+	items := []*models.Project{hardware, tagged, webApp}
+
+	judge := models.NewJudge("judge", "", "", "", 0)
+	judge.Tags = []string{" HARDWARE", "ml"}
+	if !MatchesTags(judge, hardware) || !MatchesTags(judge, tagged) || MatchesTags(judge, webApp) {
+		t.Fatalf("expected tags to match challenges and project tags, ignoring case and spaces")
+	}
+
+	op := models.NewOptions()
+	op.TagWeight = 1
+	if got := PreferTagMatches(judge, items, op); len(got) != 2 || slices.Contains(got, webApp) {
+		t.Errorf("expected only the matching projects with a tag weight of 1, got %d projects", len(got))
+	}
+	op.TagWeight = 0
+	if got := PreferTagMatches(judge, items, op); len(got) != 3 {
+		t.Errorf("expected every project with a tag weight of 0, got %d projects", len(got))
+	}
+
+	// Judges without matches still get projects
+	op.TagWeight = 1
+	judge.Tags = []string{"design"}
+	if got := PreferTagMatches(judge, items, op); len(got) != 3 {
+		t.Errorf("expected every project when none match, got %d projects", len(got))
+	}
+}
@@ -0,0 +1,135 @@
+package judging
+
+import (
+	"context"
+	"math"
+	"server/models"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// defaultAdaptiveTopN is used for databases created before the adaptive top N option existed
+const defaultAdaptiveTopN = 10
+
+// strengthsMaxAge is how long a Bradley-Terry fit is reused before it is refit, so that
+// rankings submitted to other instances of the server are picked up without an invalidation
+const strengthsMaxAge = 30 * time.Second
+
+// Strengths caches the Bradley-Terry fit over the general judges' rankings used to pick projects
+// in adaptive mode, so the model isn't refit on every pick. Invalidate it whenever rankings change.
+type Strengths struct {
+	scores     []*BTScore
+	fitted     time.Time
+	generation int64 // Incremented on every invalidation, so a fit started before one isn't kept
+	mutex      sync.Mutex
+}
+
+// NewStrengths creates an empty strengths cache
+func NewStrengths() *Strengths {
+	return &Strengths{}
+}
+
+// Invalidate clears the cached fit, so the next pick refits the model
+func (s *Strengths) Invalidate() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.scores = nil
+	s.generation++
+}
+
+// Get returns the cached fit, refitting it if it has been invalidated or is older than strengthsMaxAge.
+// The model is fit without holding the lock, so picks by other judges aren't blocked by the store.
+func (s *Strengths) Get(store Store, ctx context.Context) ([]*BTScore, error) {
+	s.mutex.Lock()
+	if s.scores != nil && time.Since(s.fitted) < strengthsMaxAge {
+		scores := s.scores
+		s.mutex.Unlock()
+		return scores, nil
+	}
+	generation := s.generation
+	s.mutex.Unlock()
+
+	// Fit the model over all general judges' rankings
+	judges, err := store.FindJudgesByTrack(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	scores := ComputeBradleyTerry(judges)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.generation == generation {
+		s.scores = scores
+		s.fitted = time.Now()
+	}
+	return scores, nil
+}
+
+// RankUncertainty calculates how uncertain it is whether each project belongs in the top N.
+// The cutoff is halfway between the Bradley-Terry strengths of the Nth and (N+1)th projects,
+// and the uncertainty is the probability (under the normal approximation of each project's
+// confidence interval) that the project is actually on the other side of the cutoff.
+// Values range from 0 (clearly in or out) to 0.5 (right on the cutoff).
+// The scores must be sorted by strength, as returned by ComputeBradleyTerry.
+func RankUncertainty(scores []*BTScore, topN int) map[primitive.ObjectID]float64 {
+	out := make(map[primitive.ObjectID]float64, len(scores))
+
+	// If every project is in the top N, there is nothing to settle
+	if topN <= 0 || topN >= len(scores) {
+		for _, s := range scores {
+			out[s.ProjectId] = 0
+		}
+		return out
+	}
+
+	cutoff := (scores[topN-1].Strength + scores[topN].Strength) / 2
+	for _, s := range scores {
+		stdErr := (s.Upper - s.Lower) / (2 * btZ)
+		if stdErr <= 0 {
+			out[s.ProjectId] = 0
+			continue
+		}
+		z := math.Abs(s.Strength-cutoff) / stdErr
+		out[s.ProjectId] = 0.5 * math.Erfc(z/math.Sqrt2)
+	}
+	return out
+}
+
+// PickMostUncertain picks the project whose place relative to the top N is least certain.
+// Projects that have never been compared are the most uncertain of all. Ties are broken
+// by picking the project that has been compared the least to the judge's seen projects.
+// The strengths are read from the cached fit kept with the comparisons (see Strengths).
+// Items param MUST not be empty.
+func PickMostUncertain(store Store, ctx context.Context, items []*models.Project, judge *models.Judge, comps *Comparisons, topN int64) (*models.Project, error) {
+	scores, err := comps.Strengths.Get(store, ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if topN <= 0 {
+		topN = defaultAdaptiveTopN
+	}
+	uncertainty := RankUncertainty(scores, int(topN))
+
+	// Find all items with the highest uncertainty
+	var candidates []*models.Project
+	best := -1.0
+	for _, item := range items {
+		u, ok := uncertainty[item.Id]
+		if !ok {
+			u = 1
+		}
+
+		if u > best+1e-9 {
+			best = u
+			candidates = []*models.Project{item}
+		} else if math.Abs(u-best) <= 1e-9 {
+			candidates = append(candidates, item)
+		}
+	}
+
+	return comps.FindLeastCompared(candidates, judge.SeenProjects), nil
+}
@@ -0,0 +1,22 @@
+package judging
+
+import (
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestRankUncertainty(t *testing.T) {
+	a, b, c := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	scores := []*BTScore{
+		{ProjectId: a, Strength: 3, Lower: 2.5, Upper: 3.5},
+		{ProjectId: b, Strength: 0.1, Lower: -1, Upper: 1.2},
+		{ProjectId: c, Strength: -0.1, Lower: -1.2, Upper: 1},
+	}
+
+	// The race for 2nd place between b and c is far less certain than a's place
+	u := RankUncertainty(scores, 2)
+	if u[a] >= u[b] || u[a] >= u[c] {
+		t.Errorf("expected a to be the most certain, got %v", u)
+	}
+}
@@ -18,6 +18,7 @@ type Logger struct {
 	Mutex  sync.Mutex
 	Memory []string
 	DbRef  *mongo.Database
+	Shared bool // If true, other instances of the server also log to the DB, so the log is read from there
 }
 
 type LogType int
@@ -175,5 +176,17 @@ func (l *Logger) Get() string {
 	l.Mutex.Lock()
 	defer l.Mutex.Unlock()
 
+	// Include the logs of the other instances, falling back to this instance's logs if the DB can't be read
+	if l.Shared {
+		dbLogs, err := GetAllDbLogs(l.DbRef)
+		if err == nil {
+			entries := []string{}
+			for _, log := range dbLogs {
+				entries = append(entries, log.Entries...)
+			}
+			return strings.Join(entries, "\n")
+		}
+	}
+
 	return strings.Join(l.Memory, "\n")
 }
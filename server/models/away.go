@@ -0,0 +1,95 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Maximum number of away windows a project can have and length of a window's reason
+const (
+	maxAwayWindows    = 20
+	maxAwayReasonSize = 200
+)
+
+// AwayWindow is a period of time when a team is away from their table, e.g. at a workshop,
+// a meal, or their own prize presentation. The project isn't assigned to judges during the window,
+// and judges marking it absent during the window doesn't count towards hiding it.
+// The source is one of:
+//
+//  1. admin: Set by an admin
+//  2. team: Set by the team with their team code
+type AwayWindow struct {
+	Start  primitive.DateTime `bson:"start" json:"start"`
+	End    primitive.DateTime `bson:"end" json:"end"`
+	Reason string             `bson:"reason" json:"reason"`
+	Source string             `bson:"source" json:"source"`
+}
+
+// Contains returns true if the time falls within the window (including the start but not the end)
+func (w *AwayWindow) Contains(t primitive.DateTime) bool {
+	return t >= w.Start && t < w.End
+}
+
+// ValidateAwayWindows makes sure every window ends after it starts, and that there aren't too many windows
+func ValidateAwayWindows(windows []AwayWindow) error {
+	if len(windows) > maxAwayWindows {
+		return fmt.Errorf("a project can have at most %d away windows", maxAwayWindows)
+	}
+	for _, w := range windows {
+		if w.Start <= 0 || w.End <= 0 {
+			return errors.New("away windows must have a start and end time")
+		}
+		if w.End <= w.Start {
+			return errors.New("away windows must end after they start")
+		}
+		if len(w.Reason) > maxAwayReasonSize {
+			return fmt.Errorf("away window reasons can be at most %d characters", maxAwayReasonSize)
+		}
+	}
+	return nil
+}
+
+// AvailableAt returns false if the team is away from their table at the given time
+func (p *Project) AvailableAt(t primitive.DateTime) bool {
+	for _, w := range p.Away {
+		if w.Contains(t) {
+			return false
+		}
+	}
+	return true
+}
+
+// Create custom marshal function to change the format of the primitive.DateTime to a unix timestamp
+func (w AwayWindow) MarshalJSON() ([]byte, error) {
+	type Alias AwayWindow
+	return json.Marshal(&struct {
+		Alias
+		Start int64 `json:"start"`
+		End   int64 `json:"end"`
+	}{
+		Alias: Alias(w),
+		Start: int64(w.Start),
+		End:   int64(w.End),
+	})
+}
+
+// Create custom unmarshal function to change the format of the primitive.DateTime from a unix timestamp
+func (w *AwayWindow) UnmarshalJSON(data []byte) error {
+	type Alias AwayWindow
+	aux := &struct {
+		Start int64 `json:"start"`
+		End   int64 `json:"end"`
+		*Alias
+	}{
+		Alias: (*Alias)(w),
+	}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	w.Start = primitive.DateTime(aux.Start)
+	w.End = primitive.DateTime(aux.End)
+	return nil
+}
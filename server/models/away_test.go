@@ -0,0 +1,38 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestAwayWindows(t *testing.T) {
+	now := time.Now()
+	at := func(d time.Duration) primitive.DateTime { return primitive.NewDateTimeFromTime(now.Add(d)) }
+
+	project := &Project{Away: []AwayWindow{
+		{Start: at(-time.Hour), End: at(-30 * time.Minute), Reason: "workshop", Source: "team"},
+		{Start: at(time.Hour), End: at(2 * time.Hour), Reason: "presentation", Source: "admin"},
+	}}
+	if err := ValidateAwayWindows(project.Away); err != nil {
+		t.Fatalf("expected valid away windows, got %s", err)
+	}
+	if !project.AvailableAt(at(0)) {
+		t.Errorf("expected the project to be available between windows")
+	}
+	if project.AvailableAt(at(-45*time.Minute)) || project.AvailableAt(at(time.Hour)) {
+		t.Errorf("expected the project to be unavailable during its windows")
+	}
+	if !project.AvailableAt(at(2 * time.Hour)) {
+		t.Errorf("expected the project to be available once a window ends")
+	}
+
+	backwards := []AwayWindow{{Start: at(time.Hour), End: at(0)}}
+	if ValidateAwayWindows(backwards) == nil {
+		t.Errorf("expected a window that ends before it starts to be invalid")
+	}
+	if ValidateAwayWindows([]AwayWindow{{End: at(0)}}) == nil {
+		t.Errorf("expected a window without a start to be invalid")
+	}
+}
@@ -9,6 +9,7 @@ type ClockState struct {
 	StartTime int64 `json:"start_time" bson:"start_time"`
 	PauseTime int64 `json:"pause_time" bson:"pause_time"`
 	Running   bool  `json:"running" bson:"running"`
+	Version   int64 `json:"-" bson:"version"` // Incremented on every change when running multiple instances
 }
 
 func NewClockState() *ClockState {
@@ -59,6 +60,13 @@ type SafeClock struct {
 	State ClockState
 }
 
+// GetDuration returns the current clock time in milliseconds, which doesn't advance while the clock is paused
+func (c *SafeClock) GetDuration() int64 {
+	c.Mutex.Lock()
+	defer c.Mutex.Unlock()
+	return c.State.GetDuration()
+}
+
 func NewSafeClock(clock *ClockState) *SafeClock {
 	return &SafeClock{
 		Mutex: sync.Mutex{},
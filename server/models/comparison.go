@@ -0,0 +1,40 @@
+package models
+
+import (
+	"bytes"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Comparison is the number of times a pair of projects has been seen by the same judge.
+// Only pairs that have been compared are stored. A is always the lower ID of the pair,
+// and the ID is made from both so each pair has exactly one document (see ComparisonKey).
+// Pairs that are no longer compared are kept with a count of 0, so that other instances
+// of the server see the change when they fetch the pairs updated since their last sync.
+type Comparison struct {
+	Key     string             `bson:"_id" json:"id"`
+	A       primitive.ObjectID `bson:"a" json:"a"`
+	B       primitive.ObjectID `bson:"b" json:"b"`
+	Count   int64              `bson:"count" json:"count"`
+	Updated primitive.DateTime `bson:"updated" json:"updated"`
+}
+
+func NewComparison(a primitive.ObjectID, b primitive.ObjectID, count int64) *Comparison {
+	if bytes.Compare(a[:], b[:]) > 0 {
+		a, b = b, a
+	}
+	return &Comparison{
+		Key:   ComparisonKey(a, b),
+		A:     a,
+		B:     b,
+		Count: count,
+	}
+}
+
+// ComparisonKey is the ID of the comparison between two projects, which doesn't depend on their order
+func ComparisonKey(a primitive.ObjectID, b primitive.ObjectID) string {
+	if bytes.Compare(a[:], b[:]) > 0 {
+		a, b = b, a
+	}
+	return a.Hex() + "-" + b.Hex()
+}
@@ -0,0 +1,20 @@
+package models
+
+import (
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestNewComparison(t *testing.T) {
+	a, b := primitive.NewObjectID(), primitive.NewObjectID()
+
+	// Each pair has the same document no matter the order of the projects
+	ab, ba := NewComparison(a, b, 1), NewComparison(b, a, 1)
+	if ab.Key != ba.Key || ab.A != ba.A || ab.B != ba.B {
+		t.Errorf("expected both orders to make the same comparison, got %+v and %+v", ab, ba)
+	}
+	if ab.A != a || ab.Key != a.Hex()+"-"+b.Hex() {
+		t.Errorf("expected the lower ID to come first, got %+v", ab)
+	}
+}
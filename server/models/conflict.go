@@ -0,0 +1,53 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Conflict is a conflict of interest between a judge and a project.
+// A conflicted project is never assigned to the judge, and anything the judge
+// ranked, starred, or scored for the project is thrown out of the results.
+// The source is one of:
+//
+//  1. manual: Entered by an admin
+//  2. email-domain: The judge's email domain matches a team member's email domain
+//  3. university: One of the judge's affiliations matches one of the team's universities
+type Conflict struct {
+	Id              primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	JudgeId         primitive.ObjectID `json:"judge_id" bson:"judge_id"`
+	ProjectId       primitive.ObjectID `json:"project_id" bson:"project_id"`
+	Time            primitive.DateTime `json:"time" bson:"time"`
+	JudgeName       string             `json:"judge_name" bson:"judge_name"`
+	ProjectName     string             `json:"project_name" bson:"project_name"`
+	ProjectLocation int64              `json:"project_location" bson:"project_location"`
+	Source          string             `json:"source" bson:"source"`
+	Reason          string             `json:"reason" bson:"reason"`
+}
+
+func NewConflict(judge *Judge, project *Project, source string, reason string) *Conflict {
+	return &Conflict{
+		JudgeId:         judge.Id,
+		ProjectId:       project.Id,
+		Time:            primitive.NewDateTimeFromTime(time.Now()),
+		JudgeName:       judge.Name,
+		ProjectName:     project.Name,
+		ProjectLocation: project.Location,
+		Source:          source,
+		Reason:          reason,
+	}
+}
+
+// Create custom marshal function to change the format of the primitive.DateTime to a unix timestamp
+func (c *Conflict) MarshalJSON() ([]byte, error) {
+	type Alias Conflict
+	return json.Marshal(&struct {
+		*Alias
+		Time int64 `json:"time"`
+	}{
+		Alias: (*Alias)(c),
+		Time:  int64(c.Time),
+	})
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Criterion is a single rubric criterion that judges score each project on (e.g. Innovation 1-5)
+type Criterion struct {
+	Name   string  `bson:"name" json:"name"`
+	Min    int64   `bson:"min" json:"min"`       // Lowest allowed score
+	Max    int64   `bson:"max" json:"max"`       // Highest allowed score
+	Weight float64 `bson:"weight" json:"weight"` // Multiplier for the criterion in the weighted total
+}
+
+// ValidateCriteria makes sure every criterion has a unique name, a valid range, and a positive weight
+func ValidateCriteria(criteria []Criterion) error {
+	names := make(map[string]bool, len(criteria))
+	for _, c := range criteria {
+		if c.Name == "" {
+			return errors.New("criterion name cannot be empty")
+		}
+		if names[c.Name] {
+			return fmt.Errorf("duplicate criterion %s", c.Name)
+		}
+		names[c.Name] = true
+
+		if c.Min >= c.Max {
+			return fmt.Errorf("criterion %s must have a min lower than its max", c.Name)
+		}
+		if c.Weight <= 0 {
+			return fmt.Errorf("criterion %s must have a positive weight", c.Name)
+		}
+	}
+	return nil
+}
+
+// ValidateCriteriaScores makes sure every score is for an existing criterion and within its range.
+// Criteria that are left out are treated as not scored.
+func ValidateCriteriaScores(criteria []Criterion, scores map[string]int64) error {
+	for name, score := range scores {
+		idx := -1
+		for i, c := range criteria {
+			if c.Name == name {
+				idx = i
+				break
+			}
+		}
+		if idx == -1 {
+			return fmt.Errorf("unknown criterion %s", name)
+		}
+		if score < criteria[idx].Min || score > criteria[idx].Max {
+			return fmt.Errorf("score for %s must be between %d and %d", name, criteria[idx].Min, criteria[idx].Max)
+		}
+	}
+	return nil
+}
@@ -3,13 +3,17 @@ package models
 import (
 	"encoding/json"
 	"fmt"
+	"slices"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 // List of valid reasons for skipping a project
-var validReasons = []string{"busy", "absent", "cannot-demo", "too-complex", "offensive", "hidden-absent"}
+var validReasons = []string{"busy", "absent", "cannot-demo", "too-complex", "offensive", "hidden-absent", "back"}
+
+// Reasons that make up the absence history of a project
+var AbsenceReasons = []string{"absent", "hidden-absent", "back"}
 
 // Defines an instance where the judge skips a project.
 // This can be one of these reasons:
@@ -19,9 +23,10 @@ var validReasons = []string{"busy", "absent", "cannot-demo", "too-complex", "off
 //  3. cannot-demo: Cannot Demo Project
 //  4. too-complex: Too Complex
 //  5. offensive: Offensive Project
-//  6. hidden-absent: Hidden due to being absent 3 times
+//  6. hidden-absent: Hidden due to being absent too many times (the absent limit option)
+//  7. back: The team or an admin marked the project as back after being absent
 //
-// With the exception of the 1st reason, all other reasons are grounds for
+// With the exception of the 1st and last reasons, all other reasons are grounds for
 // flagging, which is defined by the `flag` field.
 type Flag struct {
 	Id              primitive.ObjectID  `json:"id" bson:"_id,omitempty"`
@@ -32,6 +37,7 @@ type Flag struct {
 	ProjectLocation int64               `json:"project_location" bson:"project_location"`
 	JudgeName       string              `json:"judge_name" bson:"judge_name"`
 	Reason          string              `json:"reason" bson:"reason"`
+	Round           int64               `json:"round,omitempty" bson:"round,omitempty"` // Round the flag was archived from, only set on past flags
 }
 
 func NewFlag(project *Project, judge *Judge, reason string) (*Flag, error) {
@@ -59,6 +65,11 @@ func NewFlag(project *Project, judge *Judge, reason string) (*Flag, error) {
 	}, nil
 }
 
+// IsAbsence returns true if the flag is part of the absence history of a project
+func (s *Flag) IsAbsence() bool {
+	return slices.Contains(AbsenceReasons, s.Reason)
+}
+
 // Create custom marshal function to change the format of the primitive.DateTime to a unix timestamp
 func (s *Flag) MarshalJSON() ([]byte, error) {
 	type Alias Flag
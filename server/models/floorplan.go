@@ -0,0 +1,79 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// FloorPlan is the layout of the venue, used to send judges to nearby tables.
+// Tables that aren't in the floor plan have no known position.
+type FloorPlan struct {
+	Tables       []TablePosition `bson:"tables" json:"tables"`
+	RoomPenalty  float64         `bson:"room_penalty" json:"room_penalty"`   // Extra distance for walking between two rooms on the same floor
+	FloorPenalty float64         `bson:"floor_penalty" json:"floor_penalty"` // Extra distance for every floor between two tables
+}
+
+// TablePosition is where a table is in the venue. X and Y can be in any unit, as long as every table uses the same one.
+type TablePosition struct {
+	Table int64   `bson:"table" json:"table"` // Table number (see Project.Location)
+	X     float64 `bson:"x" json:"x"`
+	Y     float64 `bson:"y" json:"y"`
+	Room  string  `bson:"room" json:"room"`
+	Floor int64   `bson:"floor" json:"floor"`
+}
+
+func NewFloorPlan() *FloorPlan {
+	return &FloorPlan{
+		Tables:       []TablePosition{},
+		RoomPenalty:  50,
+		FloorPenalty: 200,
+	}
+}
+
+// ValidateFloorPlan makes sure every table is only placed once and the penalties aren't negative
+func ValidateFloorPlan(plan FloorPlan) error {
+	if plan.RoomPenalty < 0 || plan.FloorPenalty < 0 {
+		return errors.New("penalties cannot be negative")
+	}
+	tables := make(map[int64]bool, len(plan.Tables))
+	for _, t := range plan.Tables {
+		if tables[t.Table] {
+			return fmt.Errorf("duplicate table %d", t.Table)
+		}
+		tables[t.Table] = true
+	}
+	return nil
+}
+
+// Position returns the position of a table, or nil if the table isn't in the floor plan
+func (f *FloorPlan) Position(table int64) *TablePosition {
+	for i := range f.Tables {
+		if f.Tables[i].Table == table {
+			return &f.Tables[i]
+		}
+	}
+	return nil
+}
+
+// Distance returns the walking distance between two tables: the straight-line distance,
+// plus the room penalty if they're in different rooms on the same floor, plus the floor
+// penalty for every floor between them. Returns false if either table isn't in the floor plan.
+func (f *FloorPlan) Distance(a int64, b int64) (float64, bool) {
+	pa, pb := f.Position(a), f.Position(b)
+	if pa == nil || pb == nil {
+		return 0, false
+	}
+
+	dist := math.Hypot(pa.X-pb.X, pa.Y-pb.Y)
+	if pa.Floor != pb.Floor {
+		floors := pa.Floor - pb.Floor
+		if floors < 0 {
+			floors = -floors
+		}
+		dist += float64(floors) * f.FloorPenalty
+	} else if pa.Room != pb.Room {
+		dist += f.RoomPenalty
+	}
+	return dist, true
+}
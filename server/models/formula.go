@@ -0,0 +1,39 @@
+package models
+
+import "errors"
+
+// ScoreFormula is how the final combined score of each project is calculated:
+//
+//	combined = rank_weight * normalized rank score + star_weight * stars per view + rubric_weight * normalized rubric total
+//
+// The rank score is normalized from 0 (lowest active project) to 1 (highest active project),
+// and the rubric total from 0 (every criterion at its min) to 1 (every criterion at its max).
+type ScoreFormula struct {
+	RankWeight   float64 `bson:"rank_weight" json:"rank_weight"`
+	StarWeight   float64 `bson:"star_weight" json:"star_weight"`
+	RubricWeight float64 `bson:"rubric_weight" json:"rubric_weight"`
+}
+
+// DefaultScoreFormula ranks projects only by their ranking scores
+func DefaultScoreFormula() ScoreFormula {
+	return ScoreFormula{RankWeight: 1, StarWeight: 0, RubricWeight: 0}
+}
+
+// ScoreFormulaOrDefault returns the default formula for options from before the formula existed (all weights 0)
+func ScoreFormulaOrDefault(formula ScoreFormula) ScoreFormula {
+	if formula.RankWeight+formula.StarWeight+formula.RubricWeight == 0 {
+		return DefaultScoreFormula()
+	}
+	return formula
+}
+
+// ValidateScoreFormula makes sure no weight is negative and at least one is positive
+func ValidateScoreFormula(formula ScoreFormula) error {
+	if formula.RankWeight < 0 || formula.StarWeight < 0 || formula.RubricWeight < 0 {
+		return errors.New("weights cannot be negative")
+	}
+	if formula.RankWeight+formula.StarWeight+formula.RubricWeight == 0 {
+		return errors.New("at least one weight must be positive")
+	}
+	return nil
+}
@@ -9,25 +9,49 @@ import (
 )
 
 type Judge struct {
-	Id           primitive.ObjectID   `bson:"_id,omitempty" json:"id"`
-	Token        string               `bson:"token" json:"token"`
-	Code         string               `bson:"code" json:"code"`
-	Name         string               `bson:"name" json:"name"`
-	Email        string               `bson:"email" json:"email"`
-	Active       bool                 `bson:"active" json:"active"`
-	Track        string               `bson:"track" json:"track"`
-	Group        int64                `bson:"group" json:"group"`
-	ReadWelcome  bool                 `bson:"read_welcome" json:"read_welcome"`
-	Notes        string               `bson:"notes" json:"notes"`
-	Current      *primitive.ObjectID  `bson:"current" json:"current"`
-	LastLocation int64                `bson:"last_location" json:"last_location"`
-	Seen         int64                `bson:"seen" json:"seen"`
-	GroupSeen    int64                `bson:"group_seen" json:"group_seen"` // Projects seen in the group
-	SeenProjects []JudgedProject      `bson:"seen_projects" json:"seen_projects"`
-	Rankings     []primitive.ObjectID `bson:"rankings" json:"rankings"`
-	RankingsAgg  []AggRanking         `bson:"rankings_agg" json:"rankings_agg"` // Aggregation for ranking scoring
-	Flagged      []primitive.ObjectID `bson:"flagged" json:"flagged"`           // Projects that the judge has flagged (not ranked)
-	LastActivity primitive.DateTime   `bson:"last_activity" json:"last_activity"`
+	Id           primitive.ObjectID     `bson:"_id,omitempty" json:"id"`
+	Token        string                 `bson:"token" json:"token"`
+	Code         string                 `bson:"code" json:"code"`
+	Name         string                 `bson:"name" json:"name"`
+	Email        string                 `bson:"email" json:"email"`
+	Active       bool                   `bson:"active" json:"active"`
+	Track        string                 `bson:"track" json:"track"`
+	Group        int64                  `bson:"group" json:"group"`
+	ReadWelcome  bool                   `bson:"read_welcome" json:"read_welcome"`
+	Notes        string                 `bson:"notes" json:"notes"`
+	Current      *primitive.ObjectID    `bson:"current" json:"current"`
+	LeaseExpiry  primitive.DateTime     `bson:"lease_expiry" json:"lease_expiry"` // When the current project is released if the judge hasn't finished it (see judging.LeaseExpired)
+	LastLocation int64                  `bson:"last_location" json:"last_location"`
+	Seen         int64                  `bson:"seen" json:"seen"`
+	GroupSeen    int64                  `bson:"group_seen" json:"group_seen"` // Projects seen in the group
+	SeenProjects []JudgedProject        `bson:"seen_projects" json:"seen_projects"`
+	Rankings     []primitive.ObjectID   `bson:"rankings" json:"rankings"`
+	RankingTiers [][]primitive.ObjectID `bson:"ranking_tiers" json:"ranking_tiers"` // Rankings grouped into tiers of tied projects
+	RankingsAgg  []AggRanking           `bson:"rankings_agg" json:"rankings_agg"`   // Aggregation for ranking scoring
+	Flagged      []primitive.ObjectID   `bson:"flagged" json:"flagged"`             // Projects that the judge has flagged (not ranked)
+	Weight       float64                `bson:"weight" json:"weight"`               // Multiplier applied to the judge's ranking scores
+	WeightManual bool                   `bson:"weight_manual" json:"weight_manual"` // If true, the weight was set by an admin and won't be recalibrated
+	Calibration  JudgeCalibration       `bson:"calibration" json:"calibration"`     // How the automatic weight was calculated
+	Affiliations []string               `bson:"affiliations" json:"affiliations"`   // Universities or companies, used to find conflicts of interest
+	Tags         []string               `bson:"tags" json:"tags"`                   // Areas of expertise (e.g. hardware, ML, design), matched against project tags
+	Conflicts    []primitive.ObjectID   `bson:"conflicts" json:"conflicts"`         // Projects the judge has a conflict of interest with (see Conflict)
+	Round        int64                  `bson:"round" json:"round"`                 // Round the judge's pool judges in (0 = whichever round is current)
+	PastRounds   []JudgeRound           `bson:"past_rounds" json:"past_rounds"`     // Judging data from rounds that have been closed
+	Schedule     []ScheduleStop         `bson:"schedule" json:"schedule"`           // Precomputed itinerary, followed in schedule mode (see POST /admin/schedule)
+	Quota        int64                  `bson:"quota" json:"quota"`                 // Maximum number of projects to judge in the round (0 = no limit)
+	TimeBudget   int64                  `bson:"time_budget" json:"time_budget"`     // Minutes the judge can spend judging in the round (0 = no limit)
+	Started      primitive.DateTime     `bson:"started" json:"started"`             // When the judge was given their first project in the round
+	StartedClock int64                  `bson:"started_clock" json:"started_clock"` // Judging clock time in ms when the judge was given their first project, used for the time budget
+	Progress     QuotaProgress          `bson:"-" json:"progress"`                  // Progress toward the quota, calculated when the judge is sent
+	LastActivity primitive.DateTime     `bson:"last_activity" json:"last_activity"`
+}
+
+// JudgeCalibration describes how well a judge's rankings agree with the rest of the judges
+type JudgeCalibration struct {
+	Agreement  float64 `bson:"agreement" json:"agreement"`   // Kendall tau against the consensus, from -1 to 1
+	Concordant int64   `bson:"concordant" json:"concordant"` // Pairwise preferences that agree with the consensus
+	Discordant int64   `bson:"discordant" json:"discordant"` // Pairwise preferences that disagree with the consensus
+	Reason     string  `bson:"reason" json:"reason"`         // Human-readable explanation of the weight
 }
 
 type JudgedProject struct {
@@ -37,11 +61,12 @@ type JudgedProject struct {
 	Name        string             `bson:"name" json:"name"`
 	Location    int64              `bson:"location" json:"location"`
 	Description string             `bson:"description" json:"description"`
+	Scores      map[string]int64   `bson:"scores" json:"scores"` // Rubric score for each criterion the judge scored
 }
 
 type AggRanking struct {
 	ProjectId primitive.ObjectID `bson:"project_id,omitempty" json:"project_id"`
-	Score     int64              `bson:"score" json:"score"`
+	Score     float64            `bson:"score" json:"score"`
 }
 
 func NewJudge(name string, email string, track string, notes string, group int64) *Judge {
@@ -56,13 +81,28 @@ func NewJudge(name string, email string, track string, notes string, group int64
 		ReadWelcome:  false,
 		Notes:        notes,
 		Current:      nil,
+		LeaseExpiry:  primitive.DateTime(0),
 		LastLocation: -1,
 		Seen:         0,
 		GroupSeen:    0,
 		SeenProjects: []JudgedProject{},
 		Rankings:     []primitive.ObjectID{},
+		RankingTiers: [][]primitive.ObjectID{},
 		RankingsAgg:  []AggRanking{},
 		Flagged:      []primitive.ObjectID{},
+		Affiliations: []string{},
+		Tags:         []string{},
+		Conflicts:    []primitive.ObjectID{},
+		Round:        0,
+		PastRounds:   []JudgeRound{},
+		Schedule:     []ScheduleStop{},
+		Quota:        0,
+		TimeBudget:   0,
+		Started:      primitive.DateTime(0),
+		StartedClock: 0,
+		Weight:       1,
+		WeightManual: false,
+		Calibration:  JudgeCalibration{},
 		LastActivity: primitive.DateTime(0),
 	}
 }
@@ -80,6 +120,7 @@ func JudgeProjectFromProject(project *Project, notes string, starred bool) *Judg
 		Description: project.Description,
 		Notes:       notes,
 		Starred:     starred,
+		Scores:      map[string]int64{},
 	}
 }
 
@@ -88,9 +129,13 @@ func (j *Judge) MarshalJSON() ([]byte, error) {
 	type Alias Judge
 	return json.Marshal(&struct {
 		*Alias
+		LeaseExpiry  int64 `json:"lease_expiry"`
+		Started      int64 `json:"started"`
 		LastActivity int64 `json:"last_activity"`
 	}{
 		Alias:        (*Alias)(j),
+		LeaseExpiry:  int64(j.LeaseExpiry),
+		Started:      int64(j.Started),
 		LastActivity: int64(j.LastActivity),
 	})
 }
@@ -99,6 +144,8 @@ func (j *Judge) MarshalJSON() ([]byte, error) {
 func (j *Judge) UnmarshalJSON(data []byte) error {
 	type Alias Judge
 	aux := &struct {
+		LeaseExpiry  int64 `json:"lease_expiry"`
+		Started      int64 `json:"started"`
 		LastActivity int64 `json:"last_activity"`
 		*Alias
 	}{
@@ -107,11 +154,13 @@ func (j *Judge) UnmarshalJSON(data []byte) error {
 	if err := json.Unmarshal(data, &aux); err != nil {
 		return err
 	}
+	j.LeaseExpiry = primitive.DateTime(aux.LeaseExpiry)
+	j.Started = primitive.DateTime(aux.Started)
 	j.LastActivity = primitive.DateTime(aux.LastActivity)
 	return nil
 }
 
-func NewAggRanking(projectId primitive.ObjectID, score int64) *AggRanking {
+func NewAggRanking(projectId primitive.ObjectID, score float64) *AggRanking {
 	return &AggRanking{
 		ProjectId: projectId,
 		Score:     score,
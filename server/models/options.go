@@ -7,6 +7,7 @@ type Options struct {
 	Ref            int64              `bson:"ref" json:"ref"`
 	Clock          ClockState         `bson:"clock" json:"clock"`
 	JudgingTimer   int64              `bson:"judging_timer" json:"judging_timer"`
+	LeaseGrace     int64              `bson:"lease_grace" json:"lease_grace"` // Seconds past the judging timer before a judge's current project is released
 	MinViews       int64              `bson:"min_views" json:"min_views"`
 	ClockSync      bool               `bson:"clock_sync" json:"clock_sync"`
 	Deliberation   bool               `bson:"deliberation" json:"deliberation"`
@@ -25,12 +26,26 @@ type Options struct {
 	IgnoreTracks   []string           `bson:"ignore_tracks" json:"ignore_tracks"`       // Ignore all projects that are added with this track
 	MaxReqPerMin   int64              `bson:"max_req_per_min" json:"max_req_per_min"`   // Maximum number of requests per minute
 	BlockReqs      bool               `bson:"block_reqs" json:"block_reqs"`             // Whether or not to block login requests
+	RankingMethod  string             `bson:"ranking_method" json:"ranking_method"`     // "copeland", "borda", "schulze", "ranked-pairs", or "kemeny"
+	AdaptiveAssign bool               `bson:"adaptive_assign" json:"adaptive_assign"`   // Send judges to the projects whose standings are least certain once min views are reached
+	AdaptiveTopN   int64              `bson:"adaptive_top_n" json:"adaptive_top_n"`     // Number of top places that adaptive assignment tries to settle
+	AssignStrategy string             `bson:"assign_strategy" json:"assign_strategy"`   // "default", "random", "round-robin", "least-compared", or "coverage-first"
+	FloorPlan      FloorPlan          `bson:"floor_plan" json:"floor_plan"`             // Positions of the tables, used to send judges to nearby tables
+	Criteria       []Criterion        `bson:"criteria" json:"criteria"`                 // Rubric criteria that judges score each project on (none disables rubric scoring)
+	Round          int64              `bson:"round" json:"round"`                       // Current judging round, starting at 1 (see POST /admin/rounds/close)
+	ScoreFormula   ScoreFormula       `bson:"score_formula" json:"score_formula"`       // How rankings, stars, and rubric scores are combined into the final score
+	PublishResults bool               `bson:"publish_results" json:"publish_results"`   // Whether the final results are public (see GET /results)
+	ScheduleMode   bool               `bson:"schedule_mode" json:"schedule_mode"`       // Judges follow their precomputed itineraries instead of being assigned projects on the fly
+	TagWeight      float64            `bson:"tag_weight" json:"tag_weight"`             // Chance (0-1) that judges with expertise tags only get projects matching their tags
+	TagQuestion    string             `bson:"tag_question" json:"tag_question"`         // Devpost custom question whose answers are added to project tags
+	AbsentLimit    int64              `bson:"absent_limit" json:"absent_limit"`         // Number of absences before a project is hidden (see judging.HideAbsentProject)
 }
 
 func NewOptions() *Options {
 	return &Options{
 		Ref:            0,
 		JudgingTimer:   300,
+		LeaseGrace:     300,
 		MinViews:       3,
 		Clock:          *NewClockState(),
 		ClockSync:      false,
@@ -50,24 +65,50 @@ func NewOptions() *Options {
 		IgnoreTracks:   []string{},
 		MaxReqPerMin:   100,
 		BlockReqs:      false,
+		RankingMethod:  "copeland",
+		AdaptiveAssign: false,
+		AdaptiveTopN:   10,
+		AssignStrategy: "default",
+		FloorPlan:      *NewFloorPlan(),
+		Criteria:       []Criterion{},
+		Round:          1,
+		ScoreFormula:   DefaultScoreFormula(),
+		PublishResults: false,
+		ScheduleMode:   false,
+		TagWeight:      0.75,
+		TagQuestion:    "",
+		AbsentLimit:    3,
 	}
 }
 
 type OptionalOptions struct {
-	JudgingTimer   *int64    `bson:"judging_timer,omitempty" json:"judging_timer,omitempty"`
-	MinViews       *int64    `bson:"min_views,omitempty" json:"min_views,omitempty"`
-	ClockSync      *bool     `bson:"clock_sync,omitempty" json:"clock_sync,omitempty"`
-	Deliberation   *bool     `bson:"deliberation" json:"deliberation"`
-	JudgeTracks    *bool     `bson:"judge_tracks,omitempty" json:"judge_tracks,omitempty"`
-	Tracks         *[]string `bson:"tracks,omitempty" json:"tracks,omitempty"`
-	TrackViews     *[]int64  `bson:"track_views,omitempty" json:"track_views,omitempty"`
-	MultiGroup     *bool     `bson:"multi_group,omitempty" json:"multi_group,omitempty"`
-	NumGroups      *int64    `bson:"num_groups,omitempty" json:"num_groups,omitempty"`
-	GroupSizes     *[]int64  `bson:"group_sizes,omitempty" json:"group_sizes,omitempty"`
-	SwitchingMode  *string   `bson:"switching_mode,omitempty" json:"switching_mode,omitempty"`
-	AutoSwitchProp *float64  `bson:"auto_switch_prop,omitempty" json:"auto_switch_prop,omitempty"`
-	GroupNames     *[]string `bson:"group_names,omitempty" json:"group_names,omitempty"`
-	IgnoreTracks   *[]string `bson:"ignore_tracks,omitempty" json:"ignore_tracks,omitempty"`
-	MaxReqPerMin   *int64    `bson:"max_req_per_min,omitempty" json:"max_req_per_min,omitempty"`
-	BlockReqs      *bool     `bson:"block_reqs,omitempty" json:"block_reqs,omitempty"`
+	JudgingTimer   *int64        `bson:"judging_timer,omitempty" json:"judging_timer,omitempty"`
+	LeaseGrace     *int64        `bson:"lease_grace,omitempty" json:"lease_grace,omitempty"`
+	MinViews       *int64        `bson:"min_views,omitempty" json:"min_views,omitempty"`
+	ClockSync      *bool         `bson:"clock_sync,omitempty" json:"clock_sync,omitempty"`
+	Deliberation   *bool         `bson:"deliberation" json:"deliberation"`
+	JudgeTracks    *bool         `bson:"judge_tracks,omitempty" json:"judge_tracks,omitempty"`
+	Tracks         *[]string     `bson:"tracks,omitempty" json:"tracks,omitempty"`
+	TrackViews     *[]int64      `bson:"track_views,omitempty" json:"track_views,omitempty"`
+	MultiGroup     *bool         `bson:"multi_group,omitempty" json:"multi_group,omitempty"`
+	NumGroups      *int64        `bson:"num_groups,omitempty" json:"num_groups,omitempty"`
+	GroupSizes     *[]int64      `bson:"group_sizes,omitempty" json:"group_sizes,omitempty"`
+	SwitchingMode  *string       `bson:"switching_mode,omitempty" json:"switching_mode,omitempty"`
+	AutoSwitchProp *float64      `bson:"auto_switch_prop,omitempty" json:"auto_switch_prop,omitempty"`
+	GroupNames     *[]string     `bson:"group_names,omitempty" json:"group_names,omitempty"`
+	IgnoreTracks   *[]string     `bson:"ignore_tracks,omitempty" json:"ignore_tracks,omitempty"`
+	MaxReqPerMin   *int64        `bson:"max_req_per_min,omitempty" json:"max_req_per_min,omitempty"`
+	BlockReqs      *bool         `bson:"block_reqs,omitempty" json:"block_reqs,omitempty"`
+	RankingMethod  *string       `bson:"ranking_method,omitempty" json:"ranking_method,omitempty"`
+	AdaptiveAssign *bool         `bson:"adaptive_assign,omitempty" json:"adaptive_assign,omitempty"`
+	AdaptiveTopN   *int64        `bson:"adaptive_top_n,omitempty" json:"adaptive_top_n,omitempty"`
+	AssignStrategy *string       `bson:"assign_strategy,omitempty" json:"assign_strategy,omitempty"`
+	FloorPlan      *FloorPlan    `bson:"floor_plan,omitempty" json:"floor_plan,omitempty"`
+	Criteria       *[]Criterion  `bson:"criteria,omitempty" json:"criteria,omitempty"`
+	ScoreFormula   *ScoreFormula `bson:"score_formula,omitempty" json:"score_formula,omitempty"`
+	PublishResults *bool         `bson:"publish_results,omitempty" json:"publish_results,omitempty"`
+	ScheduleMode   *bool         `bson:"schedule_mode,omitempty" json:"schedule_mode,omitempty"`
+	TagWeight      *float64      `bson:"tag_weight,omitempty" json:"tag_weight,omitempty"`
+	TagQuestion    *string       `bson:"tag_question,omitempty" json:"tag_question,omitempty"`
+	AbsentLimit    *int64        `bson:"absent_limit,omitempty" json:"absent_limit,omitempty"`
 }
@@ -1,52 +1,95 @@
 package models
 
 import (
+	"crypto/rand"
 	"encoding/json"
+	"math/big"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type Project struct {
-	Id            primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	Name          string             `bson:"name" json:"name"`
-	Location      int64              `bson:"location" json:"location"`
-	Description   string             `bson:"description" json:"description"`
-	Url           string             `bson:"url" json:"url"`
-	TryLink       string             `bson:"try_link" json:"try_link"`
-	VideoLink     string             `bson:"video_link" json:"video_link"`
-	ChallengeList []string           `bson:"challenge_list" json:"challenge_list"`
-	Seen          int64              `bson:"seen" json:"seen"`
-	TrackSeen     map[string]int64   `bson:"track_seen" json:"track_seen"`
-	Score         int64              `bson:"score" json:"score"`
-	Stars         int64              `bson:"stars" json:"stars"`
-	TrackStars    map[string]int64   `bson:"track_stars" json:"track_stars"`
-	Active        bool               `bson:"active" json:"active"`
-	Prioritized   bool               `bson:"prioritized" json:"prioritized"`
-	Group         int64              `bson:"group" json:"group"`
-	LastActivity  primitive.DateTime `bson:"last_activity" json:"last_activity"`
+	Id                primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Name              string             `bson:"name" json:"name"`
+	Location          int64              `bson:"location" json:"location"`
+	Description       string             `bson:"description" json:"description"`
+	Url               string             `bson:"url" json:"url"`
+	TryLink           string             `bson:"try_link" json:"try_link"`
+	VideoLink         string             `bson:"video_link" json:"video_link"`
+	ChallengeList     []string           `bson:"challenge_list" json:"challenge_list"`
+	Seen              int64              `bson:"seen" json:"seen"`
+	TrackSeen         map[string]int64   `bson:"track_seen" json:"track_seen"`
+	Score             float64            `bson:"score" json:"score"`
+	Stars             int64              `bson:"stars" json:"stars"`
+	TrackStars        map[string]int64   `bson:"track_stars" json:"track_stars"`
+	TrackScores       map[string]float64 `bson:"track_scores" json:"track_scores"`
+	RubricScores      map[string]float64 `bson:"rubric_scores" json:"rubric_scores"`             // Average score of each rubric criterion from general judges
+	RubricTotal       float64            `bson:"rubric_total" json:"rubric_total"`               // Weighted total of the rubric criteria from general judges
+	TrackRubricTotals map[string]float64 `bson:"track_rubric_totals" json:"track_rubric_totals"` // Weighted rubric total from each track's judges
+	Combined          float64            `bson:"-" json:"combined"`                              // Final score under the score formula, calculated when listing projects
+	Place             int64              `bson:"-" json:"place"`                                 // Place by combined score among active projects (0 if inactive)
+	TeamEmails        []string           `bson:"team_emails" json:"-"`                           // Emails of the team members, used to find conflicts of interest (only sent to admins)
+	Universities      []string           `bson:"universities" json:"-"`                          // Universities of the team members, used to find conflicts of interest (only sent to admins)
+	Tags              []string           `bson:"tags" json:"tags"`                               // Expertise tags from the CSV or Devpost, on top of the challenges (see ExpertiseTags)
+	Round             int64              `bson:"round" json:"round"`                             // Latest round the project has been promoted into
+	Away              []AwayWindow       `bson:"away" json:"away"`                               // Times when the team is away from their table (see AwayWindow)
+	TeamCode          string             `bson:"team_code" json:"-"`                             // Code the team uses to set their own away times, never sent to judges
+	AbsentReset       primitive.DateTime `bson:"absent_reset" json:"absent_reset"`               // Only absences after this count towards hiding the project (set when hidden or marked back)
+	Active            bool               `bson:"active" json:"active"`
+	Prioritized       bool               `bson:"prioritized" json:"prioritized"`
+	Group             int64              `bson:"group" json:"group"`
+	LastActivity      primitive.DateTime `bson:"last_activity" json:"last_activity"`
 }
 
 func NewProject(name string, location int64, group int64, description string, url string, tryLink string, videoLink string, challengeList []string) *Project {
 	return &Project{
-		Name:          name,
-		Location:      location,
-		Group:         group,
-		Description:   description,
-		Url:           url,
-		TryLink:       tryLink,
-		VideoLink:     videoLink,
-		ChallengeList: challengeList,
-		Seen:          0,
-		TrackSeen:     make(map[string]int64),
-		Score:         0,
-		Stars:         0,
-		TrackStars:    make(map[string]int64),
-		Active:        true,
-		Prioritized:   false,
-		LastActivity:  primitive.DateTime(0),
+		Name:              name,
+		Location:          location,
+		Group:             group,
+		Description:       description,
+		Url:               url,
+		TryLink:           tryLink,
+		VideoLink:         videoLink,
+		ChallengeList:     challengeList,
+		Seen:              0,
+		TrackSeen:         make(map[string]int64),
+		Score:             0,
+		Stars:             0,
+		TrackStars:        make(map[string]int64),
+		TrackScores:       make(map[string]float64),
+		RubricScores:      make(map[string]float64),
+		TrackRubricTotals: make(map[string]float64),
+		TeamEmails:        []string{},
+		Universities:      []string{},
+		Tags:              []string{},
+		Round:             1,
+		Away:              []AwayWindow{},
+		TeamCode:          RandTeamCode(),
+		Active:            true,
+		Prioritized:       false,
+		LastActivity:      primitive.DateTime(0),
 	}
 }
 
+// teamCodeChars are the characters used in team codes, leaving out ones that are easy to mix up (0/O, 1/I)
+const teamCodeChars = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// teamCodeLength is the length of team codes, long enough that they can't be guessed
+const teamCodeLength = 10
+
+// RandTeamCode generates a random team code from a cryptographically secure source
+func RandTeamCode() string {
+	code := make([]byte, teamCodeLength)
+	for i := range code {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(teamCodeChars))))
+		if err != nil {
+			panic("error generating team code: " + err.Error())
+		}
+		code[i] = teamCodeChars[n.Int64()]
+	}
+	return string(code)
+}
+
 func DefaultProject() *Project {
 	return NewProject("", 0, 0, "", "", "", "", []string{})
 }
@@ -57,9 +100,11 @@ func (p *Project) MarshalJSON() ([]byte, error) {
 	return json.Marshal(&struct {
 		*Alias
 		LastActivity int64 `json:"last_activity"`
+		AbsentReset  int64 `json:"absent_reset"`
 	}{
 		Alias:        (*Alias)(p),
 		LastActivity: int64(p.LastActivity),
+		AbsentReset:  int64(p.AbsentReset),
 	})
 }
 
@@ -68,6 +113,7 @@ func (p *Project) UnmarshalJSON(data []byte) error {
 	type Alias Project
 	aux := &struct {
 		LastActivity int64 `json:"last_activity"`
+		AbsentReset  int64 `json:"absent_reset"`
 		*Alias
 	}{
 		Alias: (*Alias)(p),
@@ -76,6 +122,7 @@ func (p *Project) UnmarshalJSON(data []byte) error {
 		return err
 	}
 	p.LastActivity = primitive.DateTime(aux.LastActivity)
+	p.AbsentReset = primitive.DateTime(aux.AbsentReset)
 	return nil
 }
 
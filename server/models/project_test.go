@@ -0,0 +1,25 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRandTeamCode(t *testing.T) {
+	seen := make(map[string]bool)
+	for i := 0; i < 100; i++ {
+		code := RandTeamCode()
+		if len(code) != teamCodeLength {
+			t.Fatalf("expected a %d character code, got %s", teamCodeLength, code)
+		}
+		for _, c := range code {
+			if !strings.ContainsRune(teamCodeChars, c) {
+				t.Fatalf("unexpected character %c in code %s", c, code)
+			}
+		}
+		if seen[code] {
+			t.Errorf("expected random codes not to repeat, got %s twice", code)
+		}
+		seen[code] = true
+	}
+}
@@ -0,0 +1,12 @@
+package models
+
+// QuotaProgress is how far a judge is toward their quota of projects and time budget
+// (see judging.JudgeQuotaProgress). Once it is reached, the judge should wrap up and rank
+// instead of being given more projects.
+type QuotaProgress struct {
+	Seen    int64 `json:"seen"`    // Projects judged in the round
+	Quota   int64 `json:"quota"`   // Maximum number of projects (0 = no limit)
+	Minutes int64 `json:"minutes"` // Minutes of judging clock time since the judge was given their first project in the round
+	Budget  int64 `json:"budget"`  // Time budget in minutes (0 = no limit)
+	Reached bool  `json:"reached"` // Whether the judge has reached either limit
+}
@@ -0,0 +1,59 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// Round is the record of a judging round that has been closed.
+// The results of the round are kept in the snapshot taken when it was closed,
+// and each judge's rankings and seen projects are kept in their PastRounds.
+type Round struct {
+	Id              primitive.ObjectID   `bson:"_id,omitempty" json:"id"`
+	Round           int64                `bson:"round" json:"round"`
+	Time            int64                `bson:"time" json:"time"`                         // Time the round was closed
+	SnapshotVersion int64                `bson:"snapshot_version" json:"snapshot_version"` // Version of the results snapshot taken when the round was closed
+	Promoted        []primitive.ObjectID `bson:"promoted" json:"promoted"`                 // Projects promoted into the next round
+	Judges          []primitive.ObjectID `bson:"judges" json:"judges"`                     // Judges moved into the next round's pool
+}
+
+// JudgeRound is a judge's judging data from a round that has been closed
+type JudgeRound struct {
+	Round        int64                  `bson:"round" json:"round"`
+	Seen         int64                  `bson:"seen" json:"seen"`
+	SeenProjects []JudgedProject        `bson:"seen_projects" json:"seen_projects"`
+	Rankings     []primitive.ObjectID   `bson:"rankings" json:"rankings"`
+	RankingTiers [][]primitive.ObjectID `bson:"ranking_tiers" json:"ranking_tiers"`
+	Flagged      []primitive.ObjectID   `bson:"flagged" json:"flagged"`
+}
+
+// PastComparison is the number of times a pair of projects was seen by the same judge
+// in a judging round that has been closed
+type PastComparison struct {
+	Id    primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Round int64              `bson:"round" json:"round"`
+	A     primitive.ObjectID `bson:"a" json:"a"`
+	B     primitive.ObjectID `bson:"b" json:"b"`
+	Count int64              `bson:"count" json:"count"`
+}
+
+func NewRound(round int64, snapshotVersion int64, promoted []primitive.ObjectID, judges []primitive.ObjectID) *Round {
+	return &Round{
+		Round:           round,
+		Time:            GetCurrTime(),
+		SnapshotVersion: snapshotVersion,
+		Promoted:        promoted,
+		Judges:          judges,
+	}
+}
+
+// NewPastComparison creates the count of a pair of projects in a closed round.
+// The pair is stored with the smaller ID first, so each pair has exactly one record per round.
+func NewPastComparison(a primitive.ObjectID, b primitive.ObjectID, count int64, round int64) *PastComparison {
+	if b.Hex() < a.Hex() {
+		a, b = b, a
+	}
+	return &PastComparison{
+		Round: round,
+		A:     a,
+		B:     b,
+		Count: count,
+	}
+}
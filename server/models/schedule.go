@@ -0,0 +1,19 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// ScheduleStop is one project on a judge's precomputed itinerary.
+// The name and table are copied from the project so the itinerary can be printed as is.
+type ScheduleStop struct {
+	ProjectId primitive.ObjectID `bson:"project_id" json:"project_id"`
+	Name      string             `bson:"name" json:"name"`
+	Location  int64              `bson:"location" json:"location"`
+}
+
+func NewScheduleStop(project *Project) *ScheduleStop {
+	return &ScheduleStop{
+		ProjectId: project.Id,
+		Name:      project.Name,
+		Location:  project.Location,
+	}
+}
@@ -0,0 +1,47 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// Snapshot is a frozen copy of the results at a moment in time.
+// Snapshots are never modified after they are created.
+type Snapshot struct {
+	Id       primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Version  int64              `bson:"version" json:"version"` // Increases by 1 for every snapshot taken
+	Time     int64              `bson:"time" json:"time"`
+	Reason   string             `bson:"reason" json:"reason"` // "deliberation", "manual", or "round"
+	Projects []SnapshotProject  `bson:"projects" json:"projects"`
+	Rankings []SnapshotRanking  `bson:"rankings" json:"rankings"`
+	Options  Options            `bson:"options" json:"options"`
+}
+
+// SnapshotProject is the aggregated result of a single project in a snapshot
+type SnapshotProject struct {
+	ProjectId   primitive.ObjectID `bson:"project_id" json:"project_id"`
+	Name        string             `bson:"name" json:"name"`
+	Location    int64              `bson:"location" json:"location"`
+	Active      bool               `bson:"active" json:"active"`
+	Score       float64            `bson:"score" json:"score"`
+	Place       int64              `bson:"place" json:"place"` // Place by score (ties share the same place)
+	Stars       int64              `bson:"stars" json:"stars"`
+	TrackScores map[string]float64 `bson:"track_scores" json:"track_scores"`
+	TrackStars  map[string]int64   `bson:"track_stars" json:"track_stars"`
+}
+
+// SnapshotRanking is the ranking of a single judge in a snapshot
+type SnapshotRanking struct {
+	JudgeId   primitive.ObjectID     `bson:"judge_id" json:"judge_id"`
+	JudgeName string                 `bson:"judge_name" json:"judge_name"`
+	Track     string                 `bson:"track" json:"track"`
+	Weight    float64                `bson:"weight" json:"weight"`
+	Tiers     [][]primitive.ObjectID `bson:"tiers" json:"tiers"`
+}
+
+func NewSnapshot(version int64, reason string) *Snapshot {
+	return &Snapshot{
+		Version:  version,
+		Time:     GetCurrTime(),
+		Reason:   reason,
+		Projects: []SnapshotProject{},
+		Rankings: []SnapshotRanking{},
+	}
+}
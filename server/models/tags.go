@@ -0,0 +1,32 @@
+package models
+
+import (
+	"slices"
+	"strings"
+)
+
+// NormalizeTags lowercases and trims the tags, dropping empty and duplicate tags
+func NormalizeTags(tags []string) []string {
+	out := []string{}
+	seen := make(map[string]bool)
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		out = append(out, tag)
+	}
+	return out
+}
+
+// ParseTags splits a comma-separated list of tags (e.g. a CSV cell) and normalizes them
+func ParseTags(list string) []string {
+	return NormalizeTags(strings.Split(list, ","))
+}
+
+// ExpertiseTags returns every tag of the project: its own tags plus the challenges it entered,
+// so that e.g. a judge tagged "best hardware hack" matches every project in that challenge
+func (p *Project) ExpertiseTags() []string {
+	return NormalizeTags(append(slices.Clone(p.ChallengeList), p.Tags...))
+}
@@ -1,16 +1,21 @@
 package router
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"server/config"
 	"server/database"
 	"server/funcs"
+	"server/judging"
 	"server/models"
 	"server/util"
+	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type LoginAdminRequest struct {
@@ -127,11 +132,8 @@ func PauseClock(ctx *gin.Context) {
 	state.Clock.Mutex.Lock()
 	defer state.Clock.Mutex.Unlock()
 
-	// Pause the clock
-	state.Clock.State.Pause()
-
-	// Backup
-	err := database.UpdateClockConditional(state.Db, ctx, &state.Clock.State)
+	// Pause the clock and back it up
+	err := updateClock(state, ctx, (*models.ClockState).Pause)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error updating clock: " + err.Error()})
 		return
@@ -151,11 +153,8 @@ func UnpauseClock(ctx *gin.Context) {
 	state.Clock.Mutex.Lock()
 	defer state.Clock.Mutex.Unlock()
 
-	// Unpause the clock
-	state.Clock.State.Resume()
-
-	// Backup
-	err := database.UpdateClockConditional(state.Db, ctx, &state.Clock.State)
+	// Unpause the clock and back it up
+	err := updateClock(state, ctx, (*models.ClockState).Resume)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error updating clock: " + err.Error()})
 		return
@@ -175,11 +174,8 @@ func ResetClock(ctx *gin.Context) {
 	state.Clock.Mutex.Lock()
 	defer state.Clock.Mutex.Unlock()
 
-	// Reset the clock
-	state.Clock.State.Reset()
-
-	// Backup
-	err := database.UpdateClockConditional(state.Db, ctx, &state.Clock.State)
+	// Reset the clock and back it up
+	err := updateClock(state, ctx, (*models.ClockState).Reset)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error updating clock: " + err.Error()})
 		return
@@ -220,6 +216,69 @@ func SetOptions(ctx *gin.Context) {
 		return
 	}
 
+	// Make sure the ranking method is valid
+	if options.RankingMethod != nil && !judging.IsValidRankingMethod(*options.RankingMethod) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid ranking method: " + *options.RankingMethod})
+		return
+	}
+
+	// Make sure the assignment strategy is valid
+	if options.AssignStrategy != nil && !judging.IsValidAssignmentStrategy(*options.AssignStrategy) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid assignment strategy: " + *options.AssignStrategy})
+		return
+	}
+
+	// Make sure the lease grace period is valid
+	if options.LeaseGrace != nil && *options.LeaseGrace <= 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "lease grace period must be positive"})
+		return
+	}
+
+	// The tag weight is a probability
+	if options.TagWeight != nil && (*options.TagWeight < 0 || *options.TagWeight > 1) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "tag weight must be between 0 and 1"})
+		return
+	}
+
+	// A project has to be absent at least once to be hidden
+	if options.AbsentLimit != nil && *options.AbsentLimit < 1 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "absent limit must be at least 1"})
+		return
+	}
+
+	// Adaptive assignment needs at least one place to settle
+	if options.AdaptiveTopN != nil && *options.AdaptiveTopN < 1 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "adaptive top n must be at least 1"})
+		return
+	}
+
+	// Make sure the rubric criteria are valid
+	if options.Criteria != nil {
+		err = models.ValidateCriteria(*options.Criteria)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid criteria: " + err.Error()})
+			return
+		}
+	}
+
+	// Make sure the floor plan is valid
+	if options.FloorPlan != nil {
+		err = models.ValidateFloorPlan(*options.FloorPlan)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid floor plan: " + err.Error()})
+			return
+		}
+	}
+
+	// Make sure the score formula is valid
+	if options.ScoreFormula != nil {
+		err = models.ValidateScoreFormula(*options.ScoreFormula)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid score formula: " + err.Error()})
+			return
+		}
+	}
+
 	// Save the options in the database
 	err = database.UpdateOptions(state.Db, ctx, &options)
 	if err != nil {
@@ -227,6 +286,15 @@ func SetOptions(ctx *gin.Context) {
 		return
 	}
 
+	// Re-calculate all judges' aggregated rankings with the new ranking method
+	if options.RankingMethod != nil {
+		err = judging.InitAggregateRankings(state.Db)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error re-calculating aggregate rankings for all judges: " + err.Error()})
+			return
+		}
+	}
+
 	// Send OK
 	state.Logger.AdminLogf("Updated options: %s", util.StructToStringWithoutNils(options))
 	ctx.JSON(http.StatusOK, gin.H{"ok": 1})
@@ -280,6 +348,20 @@ func ResetDatabase(ctx *gin.Context) {
 		return
 	}
 
+	// Dropping the projects also drops the team code index
+	err = database.EnsureTeamCodeIndex(state.Db, ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error creating team code index: " + err.Error()})
+		return
+	}
+
+	// Rebuild the comparisons from the judging data that is left
+	err = judging.ReloadComparisons(state.Db, ctx, state.Comps)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error reloading comparisons: " + err.Error()})
+		return
+	}
+
 	// Send OK
 	state.Logger.AdminLogf("Reset database: " + req.Type)
 	ctx.JSON(http.StatusOK, gin.H{"ok": 1})
@@ -349,8 +431,15 @@ func ExportProjects(ctx *gin.Context) {
 		return
 	}
 
+	// Get the Bradley-Terry strength of each project
+	strengths, err := getProjectStrengths(state.Db, ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error calculating project strengths: " + err.Error()})
+		return
+	}
+
 	// Create the CSV
-	csvData := funcs.CreateProjectCSV(projects)
+	csvData := funcs.CreateProjectCSV(projects, strengths)
 
 	// Send CSV
 	state.Logger.AdminLogf("Exported projects to CSV")
@@ -370,8 +459,29 @@ func ExportProjectsByChallenge(ctx *gin.Context) {
 		return
 	}
 
+	// Get the track scores and stars of each project
+	scores, err := judging.AggregateScores(state.Db, ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error calculating scores: " + err.Error()})
+		return
+	}
+	for _, p := range projects {
+		if pScore, ok := scores[p.Id]; ok {
+			p.TrackStars = pScore.TrackStars
+			p.TrackScores = pScore.TrackScores
+			p.TrackRubricTotals = pScore.TrackRubricTotals
+		}
+	}
+
+	// Get the Bradley-Terry strength of each project
+	strengths, err := getProjectStrengths(state.Db, ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error calculating project strengths: " + err.Error()})
+		return
+	}
+
 	// Create the zip file
-	zipData, err := funcs.CreateProjectChallengeZip(projects)
+	zipData, err := funcs.CreateProjectChallengeZip(projects, strengths)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error creating zip file: " + err.Error()})
 		return
@@ -382,11 +492,26 @@ func ExportProjectsByChallenge(ctx *gin.Context) {
 	funcs.AddZipFile("projects", zipData, ctx)
 }
 
-// POST /admin/export/rankings - ExportRankings exports the rankings of each judge as a CSV
+// POST /admin/export/rankings - ExportRankings exports the rankings of each judge as a CSV.
+// The ranking method can be overridden with the "method" query parameter.
 func ExportRankings(ctx *gin.Context) {
 	// Get the state from the context
 	state := GetState(ctx)
 
+	// Get the options
+	options, err := database.GetOptions(state.Db, ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error getting options: " + err.Error()})
+		return
+	}
+
+	// Get the ranking method to score with
+	method := ctx.DefaultQuery("method", judging.NormalizeRankingMethod(options.RankingMethod))
+	if !judging.IsValidRankingMethod(method) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid ranking method: " + method})
+		return
+	}
+
 	// Get all the judges
 	judges, err := database.FindAllJudges(state.Db, ctx)
 	if err != nil {
@@ -394,14 +519,203 @@ func ExportRankings(ctx *gin.Context) {
 		return
 	}
 
+	// Get the final standings under the score formula
+	standings, err := getCombinedScores(state.Db, ctx, options)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error calculating combined scores: " + err.Error()})
+		return
+	}
+
 	// Create the CSV
-	csvData := funcs.CreateJudgeRankingCSV(judges)
+	csvData := funcs.CreateJudgeRankingCSV(judges, method, models.ScoreFormulaOrDefault(options.ScoreFormula), standings)
 
 	// Send CSV
-	state.Logger.AdminLogf("Exported rankings to CSV")
+	state.Logger.AdminLogf("Exported rankings to CSV (%s)", method)
 	funcs.AddCsvData("rankings", csvData, ctx)
 }
 
+// GET /admin/export/rankings/compare - ExportRankingComparison exports the final ranking of
+// every project under each ranking method as a CSV, so that the methods can be compared
+func ExportRankingComparison(ctx *gin.Context) {
+	// Get the state from the context
+	state := GetState(ctx)
+
+	// Get the options
+	options, err := database.GetOptions(state.Db, ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error getting options: " + err.Error()})
+		return
+	}
+
+	// Get all the projects
+	projects, err := database.FindAllProjects(state.Db, ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error getting projects: " + err.Error()})
+		return
+	}
+
+	// Get all general judges (track judges are not ranked)
+	judges, err := database.FindJudgesByTrack(state.Db, ctx, "")
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error getting judges: " + err.Error()})
+		return
+	}
+
+	// Run the rankings through every method
+	scores := make(map[string]map[primitive.ObjectID]float64, len(judging.RankingMethods))
+	for _, method := range judging.RankingMethods {
+		scores[method] = judging.ComputeMethodScores(judges, method)
+	}
+
+	// Create the CSV
+	csvData := funcs.CreateRankingComparisonCSV(projects, judging.RankingMethods, scores, judging.NormalizeRankingMethod(options.RankingMethod))
+
+	// Send CSV
+	state.Logger.AdminLogf("Exported ranking method comparison to CSV")
+	funcs.AddCsvData("ranking-comparison", csvData, ctx)
+}
+
+// GET /admin/export/pairwise - ExportPairwise exports the pairwise preference matrix built from
+// every judge's rankings. Use ?format=json for JSON (default is CSV) and ?track= to use a track's judges.
+func ExportPairwise(ctx *gin.Context) {
+	// Get the state from the context
+	state := GetState(ctx)
+
+	// Check the format
+	format := ctx.DefaultQuery("format", "csv")
+	if format != "csv" && format != "json" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid format, must be csv or json"})
+		return
+	}
+
+	// Get all projects
+	projects, err := database.FindAllProjects(state.Db, ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error getting projects: " + err.Error()})
+		return
+	}
+
+	// Get the judges of the track (general judges if no track is given)
+	track := ctx.Query("track")
+	judges, err := database.FindJudgesByTrack(state.Db, ctx, track)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error getting judges: " + err.Error()})
+		return
+	}
+
+	// Use the judges' data from the given round (the current round by default)
+	op, err := database.GetOptions(state.Db, ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error getting options: " + err.Error()})
+		return
+	}
+	current := judging.CurrentRound(op)
+	round, err := strconv.ParseInt(ctx.DefaultQuery("round", strconv.FormatInt(current, 10)), 10, 64)
+	if err != nil || round < 1 || round > current {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid round"})
+		return
+	}
+	judges = judging.JudgesInRound(judges, round, current)
+
+	// Build the matrix
+	matrix := judging.ComputePairwiseMatrix(judges, projects)
+
+	// Send JSON
+	if format == "json" {
+		state.Logger.AdminLogf("Exported pairwise matrix to JSON")
+		ctx.JSON(http.StatusOK, matrix)
+		return
+	}
+
+	// Send CSV
+	state.Logger.AdminLogf("Exported pairwise matrix to CSV")
+	funcs.AddCsvData("pairwise", funcs.CreatePairwiseCSV(matrix), ctx)
+}
+
+// getProjectStrengths fits the Bradley-Terry model over all general judges' rankings
+// and returns a map of project IDs to their strengths
+func getProjectStrengths(db *mongo.Database, ctx context.Context) (map[primitive.ObjectID]*judging.BTScore, error) {
+	judges, err := database.FindJudgesByTrack(db, ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	strengths := make(map[primitive.ObjectID]*judging.BTScore)
+	for _, bt := range judging.ComputeBradleyTerry(judges) {
+		strengths[bt.ProjectId] = bt
+	}
+	return strengths, nil
+}
+
+// getCombinedScores applies the score formula from the options to the aggregated scores of all projects
+func getCombinedScores(db *mongo.Database, ctx context.Context, op *models.Options) ([]*judging.CombinedScore, error) {
+	projects, err := database.FindAllProjects(db, ctx)
+	if err != nil {
+		return nil, err
+	}
+	scores, err := judging.AggregateScores(db, ctx)
+	if err != nil {
+		return nil, err
+	}
+	return judging.ComputeCombinedScores(projects, scores, op.ScoreFormula, op.Criteria), nil
+}
+
+// GET /admin/results/bradley-terry - GetBradleyTerryResults returns the Bradley-Terry strength and
+// 95% confidence interval of every project that has been compared, sorted by strength
+func GetBradleyTerryResults(ctx *gin.Context) {
+	// Get the state from the context
+	state := GetState(ctx)
+
+	// Get all general judges (track judges are not ranked)
+	judges, err := database.FindJudgesByTrack(state.Db, ctx, "")
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error getting judges: " + err.Error()})
+		return
+	}
+
+	// Fit the model
+	results := judging.ComputeBradleyTerry(judges)
+
+	// Send OK
+	ctx.JSON(http.StatusOK, results)
+}
+
+// GET /admin/results/explain/:id - ExplainProjectScore returns all of the evidence behind a project's score:
+// every judge that saw it, where they ranked it, its head-to-head record against every other project,
+// and its stars and flags
+func ExplainProjectScore(ctx *gin.Context) {
+	// Get the state from the context
+	state := GetState(ctx)
+
+	// Convert ID string to ObjectID
+	projectId, err := primitive.ObjectIDFromHex(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid project ID"})
+		return
+	}
+
+	// Get the project
+	project, err := database.FindProject(state.Db, ctx, &projectId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error getting project: " + err.Error()})
+		return
+	}
+	if project == nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "project not found"})
+		return
+	}
+
+	// Collect the evidence
+	explanation, err := judging.ExplainScore(state.Db, ctx, project)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error explaining project score: " + err.Error()})
+		return
+	}
+
+	// Send OK
+	ctx.JSON(http.StatusOK, explanation)
+}
+
 // GET /admin/timer - GetJudgingTimer returns the judging timer
 func GetJudgingTimer(ctx *gin.Context) {
 	// Get the state from the context
@@ -522,6 +836,189 @@ func RemoveFlag(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, gin.H{"ok": 1})
 }
 
+// GET /admin/conflicts - returns all conflicts of interest
+func GetConflicts(ctx *gin.Context) {
+	// Get the state from the context
+	state := GetState(ctx)
+
+	// Get all the conflicts
+	conflicts, err := database.FindAllConflicts(state.Db, ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error getting conflicts: " + err.Error()})
+		return
+	}
+
+	// Send OK
+	ctx.JSON(http.StatusOK, conflicts)
+}
+
+type AddConflictRequest struct {
+	JudgeId   string `json:"judge_id"`
+	ProjectId string `json:"project_id"`
+	Reason    string `json:"reason"`
+}
+
+// POST /admin/conflicts - manually adds a conflict of interest between a judge and a project
+func AddConflict(ctx *gin.Context) {
+	// Get the state from the context
+	state := GetState(ctx)
+
+	// Get the request object
+	var conflictReq AddConflictRequest
+	err := ctx.BindJSON(&conflictReq)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "error reading request body: " + err.Error()})
+		return
+	}
+
+	// Convert IDs to ObjectIDs
+	judgeId, err := primitive.ObjectIDFromHex(conflictReq.JudgeId)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid judge ID"})
+		return
+	}
+	projectId, err := primitive.ObjectIDFromHex(conflictReq.ProjectId)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid project ID"})
+		return
+	}
+
+	// Get the judge and project
+	judge, err := database.FindJudge(state.Db, ctx, judgeId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error finding judge: " + err.Error()})
+		return
+	}
+	if judge == nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "judge not found"})
+		return
+	}
+	project, err := database.FindProject(state.Db, ctx, &projectId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error finding project: " + err.Error()})
+		return
+	}
+	if project == nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "project not found"})
+		return
+	}
+
+	// Insert the conflict
+	conflict := models.NewConflict(judge, project, "manual", conflictReq.Reason)
+	added, err := database.InsertConflicts(state.Db, ctx, []*models.Conflict{conflict})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error inserting conflict: " + err.Error()})
+		return
+	}
+	if added == 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "judge already has a conflict with this project"})
+		return
+	}
+
+	// Throw out the judge's rankings of the project
+	err = refreshConflictedResults(state.Db)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error re-calculating results: " + err.Error()})
+		return
+	}
+
+	// Send OK
+	state.Logger.AdminLogf("Added conflict between judge %s and project %s", judge.Name, project.Name)
+	ctx.JSON(http.StatusOK, gin.H{"ok": 1})
+}
+
+// POST /admin/conflicts/match - automatically finds conflicts of interest from the
+// email domains and affiliations of the judges and projects
+func MatchConflicts(ctx *gin.Context) {
+	// Get the state from the context
+	state := GetState(ctx)
+
+	// Get all judges and projects
+	judges, err := database.FindAllJudges(state.Db, ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error getting judges: " + err.Error()})
+		return
+	}
+	projects, err := database.FindAllProjects(state.Db, ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error getting projects: " + err.Error()})
+		return
+	}
+
+	// Match and insert the conflicts
+	added, err := database.InsertConflicts(state.Db, ctx, judging.MatchConflicts(judges, projects))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error inserting conflicts: " + err.Error()})
+		return
+	}
+
+	// Throw out the rankings of any newly conflicted projects
+	if added > 0 {
+		err = refreshConflictedResults(state.Db)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error re-calculating results: " + err.Error()})
+			return
+		}
+	}
+
+	// Send OK
+	state.Logger.AdminLogf("Matched %d new conflicts", added)
+	ctx.JSON(http.StatusOK, gin.H{"added": added})
+}
+
+// DELETE /admin/conflicts/:id - removes a conflict of interest
+func RemoveConflict(ctx *gin.Context) {
+	// Get the state from the context
+	state := GetState(ctx)
+
+	// Get the conflict ID from the URL
+	id := ctx.Param("id")
+	conflictId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "error parsing conflict ID: " + err.Error()})
+		return
+	}
+
+	// Get the conflict
+	conflict, err := database.FindConflict(state.Db, ctx, &conflictId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error finding conflict: " + err.Error()})
+		return
+	}
+	if conflict == nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "conflict not found"})
+		return
+	}
+
+	// Delete the conflict
+	err = database.DeleteConflict(state.Db, ctx, conflict)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error deleting conflict: " + err.Error()})
+		return
+	}
+
+	// Count the judge's rankings of the project again
+	err = refreshConflictedResults(state.Db)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error re-calculating results: " + err.Error()})
+		return
+	}
+
+	// Send OK
+	state.Logger.AdminLogf("Deleted conflict between judge %s and project %s", conflict.JudgeName, conflict.ProjectName)
+	ctx.JSON(http.StatusOK, gin.H{"ok": 1})
+}
+
+// refreshConflictedResults re-calculates the aggregate rankings and judge weights,
+// which both depend on which projects each judge is conflicted with
+func refreshConflictedResults(db *mongo.Database) error {
+	err := judging.InitAggregateRankings(db)
+	if err != nil {
+		return err
+	}
+	return judging.RecalibrateJudgeWeights(db)
+}
+
 // POST /admin/qr - generates a new QR code
 func GenerateQRCode(ctx *gin.Context) {
 	// Get the state from the context
@@ -689,18 +1186,38 @@ func SetDeliberation(ctx *gin.Context) {
 		return
 	}
 
-	// Update the deliberation state
-	err = database.UpdateOptions(state.Db, ctx, &models.OptionalOptions{Deliberation: &req.Start})
+	// Update the deliberation state, freezing the results if deliberation is starting
+	var snapshot *models.Snapshot
+	err = database.WithTransaction(state.Db, func(sc mongo.SessionContext) error {
+		err := database.UpdateOptions(state.Db, sc, &models.OptionalOptions{Deliberation: &req.Start})
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error updating deliberation: " + err.Error()})
+			return err
+		}
+
+		if !req.Start {
+			return nil
+		}
+		snapshot, err = judging.TakeSnapshot(state.Db, sc, "deliberation")
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error taking results snapshot: " + err.Error()})
+			return err
+		}
+		return nil
+	})
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error updating deliberation: " + err.Error()})
 		return
 	}
 
 	// Also pause judging if deliberation has started
 	if req.Start {
 		state.Clock.Mutex.Lock()
-		state.Clock.State.Pause()
+		err = updateClock(state, ctx, (*models.ClockState).Pause)
 		state.Clock.Mutex.Unlock()
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error pausing clock: " + err.Error()})
+			return
+		}
 	}
 
 	// Send OK
@@ -709,9 +1226,197 @@ func SetDeliberation(ctx *gin.Context) {
 		hap = "Stopped"
 	}
 	state.Logger.AdminLogf("%s deliberation", hap)
+	if snapshot != nil {
+		state.Logger.AdminLogf("Took results snapshot v%d", snapshot.Version)
+	}
 	ctx.JSON(http.StatusOK, gin.H{"ok": 1})
 }
 
+// POST /admin/snapshots - TakeSnapshot freezes the current results as a new snapshot
+func TakeSnapshot(ctx *gin.Context) {
+	// Get the state from the context
+	state := GetState(ctx)
+
+	// Take the snapshot in a transaction so the results are consistent
+	var snapshot *models.Snapshot
+	err := database.WithTransaction(state.Db, func(sc mongo.SessionContext) error {
+		var err error
+		snapshot, err = judging.TakeSnapshot(state.Db, sc, "manual")
+		return err
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error taking results snapshot: " + err.Error()})
+		return
+	}
+
+	// Send OK
+	state.Logger.AdminLogf("Took results snapshot v%d", snapshot.Version)
+	ctx.JSON(http.StatusOK, gin.H{"id": snapshot.Id, "version": snapshot.Version})
+}
+
+type CloseRoundRequest struct {
+	Promote int64                `json:"promote"`
+	Judges  []primitive.ObjectID `json:"judges"`
+}
+
+// POST /admin/rounds/close - CloseRound closes the current judging round and promotes the
+// top projects into the next round, which is judged by a separate pool of judges
+func CloseRound(ctx *gin.Context) {
+	// Get the state from the context
+	state := GetState(ctx)
+
+	// Get the request object
+	var req CloseRoundRequest
+	err := ctx.BindJSON(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "error reading request body: " + err.Error()})
+		return
+	}
+
+	// At least one project has to move on
+	if req.Promote < 1 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "number of projects to promote must be at least 1"})
+		return
+	}
+
+	// Close the round
+	var round *models.Round
+	err = database.WithTransaction(state.Db, func(sc mongo.SessionContext) error {
+		var err error
+		round, err = judging.CloseRound(state.Db, sc, req.Promote, req.Judges)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error closing round: " + err.Error()})
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return
+	}
+
+	// Reload the comparisons once the round is closed, since they now only cover the new round
+	err = judging.ReloadComparisons(state.Db, ctx, state.Comps)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error reloading comparisons: " + err.Error()})
+		return
+	}
+
+	// Send OK
+	state.Logger.AdminLogf("Closed round %d and promoted %d projects and %d judges to round %d", round.Round, len(round.Promoted), len(round.Judges), round.Round+1)
+	ctx.JSON(http.StatusOK, round)
+}
+
+// GET /admin/rounds - ListRounds returns the current round and the records of all closed rounds
+func ListRounds(ctx *gin.Context) {
+	// Get the state from the context
+	state := GetState(ctx)
+
+	// Get the current round
+	op, err := database.GetOptions(state.Db, ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error getting options: " + err.Error()})
+		return
+	}
+
+	// Get the closed rounds
+	rounds, err := database.FindAllRounds(state.Db, ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error getting rounds: " + err.Error()})
+		return
+	}
+
+	// Send OK
+	ctx.JSON(http.StatusOK, gin.H{"round": judging.CurrentRound(op), "rounds": rounds})
+}
+
+// GET /admin/snapshots - ListSnapshots returns all snapshots (without their results)
+func ListSnapshots(ctx *gin.Context) {
+	// Get the state from the context
+	state := GetState(ctx)
+
+	// Get the snapshots
+	snapshots, err := database.FindAllSnapshots(state.Db, ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error getting snapshots: " + err.Error()})
+		return
+	}
+
+	// Send OK
+	ctx.JSON(http.StatusOK, snapshots)
+}
+
+// getSnapshotFromParam gets a snapshot by the ID in the given URL or query parameter.
+// Writes the error response and returns nil if the snapshot could not be found.
+func getSnapshotFromParam(ctx *gin.Context, id string) *models.Snapshot {
+	// Get the state from the context
+	state := GetState(ctx)
+
+	// Convert ID string to ObjectID
+	snapshotId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid snapshot ID: " + id})
+		return nil
+	}
+
+	// Get the snapshot
+	snapshot, err := database.FindSnapshot(state.Db, ctx, snapshotId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error getting snapshot: " + err.Error()})
+		return nil
+	}
+	if snapshot == nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "snapshot not found: " + id})
+		return nil
+	}
+
+	return snapshot
+}
+
+// GET /admin/snapshots/:id - GetSnapshot returns a snapshot with all of its results
+func GetSnapshot(ctx *gin.Context) {
+	snapshot := getSnapshotFromParam(ctx, ctx.Param("id"))
+	if snapshot == nil {
+		return
+	}
+
+	// Send OK
+	ctx.JSON(http.StatusOK, snapshot)
+}
+
+// GET /admin/export/snapshot/:id - ExportSnapshot exports the results of a snapshot as a CSV
+func ExportSnapshot(ctx *gin.Context) {
+	// Get the state from the context
+	state := GetState(ctx)
+
+	snapshot := getSnapshotFromParam(ctx, ctx.Param("id"))
+	if snapshot == nil {
+		return
+	}
+
+	// Create the CSV
+	csvData := funcs.CreateSnapshotCSV(snapshot)
+
+	// Send CSV
+	state.Logger.AdminLogf("Exported results snapshot v%d to CSV", snapshot.Version)
+	funcs.AddCsvData(fmt.Sprintf("snapshot-v%d", snapshot.Version), csvData, ctx)
+}
+
+// GET /admin/snapshots/diff - DiffSnapshots returns everything that changed between
+// the snapshots given by the "from" and "to" query parameters
+func DiffSnapshots(ctx *gin.Context) {
+	from := getSnapshotFromParam(ctx, ctx.Query("from"))
+	if from == nil {
+		return
+	}
+	to := getSnapshotFromParam(ctx, ctx.Query("to"))
+	if to == nil {
+		return
+	}
+
+	// Send OK
+	ctx.JSON(http.StatusOK, judging.DiffSnapshots(from, to))
+}
+
 // GET /group-info - GetGroupInfo returns the names of the groups and if groups are enabled
 func GetGroupInfo(ctx *gin.Context) {
 	// Get the state from the context
@@ -758,7 +1463,9 @@ func SetBlockReqs(ctx *gin.Context) {
 	}
 
 	// Update the limiter
+	state.Limiter.Mutex.Lock()
 	state.Limiter.Block = *req.BlockReqs
+	state.Limiter.Mutex.Unlock()
 
 	// Send OK
 	state.Logger.AdminLogf("Updated block requests to %t", *req.BlockReqs)
@@ -792,7 +1499,9 @@ func SetMaxReqs(ctx *gin.Context) {
 	}
 
 	// Update the limiter
+	state.Limiter.Mutex.Lock()
 	state.Limiter.MaxReqPerMin = int(*req.MaxReqPerMin)
+	state.Limiter.Mutex.Unlock()
 
 	// Send OK
 	state.Logger.AdminLogf("Updated max requests to %d", *req.MaxReqPerMin)
@@ -897,3 +1606,234 @@ func SetTrackViews(ctx *gin.Context) {
 	state.Logger.AdminLogf("Updated track views to %s", util.StructToStringWithoutNils(req))
 	ctx.JSON(http.StatusOK, gin.H{"ok": 1})
 }
+
+// POST /admin/floor-plan - sets the positions of the tables, used to send judges to nearby tables
+func SetFloorPlan(ctx *gin.Context) {
+	// Get the state from the context
+	state := GetState(ctx)
+
+	// Get the request
+	var plan models.FloorPlan
+	err := ctx.BindJSON(&plan)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "error parsing request: " + err.Error()})
+		return
+	}
+	if plan.Tables == nil {
+		plan.Tables = []models.TablePosition{}
+	}
+
+	// Make sure the floor plan is valid
+	err = models.ValidateFloorPlan(plan)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid floor plan: " + err.Error()})
+		return
+	}
+
+	// Update options
+	err = database.UpdateOptions(state.Db, ctx, &models.OptionalOptions{FloorPlan: &plan})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error updating floor plan: " + err.Error()})
+		return
+	}
+
+	// Send OK
+	state.Logger.AdminLogf("Updated floor plan with %d tables", len(plan.Tables))
+	ctx.JSON(http.StatusOK, gin.H{"ok": 1})
+}
+
+// POST /admin/schedule - GenerateSchedule plans a full itinerary for every judge up front
+// (see judging.ComputeSchedules), replacing any existing itineraries
+func GenerateSchedule(ctx *gin.Context) {
+	// Get the state from the context
+	state := GetState(ctx)
+
+	// Get the request
+	var limits judging.ScheduleLimits
+	err := ctx.BindJSON(&limits)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "error parsing request: " + err.Error()})
+		return
+	}
+	if limits.Length < 0 || limits.MaxPairs < 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "length and max pairs cannot be negative"})
+		return
+	}
+
+	// Plan and save the itineraries
+	var summary *judging.ScheduleSummary
+	err = database.WithTransaction(state.Db, func(sc mongo.SessionContext) error {
+		judges, err := database.FindAllJudges(state.Db, sc)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error getting judges: " + err.Error()})
+			return err
+		}
+		projects, err := database.FindAllProjects(state.Db, sc)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error getting projects: " + err.Error()})
+			return err
+		}
+		op, err := database.GetOptions(state.Db, sc)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error getting options: " + err.Error()})
+			return err
+		}
+
+		var schedules map[primitive.ObjectID][]models.ScheduleStop
+		schedules, summary = judging.ComputeSchedules(judges, projects, op, limits)
+		err = database.SetJudgeSchedules(state.Db, sc, schedules)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error saving itineraries: " + err.Error()})
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return
+	}
+
+	// Send OK
+	state.Logger.AdminLogf("Generated itineraries for %d judges (%d stops, %d projects short of min views)", summary.Judges, summary.Stops, len(summary.Short))
+	ctx.JSON(http.StatusOK, summary)
+}
+
+// ScheduleResponse is every judge's itinerary along with how well they cover the projects
+type ScheduleResponse struct {
+	ScheduleMode bool                     `json:"schedule_mode"`
+	Summary      *judging.ScheduleSummary `json:"summary"`
+	Itineraries  []JudgeItinerary         `json:"itineraries"`
+}
+
+// JudgeItinerary is the itinerary of a single judge
+type JudgeItinerary struct {
+	JudgeId   primitive.ObjectID    `json:"judge_id"`
+	JudgeName string                `json:"judge_name"`
+	Track     string                `json:"track"`
+	Group     int64                 `json:"group"`
+	Stops     []models.ScheduleStop `json:"stops"`
+}
+
+// GET /admin/schedule - GetSchedule returns every judge's itinerary
+func GetSchedule(ctx *gin.Context) {
+	// Get the state from the context
+	state := GetState(ctx)
+
+	// Get the judges, projects, and options
+	judges, err := database.FindAllJudges(state.Db, ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error getting judges: " + err.Error()})
+		return
+	}
+	projects, err := database.FindAllProjects(state.Db, ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error getting projects: " + err.Error()})
+		return
+	}
+	op, err := database.GetOptions(state.Db, ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error getting options: " + err.Error()})
+		return
+	}
+
+	// List the judges that have an itinerary
+	itineraries := []JudgeItinerary{}
+	for _, judge := range judges {
+		if len(judge.Schedule) == 0 {
+			continue
+		}
+		itineraries = append(itineraries, JudgeItinerary{
+			JudgeId:   judge.Id,
+			JudgeName: judge.Name,
+			Track:     judge.Track,
+			Group:     judge.Group,
+			Stops:     judge.Schedule,
+		})
+	}
+
+	// Send OK
+	ctx.JSON(http.StatusOK, ScheduleResponse{
+		ScheduleMode: op.ScheduleMode,
+		Summary:      judging.SummarizeSchedules(judges, projects, op),
+		Itineraries:  itineraries,
+	})
+}
+
+// DELETE /admin/schedule - ClearSchedule removes every judge's itinerary
+func ClearSchedule(ctx *gin.Context) {
+	// Get the state from the context
+	state := GetState(ctx)
+
+	// Clear the itineraries
+	err := database.ClearJudgeSchedules(state.Db, ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error clearing itineraries: " + err.Error()})
+		return
+	}
+
+	// Send OK
+	state.Logger.AdminLogf("Cleared all itineraries")
+	ctx.JSON(http.StatusOK, gin.H{"ok": 1})
+}
+
+// GET /admin/export/schedule - ExportSchedule exports the itinerary of the given judge as a CSV or PDF file,
+// or the itineraries of all judges as a zip file of CSV or PDF files if no judge is given
+func ExportSchedule(ctx *gin.Context) {
+	// Get the state from the context
+	state := GetState(ctx)
+
+	// Check the format
+	format := ctx.DefaultQuery("format", "csv")
+	if format != "csv" && format != "pdf" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid format, must be csv or pdf"})
+		return
+	}
+
+	// Get options
+	op, err := database.GetOptions(state.Db, ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error getting options: " + err.Error()})
+		return
+	}
+
+	// Export a single judge's itinerary if a judge is given
+	if rawId := ctx.Query("judge"); rawId != "" {
+		judgeId, err := primitive.ObjectIDFromHex(rawId)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid judge ID"})
+			return
+		}
+		judge, err := database.FindJudge(state.Db, ctx, judgeId)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error finding judge: " + err.Error()})
+			return
+		}
+		if judge == nil {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "judge not found"})
+			return
+		}
+
+		state.Logger.AdminLogf("Exported itinerary of judge %s to %s", judge.Name, strings.ToUpper(format))
+		if format == "pdf" {
+			funcs.AddPdfFile(funcs.ItineraryFileName(judge), funcs.CreateItineraryPDF(judge, op), ctx)
+			return
+		}
+		funcs.AddCsvData(funcs.ItineraryFileName(judge), funcs.CreateItineraryCSV(judge, op), ctx)
+		return
+	}
+
+	// Otherwise, zip up the itineraries of all judges
+	judges, err := database.FindAllJudges(state.Db, ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error getting judges: " + err.Error()})
+		return
+	}
+	zipData, err := funcs.CreateItineraryZip(judges, op, format)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error creating zip file: " + err.Error()})
+		return
+	}
+
+	// Send zip file
+	state.Logger.AdminLogf("Exported all itineraries to %s", strings.ToUpper(format))
+	funcs.AddZipFile("itineraries", zipData, ctx)
+}
@@ -3,10 +3,12 @@ package router
 import (
 	"context"
 	"log"
+	"server/config"
 	"server/database"
 	"server/judging"
 	"server/logging"
 	"server/models"
+	"strings"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-contrib/static"
@@ -19,8 +21,15 @@ func NewRouter(db *mongo.Database, logger *logging.Logger) *gin.Engine {
 	// Create the router
 	router := gin.Default()
 
+	// Check if other instances of the server share the database
+	replica := NewReplica(config.MultiInstance())
+	if replica.Shared {
+		logger.Shared = true
+		logger.SystemLogf("Started instance %s, sharing the database with other instances", replica.Id)
+	}
+
 	// Get the clock state from the database
-	clock := getClockFromDb(db)
+	clock := getClockFromDb(db, replica.Shared)
 
 	// Create the comparisons object
 	comps, err := judging.LoadComparisons(db)
@@ -36,11 +45,29 @@ func NewRouter(db *mongo.Database, logger *logging.Logger) *gin.Engine {
 
 	// Get the limiter from the database
 	limiter := getLimiterFromDb(db)
+	teams := CreateLimiter(maxTeamCodeFailures, false)
+	if replica.Shared {
+		limiter.Db = db
+		teams.Db = db
+	}
+
+	// Make sure no two projects can have the same team code.
+	// This fails if projects from before the index already share a code, which shouldn't stop the server.
+	err = database.EnsureTeamCodeIndex(db, context.Background())
+	if err != nil {
+		logger.SystemLogf("Error creating team code index: %s", err.Error())
+	}
 
 	// Add shared variables to router
-	state := NewState(db, clock, comps, logger, limiter)
+	state := NewState(db, clock, comps, logger, limiter, teams, replica)
 	router.Use(useVar("state", state))
 
+	// Keep the shared state in sync with the other instances
+	startSync(state)
+
+	// Release projects held by judges past their lease
+	startLeaseSweeper(state)
+
 	// CORS
 	router.Use(cors.New(cors.Config{
 		AllowAllOrigins:  true,
@@ -52,7 +79,7 @@ func NewRouter(db *mongo.Database, logger *logging.Logger) *gin.Engine {
 	}))
 
 	// Rate limit login requests
-	router.Use(rateLimit(limiter))
+	router.Use(rateLimit(limiter, logger))
 
 	// Create router groups for judge and admins
 	// This grouping allows us to add middleware to all routes in the group
@@ -96,13 +123,32 @@ func NewRouter(db *mongo.Database, logger *logging.Logger) *gin.Engine {
 	adminRouter.GET("/project/list", ListProjects)
 	adminRouter.DELETE("/project/:id", DeleteProject)
 	adminRouter.PUT("/project/:id", EditProject)
+	adminRouter.GET("/project/affiliations/:id", GetProjectAffiliations)
+	adminRouter.PUT("/project/affiliations/:id", SetProjectAffiliations)
+	adminRouter.PUT("/project/tags/:id", SetProjectTags)
+	adminRouter.PUT("/project/away/:id", SetProjectAway)
+	adminRouter.POST("/project/team-codes", GenerateTeamCodes)
+	adminRouter.PUT("/project/back/:id", MarkProjectBack)
+	adminRouter.GET("/project/absences/:id", GetProjectAbsences)
 
 	// Admin panel - stats/data
 	adminRouter.GET("/admin/stats", GetAdminStats)
 	adminRouter.GET("/admin/stats/:track", GetAdminTrackStats)
 	adminRouter.GET("/project/stats", ProjectStats)
 	adminRouter.GET("/judge/stats", JudgeStats)
+	adminRouter.GET("/judge/agreement", GetJudgeAgreement)
 	adminRouter.GET("/admin/flags", GetFlags)
+	adminRouter.GET("/admin/results/bradley-terry", GetBradleyTerryResults)
+	adminRouter.GET("/admin/results/explain/:id", ExplainProjectScore)
+	adminRouter.POST("/admin/snapshots", TakeSnapshot)
+	adminRouter.GET("/admin/snapshots", ListSnapshots)
+	adminRouter.GET("/admin/snapshots/diff", DiffSnapshots)
+	adminRouter.GET("/admin/snapshots/:id", GetSnapshot)
+	adminRouter.GET("/admin/rounds", ListRounds)
+	adminRouter.POST("/admin/rounds/close", CloseRound)
+	adminRouter.GET("/admin/schedule", GetSchedule)
+	adminRouter.POST("/admin/schedule", GenerateSchedule)
+	adminRouter.DELETE("/admin/schedule", ClearSchedule)
 
 	// Admin panel - clock
 	adminRouter.GET("/admin/clock", GetClock)
@@ -126,12 +172,17 @@ func NewRouter(db *mongo.Database, logger *logging.Logger) *gin.Engine {
 	adminRouter.POST("/admin/group-sizes", SetGroupSizes)
 	adminRouter.POST("/admin/block-reqs", SetBlockReqs)
 	adminRouter.POST("/admin/max-reqs", SetMaxReqs)
+	adminRouter.POST("/admin/floor-plan", SetFloorPlan)
 
 	// Admin panel - exports
 	adminRouter.GET("/admin/export/judges", ExportJudges)
 	adminRouter.GET("/admin/export/projects", ExportProjects)
 	adminRouter.GET("/admin/export/challenges", ExportProjectsByChallenge)
 	adminRouter.GET("/admin/export/rankings", ExportRankings)
+	adminRouter.GET("/admin/export/rankings/compare", ExportRankingComparison)
+	adminRouter.GET("/admin/export/pairwise", ExportPairwise)
+	adminRouter.GET("/admin/export/snapshot/:id", ExportSnapshot)
+	adminRouter.GET("/admin/export/schedule", ExportSchedule)
 
 	// Admin panel - table actions
 	adminRouter.PUT("/judge/hide/:id", HideJudge)
@@ -142,7 +193,19 @@ func NewRouter(db *mongo.Database, logger *logging.Logger) *gin.Engine {
 	adminRouter.POST("/judge/hide", HideSelectedJudges)
 	adminRouter.PUT("/judge/move/group/:id", MoveJudge)
 	adminRouter.POST("/judge/move/group", MoveSelectedJudges)
+	adminRouter.POST("/judge/move/round", MoveSelectedJudgesRound)
+	adminRouter.PUT("/judge/weight/:id", SetJudgeWeight)
+	adminRouter.PUT("/judge/weight/auto/:id", ResetJudgeWeight)
+	adminRouter.POST("/judge/calibrate", CalibrateJudges)
+	adminRouter.GET("/judge/calibration/:id", GetJudgeCalibration)
 	adminRouter.DELETE("/admin/flag/:id", RemoveFlag)
+	adminRouter.GET("/admin/conflicts", GetConflicts)
+	adminRouter.POST("/admin/conflicts", AddConflict)
+	adminRouter.POST("/admin/conflicts/match", MatchConflicts)
+	adminRouter.DELETE("/admin/conflicts/:id", RemoveConflict)
+	adminRouter.PUT("/judge/affiliations/:id", SetJudgeAffiliations)
+	adminRouter.PUT("/judge/tags/:id", SetJudgeTags)
+	adminRouter.PUT("/judge/quota/:id", SetJudgeQuota)
 	adminRouter.PUT("/project/move/:id", MoveProject)
 	adminRouter.PUT("/project/move/group/:id", MoveProjectGroup)
 	adminRouter.POST("/project/move/group", MoveSelectedProjectsGroup)
@@ -159,6 +222,7 @@ func NewRouter(db *mongo.Database, logger *logging.Logger) *gin.Engine {
 	judgeRouter.POST("/judge/next", GetNextJudgeProject)
 	judgeRouter.POST("/judge/skip", JudgeSkip)
 	judgeRouter.POST("/judge/finish", JudgeFinish)
+	judgeRouter.POST("/judge/heartbeat", JudgeHeartbeat)
 	judgeRouter.POST("/judge/rank", JudgeRank)
 	judgeRouter.PUT("/judge/star/:id", JudgeStar)
 	judgeRouter.PUT("/judge/notes/:id", JudgeUpdateNotes)
@@ -166,12 +230,20 @@ func NewRouter(db *mongo.Database, logger *logging.Logger) *gin.Engine {
 	judgeRouter.GET("/project/count", GetProjectCount)
 	judgeRouter.GET("/judge/project/:id", GetJudgedProject)
 	judgeRouter.GET("/judge/deliberation", GetDeliberationStatus)
+	judgeRouter.GET("/judge/criteria", GetCriteria)
+	judgeRouter.GET("/judge/schedule", GetJudgeSchedule)
 
 	// Project expo routes
 	defaultRouter.GET("/project/list/public", ListPublicProjects)
+	defaultRouter.GET("/results", ListPublicResults)
 	defaultRouter.GET("/challenges", GetChallenges)
 	defaultRouter.GET("/group-info", GetGroupInfo)
 
+	// Team routes
+	defaultRouter.POST("/team/project", GetTeamProject)
+	defaultRouter.PUT("/team/away", SetTeamAway)
+	defaultRouter.POST("/team/back", TeamBack)
+
 	// ######################
 	// ##### END ROUTES #####
 	// ######################
@@ -201,17 +273,28 @@ func useVar(key string, v any) gin.HandlerFunc {
 }
 
 // rateLimit is a middleware that limits the number of requests per minute
-// for the judge login endpoint
-func rateLimit(limiter *Limiter) gin.HandlerFunc {
+// for the judge login endpoint and the team routes. Each IP has a separate limit for each,
+// so teams checking their project can't lock judges on the same network out of logging in.
+func rateLimit(limiter *Limiter, logger *logging.Logger) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
-		// Check for /judge/login endpoint
-		if ctx.Request.URL.Path != "/api/judge/login" {
+		// Check for /judge/login endpoint and team endpoints, which are all authenticated by code
+		path := ctx.Request.URL.Path
+		if path != "/api/judge/login" && !strings.HasPrefix(path, "/api/team/") {
 			ctx.Next()
 			return
 		}
 
-		ip := ctx.ClientIP()
-		if !limiter.CheckNewRequest(ip) {
+		key := ctx.ClientIP()
+		if strings.HasPrefix(path, "/api/team/") {
+			key = "team|" + key
+		}
+		ok, err := limiter.CheckNewRequest(key)
+		if err != nil {
+			logger.SystemLogf("Error counting request from %s for the rate limit: %s", ctx.ClientIP(), err.Error())
+			ctx.AbortWithStatusJSON(503, gin.H{"error": "error checking rate limit: " + err.Error()})
+			return
+		}
+		if !ok {
 			ctx.AbortWithStatusJSON(429, gin.H{"error": "Too many requests. Logins have been blocked or rate limited."})
 			return
 		}
@@ -220,8 +303,9 @@ func rateLimit(limiter *Limiter) gin.HandlerFunc {
 }
 
 // getClockFromDb gets the clock state from the database.
-// If the clock sync option is not enabled, the clock will be 0.
-func getClockFromDb(db *mongo.Database) *models.SafeClock {
+// If the clock sync option is not enabled, the clock will be 0,
+// unless the clock is shared with other instances of the server.
+func getClockFromDb(db *mongo.Database, shared bool) *models.SafeClock {
 	// Get the clock state from the database
 	options, err := database.GetOptions(db, context.Background())
 	if err != nil {
@@ -230,7 +314,7 @@ func getClockFromDb(db *mongo.Database) *models.SafeClock {
 	clock := options.Clock
 
 	// If the sync clock option is not enabled, return 0 clock
-	if !options.ClockSync {
+	if !options.ClockSync && !shared {
 		return models.NewSafeClock(models.NewClockState())
 	}
 
@@ -8,6 +8,8 @@ import (
 	"server/judging"
 	"server/models"
 	"server/util"
+	"slices"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -18,6 +20,14 @@ import (
 func GetJudge(ctx *gin.Context) {
 	// Get the judge from the context (See middleware.go)
 	judge := ctx.MustGet("judge").(*models.Judge)
+	judge.Progress = judging.JudgeQuotaProgress(judge, GetState(ctx).Clock.GetDuration())
+
+	// Opening the app counts as activity on the current project, so renew its lease
+	err := judging.RenewLease(GetState(ctx).Db, ctx, judge)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error renewing lease: " + err.Error()})
+		return
+	}
 
 	// Send Judge
 	ctx.JSON(http.StatusOK, judge)
@@ -308,6 +318,12 @@ func ListJudges(ctx *gin.Context) {
 		return
 	}
 
+	// Calculate the progress of each judge toward their quota
+	clock := state.Clock.GetDuration()
+	for _, judge := range judges {
+		judge.Progress = judging.JudgeQuotaProgress(judge, clock)
+	}
+
 	// Send OK
 	ctx.JSON(http.StatusOK, judges)
 }
@@ -328,6 +344,23 @@ func JudgeStats(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, stats)
 }
 
+// GET /judge/agreement - Endpoint to get how closely each judge agrees with the other judges.
+// Use ?track= to analyze a track's judges instead of the general judges.
+func GetJudgeAgreement(ctx *gin.Context) {
+	// Get the state from the context
+	state := GetState(ctx)
+
+	// Get the judges of the track (general judges if no track is given)
+	judges, err := database.FindJudgesByTrack(state.Db, ctx, ctx.Query("track"))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error getting judges: " + err.Error()})
+		return
+	}
+
+	// Send OK
+	ctx.JSON(http.StatusOK, judging.AnalyzeAgreement(judges))
+}
+
 // DELETE /judge/:id - Endpoint to delete a judge
 func DeleteJudge(ctx *gin.Context) {
 	// Get the state from the context
@@ -344,6 +377,7 @@ func DeleteJudge(ctx *gin.Context) {
 	}
 
 	// Run in transaction
+	var removedPairs []*models.Comparison
 	err = database.WithTransaction(state.Db, func(sc mongo.SessionContext) error {
 		// Get judge data
 		judge, err := database.FindJudge(state.Db, sc, judgeObjectId)
@@ -366,6 +400,14 @@ func DeleteJudge(ctx *gin.Context) {
 			return err
 		}
 
+		// Take the judge's pairs of seen projects out of the comparisons
+		removedPairs = judging.JudgeComparisons(judge, -1)
+		err = database.IncrementComparisons(state.Db, sc, removedPairs)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error updating comparisons: " + err.Error()})
+			return err
+		}
+
 		// Delete all flags for judge
 		err = database.DeleteFlagsCascade(state.Db, sc, nil, &judgeObjectId)
 		if err != nil {
@@ -373,11 +415,20 @@ func DeleteJudge(ctx *gin.Context) {
 			return err
 		}
 
+		// Delete all conflicts for judge
+		err = database.DeleteConflictsCascade(state.Db, sc, nil, &judgeObjectId)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error deleting conflicts for judge: " + err.Error()})
+			return err
+		}
+
 		return nil
 	})
 	if err != nil {
 		return
 	}
+	state.Comps.ApplyComparisons(removedPairs)
+	state.Comps.Strengths.Invalidate()
 
 	// Send OK
 	state.Logger.AdminLogf("Deleted judge %s", judgeId)
@@ -392,12 +443,24 @@ func GetNextJudgeProject(ctx *gin.Context) {
 	// Get the judge from the context
 	judge := ctx.MustGet("judge").(*models.Judge)
 
-	// If the judge already has a next project, return that project
+	// If the judge already has a next project, renew the lease and return that project
 	if judge.Current != nil {
+		err := judging.RenewLease(state.Db, ctx, judge)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error renewing lease: " + err.Error()})
+			return
+		}
+
 		ctx.JSON(http.StatusOK, gin.H{"project_id": judge.Current.Hex()})
 		return
 	}
 
+	// If the judge has reached their quota, tell them to wrap up and rank instead
+	if judging.QuotaReached(judge, state.Clock.GetDuration()) {
+		ctx.JSON(http.StatusOK, gin.H{"wrap_up": true})
+		return
+	}
+
 	// Otherwise, get the next project for the judge
 	err := database.WithTransaction(state.Db, func(sc mongo.SessionContext) error {
 		// Get options
@@ -409,12 +472,13 @@ func GetNextJudgeProject(ctx *gin.Context) {
 		// If the clock is paused, return an empty object
 		// This is to ensure that no projects are gotten if the clock is paused
 		state.Clock.Mutex.Lock()
-		if !state.Clock.State.Running || options.Deliberation {
+		running := state.Clock.State.Running
+		state.Clock.Mutex.Unlock()
+		if !running || options.Deliberation {
 			return nil
 		}
-		state.Clock.Mutex.Unlock()
 
-		project, err := judging.PickNextProject(state.Db, sc, judge, state.Comps)
+		project, err := judging.PickNextProject(judging.NewDbStore(state.Db), sc, judge, state.Comps, state.Clock.GetDuration())
 		if err != nil {
 			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error picking next project: " + err.Error()})
 			return nil
@@ -427,7 +491,7 @@ func GetNextJudgeProject(ctx *gin.Context) {
 		}
 
 		// Update judge and project
-		err = database.UpdateAfterPicked(state.Db, sc, project, judge)
+		err = database.UpdateAfterPicked(state.Db, sc, project, judge, judging.LeaseExpiry(options, time.Now()), state.Clock.GetDuration())
 		if err != nil {
 			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error updating next project in database: " + err.Error()})
 			return nil
@@ -540,7 +604,7 @@ func JudgeSkip(ctx *gin.Context) {
 		state.Clock.Mutex.Unlock()
 
 		// Skip the project
-		err = judging.SkipCurrentProjectWithTx(state.Db, sc, judge, state.Comps, skipReq.Reason, newProj)
+		err = judging.SkipCurrentProjectWithTx(state.Db, sc, judge, state.Comps, skipReq.Reason, newProj, state.Clock.GetDuration())
 		if err != nil {
 			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return err
@@ -664,8 +728,9 @@ func EditJudge(ctx *gin.Context) {
 }
 
 type JudgeScoreRequest struct {
-	Notes   string `json:"notes"`
-	Starred bool   `json:"starred"`
+	Notes   string           `json:"notes"`
+	Starred bool             `json:"starred"`
+	Scores  map[string]int64 `json:"scores"` // Rubric score for each criterion (optional)
 }
 
 // POST /judge/finish - Endpoint to finish judging a project
@@ -684,7 +749,14 @@ func JudgeFinish(ctx *gin.Context) {
 		return
 	}
 
+	// The project may have been released for other judges if the lease expired
+	if judge.Current == nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "judge has no current project, it may have been released after the lease expired"})
+		return
+	}
+
 	// Run remaining actions in a transaction
+	var seenPairs []*models.Comparison
 	err = database.WithTransaction(state.Db, func(sc mongo.SessionContext) error {
 		// Get the options and return error if deliberations
 		options, err := database.GetOptions(state.Db, ctx)
@@ -697,6 +769,13 @@ func JudgeFinish(ctx *gin.Context) {
 			return err
 		}
 
+		// Make sure the rubric scores are valid
+		err = models.ValidateCriteriaScores(options.Criteria, scoreReq.Scores)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid rubric scores: " + err.Error()})
+			return err
+		}
+
 		// Get the project from the database
 		project, err := database.FindProject(state.Db, sc, judge.Current)
 		if err != nil {
@@ -706,10 +785,13 @@ func JudgeFinish(ctx *gin.Context) {
 
 		// Create the judged project object
 		judgedProject := models.JudgeProjectFromProject(project, scoreReq.Notes, scoreReq.Starred)
+		if scoreReq.Scores != nil {
+			judgedProject.Scores = scoreReq.Scores
+		}
 
 		// If groups are enabled and auto switch, move the judge to the next group conditionally
 		if options.MultiGroup && options.SwitchingMode == "auto" {
-			err = judging.MoveJudgeGroup(state.Db, sc, judge, options)
+			err = judging.MoveJudgeGroup(judging.NewDbStore(state.Db), sc, judge, options)
 			if err != nil {
 				ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error moving judge group: " + err.Error()})
 				return err
@@ -723,6 +805,14 @@ func JudgeFinish(ctx *gin.Context) {
 			return err
 		}
 
+		// Count the new project as compared with each project the judge has already seen
+		seenPairs = judging.SeenComparisons(judge.SeenProjects, project.Id, 1)
+		err = database.IncrementComparisons(state.Db, sc, seenPairs)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error updating comparisons: " + err.Error()})
+			return err
+		}
+
 		// Reset list of skipped projects due to busy status
 		err = database.ResetBusyProjectListForJudge(state.Db, sc, judge)
 		if err != nil {
@@ -735,6 +825,7 @@ func JudgeFinish(ctx *gin.Context) {
 	if err != nil {
 		return
 	}
+	state.Comps.ApplyComparisons(seenPairs)
 
 	// Send OK
 	starred := ""
@@ -747,7 +838,8 @@ func JudgeFinish(ctx *gin.Context) {
 }
 
 type RankRequest struct {
-	Ranking []primitive.ObjectID `json:"ranking"`
+	Ranking []primitive.ObjectID   `json:"ranking"`
+	Tiers   [][]primitive.ObjectID `json:"tiers"` // If given, projects in the same tier are tied and ranking is ignored
 }
 
 // POST /judge/rank - Update the judge's ranking of projects
@@ -766,6 +858,32 @@ func JudgeRank(ctx *gin.Context) {
 		return
 	}
 
+	// Get the tiers, treating a plain ranking as one project per tier
+	tiers := make([][]primitive.ObjectID, 0, len(rankReq.Ranking))
+	if rankReq.Tiers != nil {
+		for _, tier := range rankReq.Tiers {
+			if len(tier) > 0 {
+				tiers = append(tiers, tier)
+			}
+		}
+	} else {
+		for _, id := range rankReq.Ranking {
+			tiers = append(tiers, []primitive.ObjectID{id})
+		}
+	}
+
+	// Make sure no project is ranked twice
+	ranking := util.FlattenTiers(tiers)
+	for i, id := range ranking {
+		if slices.Contains(ranking[i+1:], id) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "project " + id.Hex() + " is ranked more than once"})
+			return
+		}
+	}
+
+	// Keep the old rankings for the log
+	oldRanks := util.TiersToString(util.GetRankingTiers(judge))
+
 	// Wrap in transaction
 	err = database.WithTransaction(state.Db, func(sc mongo.SessionContext) error {
 		// Get the options and return error if deliberations
@@ -780,11 +898,12 @@ func JudgeRank(ctx *gin.Context) {
 		}
 
 		// Calculate updated rankings
-		judge.Rankings = rankReq.Ranking
-		agg := judging.AggregateRanking(judge)
+		judge.Rankings = ranking
+		judge.RankingTiers = tiers
+		agg := judging.AggregateRanking(judge, options.RankingMethod)
 
 		// Update the judge's ranking
-		err = database.UpdateJudgeRanking(state.Db, sc, judge.Id, judge.Rankings, agg)
+		err = database.UpdateJudgeRanking(state.Db, sc, judge.Id, judge.Rankings, judge.RankingTiers, agg)
 		if err != nil {
 			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error updating judge ranking in database: " + err.Error()})
 			return err
@@ -795,10 +914,10 @@ func JudgeRank(ctx *gin.Context) {
 	if err != nil {
 		return
 	}
+	state.Comps.Strengths.Invalidate()
 
 	// Send OK
-	oldRanks := util.RankingToString(judge.Rankings)
-	state.Logger.JudgeLogf(judge, "Updated rankings from %s to %s", oldRanks, util.RankingToString(rankReq.Ranking))
+	state.Logger.JudgeLogf(judge, "Updated rankings from %s to %s", oldRanks, util.TiersToString(tiers))
 	ctx.JSON(http.StatusOK, gin.H{"ok": 1})
 }
 
@@ -863,6 +982,13 @@ func JudgeStar(ctx *gin.Context) {
 		return
 	}
 
+	// Renew the lease on the current project, since the judge is still active
+	err = judging.RenewLease(state.Db, ctx, judge)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error renewing lease: " + err.Error()})
+		return
+	}
+
 	// Send OK
 	starred := "Removed"
 	if starReq.Starred {
@@ -917,10 +1043,36 @@ func JudgeUpdateNotes(ctx *gin.Context) {
 		return
 	}
 
+	// Renew the lease on the current project, since the judge is still active
+	err = judging.RenewLease(state.Db, ctx, judge)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error renewing lease: " + err.Error()})
+		return
+	}
+
 	// Send OK
 	ctx.JSON(http.StatusOK, gin.H{"ok": 1})
 }
 
+// POST /judge/heartbeat - Renews the lease on the judge's current project while they are judging it
+func JudgeHeartbeat(ctx *gin.Context) {
+	// Get the state from the context
+	state := GetState(ctx)
+
+	// Get the judge from the context
+	judge := ctx.MustGet("judge").(*models.Judge)
+
+	// Renew the lease
+	err := judging.RenewLease(state.Db, ctx, judge)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error renewing lease: " + err.Error()})
+		return
+	}
+
+	// Send whether the judge still has a current project
+	ctx.JSON(http.StatusOK, gin.H{"ok": 1, "current": judge.Current != nil})
+}
+
 // POST /judge/reassign - Reassigns judge numbers to all judges that are not in a track
 func ReassignJudgeGroups(ctx *gin.Context) {
 	// Get the state from the context
@@ -1018,6 +1170,304 @@ func MoveSelectedJudges(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, gin.H{"ok": 1})
 }
 
+type MoveJudgesRoundRequest struct {
+	Items []primitive.ObjectID `json:"items"`
+	Round int64                `json:"round"`
+}
+
+// POST /judge/move/round - Move selected judges to the pool of a round (0 = whichever round is current)
+func MoveSelectedJudgesRound(ctx *gin.Context) {
+	// Get the state from the context
+	state := GetState(ctx)
+
+	// Get the request object
+	var moveReq MoveJudgesRoundRequest
+	err := ctx.BindJSON(&moveReq)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "error reading request body: " + err.Error()})
+		return
+	}
+
+	// Round must not be negative
+	if moveReq.Round < 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "round must not be negative"})
+		return
+	}
+
+	// Move the judges to the round
+	err = database.SetJudgesRound(state.Db, ctx, moveReq.Items, moveReq.Round)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error moving judges round: " + err.Error()})
+		return
+	}
+
+	// Send OK
+	state.Logger.AdminLogf("Moved %d judges to round %d", len(moveReq.Items), moveReq.Round)
+	ctx.JSON(http.StatusOK, gin.H{"ok": 1})
+}
+
+type SetJudgeWeightRequest struct {
+	Weight float64 `json:"weight"`
+}
+
+// PUT /judge/weight/:id - Manually set the weight of a judge's rankings
+func SetJudgeWeight(ctx *gin.Context) {
+	// Get the state from the context
+	state := GetState(ctx)
+
+	// Get the ID from the URL
+	id := ctx.Param("id")
+
+	// Get the request object
+	var weightReq SetJudgeWeightRequest
+	err := ctx.BindJSON(&weightReq)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "error reading request body: " + err.Error()})
+		return
+	}
+
+	// Weights must be positive (hide the judge instead to ignore their rankings)
+	if weightReq.Weight <= 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "weight must be greater than 0"})
+		return
+	}
+
+	// Convert ID string to ObjectID
+	judgeObjectId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid judge ID"})
+		return
+	}
+
+	// Set the weight
+	err = database.SetJudgeWeight(state.Db, ctx, &judgeObjectId, weightReq.Weight, true)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error setting judge weight: " + err.Error()})
+		return
+	}
+
+	// Send OK
+	state.Logger.AdminLogf("Set weight of judge %s to %.2f", id, weightReq.Weight)
+	ctx.JSON(http.StatusOK, gin.H{"ok": 1})
+}
+
+type SetJudgeAffiliationsRequest struct {
+	Affiliations []string `json:"affiliations"`
+}
+
+// PUT /judge/affiliations/:id - Set the affiliations (e.g. universities or companies) of a judge,
+// used to match conflicts of interest
+func SetJudgeAffiliations(ctx *gin.Context) {
+	// Get the state from the context
+	state := GetState(ctx)
+
+	// Get the ID from the URL
+	id := ctx.Param("id")
+
+	// Get the request object
+	var affReq SetJudgeAffiliationsRequest
+	err := ctx.BindJSON(&affReq)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "error reading request body: " + err.Error()})
+		return
+	}
+	if affReq.Affiliations == nil {
+		affReq.Affiliations = []string{}
+	}
+
+	// Convert ID string to ObjectID
+	judgeObjectId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid judge ID"})
+		return
+	}
+
+	// Set the affiliations
+	err = database.SetJudgeAffiliations(state.Db, ctx, &judgeObjectId, affReq.Affiliations)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error setting judge affiliations: " + err.Error()})
+		return
+	}
+
+	// Send OK
+	state.Logger.AdminLogf("Set affiliations of judge %s to %v", id, affReq.Affiliations)
+	ctx.JSON(http.StatusOK, gin.H{"ok": 1})
+}
+
+type SetTagsRequest struct {
+	Tags []string `json:"tags"`
+}
+
+// PUT /judge/tags/:id - Set the expertise tags of a judge (e.g. hardware, ML, design),
+// used to prefer projects with matching tags
+func SetJudgeTags(ctx *gin.Context) {
+	// Get the state from the context
+	state := GetState(ctx)
+
+	// Get the ID from the URL
+	id := ctx.Param("id")
+
+	// Get the request object
+	var tagsReq SetTagsRequest
+	err := ctx.BindJSON(&tagsReq)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "error reading request body: " + err.Error()})
+		return
+	}
+	tags := models.NormalizeTags(tagsReq.Tags)
+
+	// Convert ID string to ObjectID
+	judgeObjectId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid judge ID"})
+		return
+	}
+
+	// Set the tags
+	err = database.SetJudgeTags(state.Db, ctx, &judgeObjectId, tags)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error setting judge tags: " + err.Error()})
+		return
+	}
+
+	// Send OK
+	state.Logger.AdminLogf("Set tags of judge %s to %v", id, tags)
+	ctx.JSON(http.StatusOK, gin.H{"ok": 1})
+}
+
+type SetJudgeQuotaRequest struct {
+	Quota      int64 `json:"quota"`
+	TimeBudget int64 `json:"time_budget"`
+}
+
+// PUT /judge/quota/:id - Set the maximum number of projects and the time budget (in minutes)
+// of a judge for the round, where 0 means no limit
+func SetJudgeQuota(ctx *gin.Context) {
+	// Get the state from the context
+	state := GetState(ctx)
+
+	// Get the ID from the URL
+	id := ctx.Param("id")
+
+	// Get the request object
+	var quotaReq SetJudgeQuotaRequest
+	err := ctx.BindJSON(&quotaReq)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "error reading request body: " + err.Error()})
+		return
+	}
+	if quotaReq.Quota < 0 || quotaReq.TimeBudget < 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "quota and time budget cannot be negative"})
+		return
+	}
+
+	// Convert ID string to ObjectID
+	judgeObjectId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid judge ID"})
+		return
+	}
+
+	// Set the quota
+	err = database.SetJudgeQuota(state.Db, ctx, &judgeObjectId, quotaReq.Quota, quotaReq.TimeBudget)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error setting judge quota: " + err.Error()})
+		return
+	}
+
+	// Send OK
+	state.Logger.AdminLogf("Set quota of judge %s to %d projects and %d minutes", id, quotaReq.Quota, quotaReq.TimeBudget)
+	ctx.JSON(http.StatusOK, gin.H{"ok": 1})
+}
+
+// PUT /judge/weight/auto/:id - Clear the manual weight of a judge, going back to the default weight
+// until judges are recalibrated
+func ResetJudgeWeight(ctx *gin.Context) {
+	// Get the state from the context
+	state := GetState(ctx)
+
+	// Get the ID from the URL
+	id := ctx.Param("id")
+
+	// Convert ID string to ObjectID
+	judgeObjectId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid judge ID"})
+		return
+	}
+
+	// Clear the manual weight
+	err = database.SetJudgeWeight(state.Db, ctx, &judgeObjectId, 1, false)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error resetting judge weight: " + err.Error()})
+		return
+	}
+
+	// Send OK
+	state.Logger.AdminLogf("Reset weight of judge %s to default", id)
+	ctx.JSON(http.StatusOK, gin.H{"ok": 1})
+}
+
+// POST /judge/calibrate - Recalculate the automatic weights of all judges from their agreement with the consensus
+func CalibrateJudges(ctx *gin.Context) {
+	// Get the state from the context
+	state := GetState(ctx)
+
+	// Recalibrate all judges
+	err := judging.RecalibrateJudgeWeights(state.Db)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error calibrating judge weights: " + err.Error()})
+		return
+	}
+
+	// Send OK
+	state.Logger.AdminLogf("Recalibrated judge weights")
+	ctx.JSON(http.StatusOK, gin.H{"ok": 1})
+}
+
+type JudgeCalibrationResponse struct {
+	Weight       float64                 `json:"weight"`
+	WeightManual bool                    `json:"weight_manual"`
+	Calibration  models.JudgeCalibration `json:"calibration"`
+}
+
+// GET /judge/calibration/:id - Get the weight of a judge and an explanation of how it was calculated
+func GetJudgeCalibration(ctx *gin.Context) {
+	// Get the state from the context
+	state := GetState(ctx)
+
+	// Convert ID string to ObjectID
+	judgeObjectId, err := primitive.ObjectIDFromHex(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid judge ID"})
+		return
+	}
+
+	// Get the judge
+	judge, err := database.FindJudge(state.Db, ctx, judgeObjectId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error finding judge: " + err.Error()})
+		return
+	}
+	if judge == nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "judge not found"})
+		return
+	}
+
+	// Manual weights override the calibration, so say so
+	calibration := judge.Calibration
+	if judge.WeightManual {
+		calibration.Reason = "Weight was set manually by an admin. " + calibration.Reason
+	}
+
+	// Send OK
+	ctx.JSON(http.StatusOK, JudgeCalibrationResponse{
+		Weight:       judging.JudgeWeight(judge),
+		WeightManual: judge.WeightManual,
+		Calibration:  calibration,
+	})
+}
+
 type AddJudgeFromQRRequest struct {
 	Code  string `json:"code"`
 	Name  string `json:"name"`
@@ -1141,3 +1591,73 @@ func GetDeliberationStatus(ctx *gin.Context) {
 		ctx.JSON(http.StatusOK, gin.H{"ok": 0})
 	}
 }
+
+// GET /judge/criteria - Get the rubric criteria to score each project on
+func GetCriteria(ctx *gin.Context) {
+	// Get the state from the context
+	state := GetState(ctx)
+
+	// Get the options from the database
+	options, err := database.GetOptions(state.Db, ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error getting options: " + err.Error()})
+		return
+	}
+
+	// Databases from before rubric scoring have no criteria
+	criteria := options.Criteria
+	if criteria == nil {
+		criteria = []models.Criterion{}
+	}
+
+	// Send OK
+	ctx.JSON(http.StatusOK, criteria)
+}
+
+// GET /judge/schedule - GetJudgeSchedule returns the judge's itinerary and the next stop on it,
+// or the itinerary as a printable CSV or PDF file (?format=csv or ?format=pdf)
+func GetJudgeSchedule(ctx *gin.Context) {
+	// Get the state from the context
+	state := GetState(ctx)
+
+	// Get the judge from the context
+	judge := ctx.MustGet("judge").(*models.Judge)
+
+	// Check the format
+	format := ctx.DefaultQuery("format", "json")
+	if format != "json" && format != "csv" && format != "pdf" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid format, must be json, csv, or pdf"})
+		return
+	}
+
+	// Get options
+	op, err := database.GetOptions(state.Db, ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error getting options: " + err.Error()})
+		return
+	}
+
+	// Send the file if asked for one
+	if format == "pdf" {
+		funcs.AddPdfFile(funcs.ItineraryFileName(judge), funcs.CreateItineraryPDF(judge, op), ctx)
+		return
+	}
+	if format == "csv" {
+		funcs.AddCsvData(funcs.ItineraryFileName(judge), funcs.CreateItineraryCSV(judge, op), ctx)
+		return
+	}
+
+	// Find the next stop
+	next, err := judging.NextScheduledProject(judging.NewDbStore(state.Db), ctx, judge, op)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error finding next stop: " + err.Error()})
+		return
+	}
+	var nextId *primitive.ObjectID
+	if next != nil {
+		nextId = &next.Id
+	}
+
+	// Send OK
+	ctx.JSON(http.StatusOK, gin.H{"schedule_mode": op.ScheduleMode, "stops": judge.Schedule, "next": nextId})
+}
@@ -0,0 +1,74 @@
+package router
+
+import (
+	"context"
+	"server/database"
+	"server/judging"
+	"time"
+)
+
+// leaseSweepInterval is how often expired project leases are released
+const leaseSweepInterval = 30 * time.Second
+
+// startLeaseSweeper releases the projects of judges whose leases have expired (see judging.ReleaseExpiredLeases)
+// in the background for as long as the server runs. Without this, a judge that closes the app without
+// finishing or skipping their project keeps it busy forever. Only the leader sweeps when running multiple instances.
+// Nothing is released while the clock is paused, and all leases are renewed once it is resumed.
+func startLeaseSweeper(state *State) {
+	go func() {
+		ticker := time.NewTicker(leaseSweepInterval)
+		defer ticker.Stop()
+		paused := false
+		for range ticker.C {
+			if !state.Replica.IsLeader() {
+				continue
+			}
+
+			state.Clock.Mutex.Lock()
+			running := state.Clock.State.Running
+			state.Clock.Mutex.Unlock()
+
+			renew, sweep := sweepAction(running, paused)
+			paused = !running
+			if renew {
+				err := judging.RenewAllLeases(state.Db, context.Background())
+				if err != nil {
+					state.Logger.SystemLogf("Error renewing leases after the clock was resumed: %s", err.Error())
+					paused = true
+					continue
+				}
+			}
+			if sweep {
+				sweepLeases(state)
+			}
+		}
+	}()
+}
+
+// sweepAction decides what the lease sweeper does on a tick, given whether the clock is running
+// and whether it was paused on the previous tick: nothing while paused, and renewing every lease
+// before sweeping on the first tick after the clock is resumed
+func sweepAction(running bool, wasPaused bool) (renew bool, sweep bool) {
+	if !running {
+		return false, false
+	}
+	return wasPaused, true
+}
+
+// sweepLeases releases all expired leases once, logging each released project
+func sweepLeases(state *State) {
+	ctx := context.Background()
+	released, err := judging.ReleaseExpiredLeases(state.Db, ctx)
+	if err != nil {
+		state.Logger.SystemLogf("Error releasing expired leases: %s", err.Error())
+	}
+
+	for _, judge := range released {
+		name := judge.Current.Hex()
+		project, err := database.FindProject(state.Db, ctx, judge.Current)
+		if err == nil && project != nil {
+			name = project.Name
+		}
+		state.Logger.SystemLogf("Released project %s (%s) from judge %s after the lease expired", name, judge.Current.Hex(), judge.Name)
+	}
+}
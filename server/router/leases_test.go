@@ -0,0 +1,24 @@
+package router
+
+import "testing"
+
+func TestSweepAction(t *testing.T) {
+	tests := []struct {
+		name      string
+		running   bool
+		wasPaused bool
+		renew     bool
+		sweep     bool
+	}{
+		{"running", true, false, false, true},
+		{"paused", false, false, false, false},
+		{"still paused", false, true, false, false},
+		{"resumed", true, true, true, true},
+	}
+	for _, test := range tests {
+		renew, sweep := sweepAction(test.running, test.wasPaused)
+		if renew != test.renew || sweep != test.sweep {
+			t.Errorf("%s: expected renew %v and sweep %v, got %v and %v", test.name, test.renew, test.sweep, renew, sweep)
+		}
+	}
+}
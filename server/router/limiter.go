@@ -1,12 +1,21 @@
 package router
 
-import "time"
+import (
+	"context"
+	"server/database"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
 
 type Limiter struct {
-	MaxReqPerMin int            `json:"max_requests_per_minute"` // The maximum number of requests per minute, stored here and in the DB
-	IpMap        map[string]int `json:"ip_map"`                  // A map of IP addresses to the number of requests they have made
-	LastReset    int64          `json:"last_reset"`              // The last time the IP map was reset
-	Block        bool           `json:"block"`                   // Whether or not to completely block requests
+	MaxReqPerMin int             `json:"max_requests_per_minute"` // The maximum number of requests per minute, stored here and in the DB
+	IpMap        map[string]int  `json:"ip_map"`                  // A map of IP addresses to the number of requests they have made
+	LastReset    int64           `json:"last_reset"`              // The last time the IP map was reset
+	Block        bool            `json:"block"`                   // Whether or not to completely block requests
+	Db           *mongo.Database `json:"-"`                       // If set, requests are counted in the DB so the limit is shared by all instances
+	Mutex        sync.Mutex      `json:"-"`
 }
 
 // CreateLimiter creates a new Limiter struct
@@ -19,18 +28,43 @@ func CreateLimiter(maxReqPerMin int, block bool) *Limiter {
 	}
 }
 
-// CheckNewRequest checks if a new request is allowed
-func (l *Limiter) CheckNewRequest(ip string) bool {
+// Update updates the limits of the limiter
+func (l *Limiter) Update(maxReqPerMin int, block bool) {
+	l.Mutex.Lock()
+	defer l.Mutex.Unlock()
+
+	l.MaxReqPerMin = maxReqPerMin
+	l.Block = block
+}
+
+// CheckNewRequest checks if a new request is allowed.
+// If the limit is shared and the request can't be counted in the DB,
+// the request is denied and the error is returned.
+func (l *Limiter) CheckNewRequest(ip string) (bool, error) {
+	l.Mutex.Lock()
+
 	// Block all requests
 	if l.Block {
-		return false
+		l.Mutex.Unlock()
+		return false, nil
 	}
 
-	// If the IP map was last reset more than a minute ago, reset it
-	if time.Now().Unix()-l.LastReset > 60 {
-		l.IpMap = make(map[string]int)
-		l.LastReset = time.Now().Unix()
+	// Count the request in the DB if the limit is shared,
+	// without holding the lock so a slow DB doesn't stall every other request
+	if l.Db != nil {
+		db, max := l.Db, l.MaxReqPerMin
+		l.Mutex.Unlock()
+
+		count, err := database.CountRequest(db, context.Background(), ip, time.Now().Unix()/60)
+		if err != nil {
+			return false, err
+		}
+		return count <= int64(max), nil
 	}
+	defer l.Mutex.Unlock()
+
+	// If the IP map was last reset more than a minute ago, reset it
+	l.resetIfStale()
 
 	// Create entry in IpMap if not exists
 	if _, ok := l.IpMap[ip]; !ok {
@@ -39,10 +73,41 @@ func (l *Limiter) CheckNewRequest(ip string) bool {
 
 	// If IP has exceeded the max requests per min, block it
 	if l.IpMap[ip] >= l.MaxReqPerMin {
-		return false
+		return false, nil
 	}
 
 	// Increment the request count for the IP
 	l.IpMap[ip]++
-	return true
+	return true, nil
+}
+
+// Exceeded checks if an IP has already made the maximum number of requests this minute,
+// without counting a new request
+func (l *Limiter) Exceeded(ip string) (bool, error) {
+	l.Mutex.Lock()
+
+	// Read the count from the DB if the limit is shared
+	if l.Db != nil {
+		db, max := l.Db, l.MaxReqPerMin
+		l.Mutex.Unlock()
+
+		count, err := database.GetRequestCount(db, context.Background(), ip, time.Now().Unix()/60)
+		if err != nil {
+			return true, err
+		}
+		return count >= int64(max), nil
+	}
+	defer l.Mutex.Unlock()
+
+	l.resetIfStale()
+	return l.IpMap[ip] >= l.MaxReqPerMin, nil
+}
+
+// resetIfStale resets the IP map if it was last reset more than a minute ago.
+// The mutex MUST be held by the caller.
+func (l *Limiter) resetIfStale() {
+	if time.Now().Unix()-l.LastReset > 60 {
+		l.IpMap = make(map[string]int)
+		l.LastReset = time.Now().Unix()
+	}
 }
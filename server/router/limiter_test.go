@@ -0,0 +1,86 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestLimiter(t *testing.T) {
+	limiter := CreateLimiter(2, false)
+
+	// Each IP gets the maximum number of requests per minute
+	for i := 0; i < 2; i++ {
+		if ok, err := limiter.CheckNewRequest("1.1.1.1"); !ok || err != nil {
+			t.Fatalf("expected request %d to be allowed, got %v %v", i+1, ok, err)
+		}
+	}
+	if ok, _ := limiter.CheckNewRequest("1.1.1.1"); ok {
+		t.Errorf("expected the third request in a minute to be denied")
+	}
+	if ok, _ := limiter.CheckNewRequest("2.2.2.2"); !ok {
+		t.Errorf("expected other IPs to have their own limit")
+	}
+
+	// Checking whether an IP is over the limit doesn't count as a request
+	if exceeded, _ := limiter.Exceeded("3.3.3.3"); exceeded {
+		t.Errorf("expected an IP without requests not to be over the limit")
+	}
+	if exceeded, _ := limiter.Exceeded("1.1.1.1"); !exceeded {
+		t.Errorf("expected an IP that used up its requests to be over the limit")
+	}
+	if limiter.IpMap["3.3.3.3"] != 0 {
+		t.Errorf("expected checking the limit not to count a request")
+	}
+
+	// The counts reset after a minute
+	limiter.LastReset = time.Now().Add(-2 * time.Minute).Unix()
+	if ok, _ := limiter.CheckNewRequest("1.1.1.1"); !ok {
+		t.Errorf("expected requests to be allowed again after a minute")
+	}
+
+	// Blocking denies every request
+	limiter.Update(2, true)
+	if ok, _ := limiter.CheckNewRequest("4.4.4.4"); ok {
+		t.Errorf("expected requests to be denied while blocked")
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(rateLimit(CreateLimiter(1, false), nil))
+	router.POST("/api/judge/login", func(ctx *gin.Context) { ctx.JSON(http.StatusOK, gin.H{"ok": 1}) })
+	router.POST("/api/team/project", func(ctx *gin.Context) { ctx.JSON(http.StatusOK, gin.H{"ok": 1}) })
+	router.GET("/api/project/count", func(ctx *gin.Context) { ctx.JSON(http.StatusOK, gin.H{"ok": 1}) })
+
+	send := func(method string, path string) int {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+		return w.Code
+	}
+
+	// Logins and team routes each have their own limit for an IP
+	if code := send("POST", "/api/judge/login"); code != http.StatusOK {
+		t.Errorf("expected the first login to be allowed, got %d", code)
+	}
+	if code := send("POST", "/api/team/project"); code != http.StatusOK {
+		t.Errorf("expected team routes not to count towards the login limit, got %d", code)
+	}
+	if code := send("POST", "/api/judge/login"); code != http.StatusTooManyRequests {
+		t.Errorf("expected logins to be rate limited, got %d", code)
+	}
+	if code := send("POST", "/api/team/project"); code != http.StatusTooManyRequests {
+		t.Errorf("expected team routes to be rate limited, got %d", code)
+	}
+
+	// Other routes aren't rate limited
+	for i := 0; i < 3; i++ {
+		if code := send("GET", "/api/project/count"); code != http.StatusOK {
+			t.Errorf("expected other routes not to be rate limited, got %d", code)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package router
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
@@ -60,12 +61,6 @@ func AddDevpostCsv(ctx *gin.Context) {
 			return err
 		}
 
-		// Reload comparisons
-		err = judging.ReloadComparisons(state.Db, sc, state.Comps)
-		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error reloading comparisons: " + err.Error()})
-			return err
-		}
 		return nil
 	})
 	if err != nil {
@@ -146,13 +141,6 @@ func AddProject(ctx *gin.Context) {
 			return err
 		}
 
-		// Reload comparisons
-		err = judging.ReloadComparisons(state.Db, sc, state.Comps)
-		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error reloading comparisons: " + err.Error()})
-			return err
-		}
-
 		return nil
 	})
 	if err != nil {
@@ -189,9 +177,28 @@ func ListProjects(ctx *gin.Context) {
 			projects[i].Score = pScore.Score
 			projects[i].Stars = pScore.Stars
 			projects[i].TrackStars = pScore.TrackStars
+			projects[i].TrackScores = pScore.TrackScores
+			projects[i].RubricScores = pScore.RubricScores
+			projects[i].RubricTotal = pScore.RubricTotal
+			projects[i].TrackRubricTotals = pScore.TrackRubricTotals
 		}
 	}
 
+	// Apply the score formula
+	options, err := database.GetOptions(state.Db, ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error getting options: " + err.Error()})
+		return
+	}
+	combined := make(map[primitive.ObjectID]*judging.CombinedScore, len(projects))
+	for _, cs := range judging.ComputeCombinedScores(projects, scores, options.ScoreFormula, options.Criteria) {
+		combined[cs.ProjectId] = cs
+	}
+	for _, p := range projects {
+		p.Combined = combined[p.Id].Combined
+		p.Place = combined[p.Id].Place
+	}
+
 	// Send OK
 	ctx.JSON(http.StatusOK, projects)
 }
@@ -237,6 +244,55 @@ func ListPublicProjects(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, publicProjects)
 }
 
+type PublicResult struct {
+	Place    int64   `json:"place"`
+	Name     string  `json:"name"`
+	Location int64   `json:"location"`
+	Combined float64 `json:"combined"`
+}
+
+// GET /results - ListPublicResults returns the final standings of all active projects
+// under the score formula, once the admins have published the results
+func ListPublicResults(ctx *gin.Context) {
+	// Get the state from the context
+	state := GetState(ctx)
+
+	// Make sure the results have been published
+	options, err := database.GetOptions(state.Db, ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error getting options: " + err.Error()})
+		return
+	}
+	if !options.PublishResults {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "results have not been published"})
+		return
+	}
+
+	// Get the final standings
+	standings, err := getCombinedScores(state.Db, ctx, options)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error calculating combined scores: " + err.Error()})
+		return
+	}
+
+	// Convert standings to public results
+	results := make([]PublicResult, 0, len(standings))
+	for _, s := range standings {
+		if !s.Active {
+			continue
+		}
+		results = append(results, PublicResult{
+			Place:    s.Place,
+			Name:     s.Name,
+			Location: s.Location,
+			Combined: s.Combined,
+		})
+	}
+
+	// Send OK
+	ctx.JSON(http.StatusOK, gin.H{"formula": models.ScoreFormulaOrDefault(options.ScoreFormula), "results": results})
+}
+
 // POST /project/csv - Endpoint to add projects from a CSV file
 func AddProjectsCsv(ctx *gin.Context) {
 	// Get the state from the context
@@ -283,13 +339,6 @@ func AddProjectsCsv(ctx *gin.Context) {
 			return err
 		}
 
-		// Reload comparisons
-		err = judging.ReloadComparisons(state.Db, sc, state.Comps)
-		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error reloading comparisons: " + err.Error()})
-			return err
-		}
-
 		return nil
 	})
 	if err != nil {
@@ -344,6 +393,336 @@ func EditProject(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, gin.H{"ok": 1})
 }
 
+type ProjectAffiliations struct {
+	TeamEmails   []string `json:"team_emails"`
+	Universities []string `json:"universities"`
+}
+
+// GET /project/affiliations/:id - GetProjectAffiliations gets the team member emails and universities
+// of a project. These are left out of the project itself so that judges never see them.
+func GetProjectAffiliations(ctx *gin.Context) {
+	// Get the state from the context
+	state := GetState(ctx)
+
+	// Get the ID from the URL
+	id := ctx.Param("id")
+
+	// Convert ID string to ObjectID
+	projectObjectId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid project ID"})
+		return
+	}
+
+	// Get the project from the database
+	project, err := database.FindProject(state.Db, ctx, &projectObjectId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error getting project from database: " + err.Error()})
+		return
+	}
+	if project == nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "project not found"})
+		return
+	}
+
+	// Send the affiliations
+	ctx.JSON(http.StatusOK, ProjectAffiliations{TeamEmails: project.TeamEmails, Universities: project.Universities})
+}
+
+// PUT /project/affiliations/:id - SetProjectAffiliations sets the team member emails and universities
+// of a project, used to match conflicts of interest
+func SetProjectAffiliations(ctx *gin.Context) {
+	// Get the state from the context
+	state := GetState(ctx)
+
+	// Get the ID from the URL
+	id := ctx.Param("id")
+
+	// Get the request object
+	var affReq ProjectAffiliations
+	err := ctx.BindJSON(&affReq)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "error reading request body: " + err.Error()})
+		return
+	}
+	if affReq.TeamEmails == nil {
+		affReq.TeamEmails = []string{}
+	}
+	if affReq.Universities == nil {
+		affReq.Universities = []string{}
+	}
+
+	// Convert ID string to ObjectID
+	projectObjectId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid project ID"})
+		return
+	}
+
+	// Set the affiliations
+	err = database.SetProjectAffiliations(state.Db, ctx, &projectObjectId, affReq.TeamEmails, affReq.Universities)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error setting project affiliations: " + err.Error()})
+		return
+	}
+
+	// Send OK
+	state.Logger.AdminLogf("Set affiliations of project %s", id)
+	ctx.JSON(http.StatusOK, gin.H{"ok": 1})
+}
+
+// PUT /project/tags/:id - SetProjectTags sets the expertise tags of a project.
+// Projects are also tagged with the challenges they entered, so those don't need to be set here.
+func SetProjectTags(ctx *gin.Context) {
+	// Get the state from the context
+	state := GetState(ctx)
+
+	// Get the ID from the URL
+	id := ctx.Param("id")
+
+	// Get the request object
+	var tagsReq SetTagsRequest
+	err := ctx.BindJSON(&tagsReq)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "error reading request body: " + err.Error()})
+		return
+	}
+	tags := models.NormalizeTags(tagsReq.Tags)
+
+	// Convert ID string to ObjectID
+	projectObjectId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid project ID"})
+		return
+	}
+
+	// Set the tags
+	err = database.SetProjectTags(state.Db, ctx, &projectObjectId, tags)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error setting project tags: " + err.Error()})
+		return
+	}
+
+	// Send OK
+	state.Logger.AdminLogf("Set tags of project %s to %v", id, tags)
+	ctx.JSON(http.StatusOK, gin.H{"ok": 1})
+}
+
+type SetProjectAwayRequest struct {
+	Away []models.AwayWindow `json:"away"`
+}
+
+// PUT /project/away/:id - SetProjectAway replaces the times when a project's team is away from their table.
+// Windows without a source are set by the admin.
+func SetProjectAway(ctx *gin.Context) {
+	// Get the state from the context
+	state := GetState(ctx)
+
+	// Get the ID from the URL
+	id := ctx.Param("id")
+
+	// Get the request object
+	var awayReq SetProjectAwayRequest
+	err := ctx.BindJSON(&awayReq)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "error reading request body: " + err.Error()})
+		return
+	}
+	if awayReq.Away == nil {
+		awayReq.Away = []models.AwayWindow{}
+	}
+	for i := range awayReq.Away {
+		if awayReq.Away[i].Source == "" {
+			awayReq.Away[i].Source = "admin"
+		}
+	}
+	err = models.ValidateAwayWindows(awayReq.Away)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Convert ID string to ObjectID
+	projectObjectId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid project ID"})
+		return
+	}
+
+	// Set the away windows
+	err = database.SetProjectAway(state.Db, ctx, &projectObjectId, awayReq.Away)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error setting project away windows: " + err.Error()})
+		return
+	}
+
+	// Send OK
+	state.Logger.AdminLogf("Set %d away windows of project %s", len(awayReq.Away), id)
+	ctx.JSON(http.StatusOK, gin.H{"ok": 1})
+}
+
+// PUT /project/back/:id - MarkProjectBack marks a project as back after being absent,
+// unhiding and prioritizing it and resetting its absences
+func MarkProjectBack(ctx *gin.Context) {
+	// Get the state from the context
+	state := GetState(ctx)
+
+	// Get the ID from the URL
+	id := ctx.Param("id")
+
+	// Convert ID string to ObjectID
+	projectObjectId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid project ID"})
+		return
+	}
+
+	// Run in transaction
+	err = database.WithTransaction(state.Db, func(sc mongo.SessionContext) error {
+		project, err := database.FindProject(state.Db, sc, &projectObjectId)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error finding project: " + err.Error()})
+			return err
+		}
+
+		err = judging.MarkProjectBack(state.Db, sc, project, "admin")
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return
+	}
+
+	// Send OK
+	state.Logger.AdminLogf("Marked project %s as back", id)
+	ctx.JSON(http.StatusOK, gin.H{"ok": 1})
+}
+
+type ProjectAbsences struct {
+	Absent  int            `json:"absent"`  // Absences that count towards hiding the project
+	Limit   int64          `json:"limit"`   // Number of absences before the project is hidden
+	Hidden  bool           `json:"hidden"`  // Whether the project is hidden because it was absent
+	History []*models.Flag `json:"history"` // Every absent, hidden-absent, and back flag of the project, oldest first
+}
+
+// GET /project/absences/:id - GetProjectAbsences returns the absence history of a project
+func GetProjectAbsences(ctx *gin.Context) {
+	// Get the state from the context
+	state := GetState(ctx)
+
+	// Get the ID from the URL
+	id := ctx.Param("id")
+
+	// Convert ID string to ObjectID
+	projectObjectId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid project ID"})
+		return
+	}
+
+	// Get the project and options
+	project, err := database.FindProject(state.Db, ctx, &projectObjectId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error finding project: " + err.Error()})
+		return
+	}
+	options, err := database.GetOptions(state.Db, ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error getting options: " + err.Error()})
+		return
+	}
+
+	// Get the absences
+	absences, err := getProjectAbsences(ctx, state, project, options)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error getting absences: " + err.Error()})
+		return
+	}
+
+	// Send OK
+	ctx.JSON(http.StatusOK, absences)
+}
+
+// getProjectAbsences gets the current absences and absence history of a project
+func getProjectAbsences(ctx context.Context, state *State, project *models.Project, options *models.Options) (*ProjectAbsences, error) {
+	absent, err := database.GetProjectAbsentCount(state.Db, ctx, project)
+	if err != nil {
+		return nil, err
+	}
+	history, err := database.FindAbsenceFlags(state.Db, ctx, &project.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ProjectAbsences{
+		Absent:  absent,
+		Limit:   judging.AbsentLimit(options),
+		Hidden:  judging.HiddenForAbsence(project, history),
+		History: history,
+	}, nil
+}
+
+type TeamCode struct {
+	ProjectId primitive.ObjectID `json:"project_id"`
+	Name      string             `json:"name"`
+	Location  int64              `json:"location"`
+	TeamCode  string             `json:"team_code"`
+}
+
+// maxTeamCodeTries is the number of codes to try for a project before giving up
+const maxTeamCodeTries = 5
+
+// POST /project/team-codes - GenerateTeamCodes gives a team code to every project that doesn't have one yet
+// (e.g. projects added before team codes existed), then returns the codes of all projects
+// so they can be handed out to the teams
+func GenerateTeamCodes(ctx *gin.Context) {
+	// Get the state from the context
+	state := GetState(ctx)
+
+	// Get all projects
+	projects, err := database.FindAllProjects(state.Db, ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error getting projects from database: " + err.Error()})
+		return
+	}
+
+	// Generate missing codes
+	generated := 0
+	codes := make([]TeamCode, len(projects))
+	for i, project := range projects {
+		if project.TeamCode == "" {
+			// Try a new code if another project already has this one
+			for range maxTeamCodeTries {
+				project.TeamCode = models.RandTeamCode()
+				err = database.SetProjectTeamCode(state.Db, ctx, &project.Id, project.TeamCode)
+				if !mongo.IsDuplicateKeyError(err) {
+					break
+				}
+			}
+			if err != nil {
+				ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error setting project team code: " + err.Error()})
+				return
+			}
+			generated++
+		}
+		codes[i] = TeamCode{
+			ProjectId: project.Id,
+			Name:      project.Name,
+			Location:  project.Location,
+			TeamCode:  project.TeamCode,
+		}
+	}
+
+	// Send OK
+	if generated > 0 {
+		state.Logger.AdminLogf("Generated %d team codes", generated)
+	}
+	ctx.JSON(http.StatusOK, codes)
+}
+
 // DELETE /project/:id - DeleteProject deletes a project from the database
 func DeleteProject(ctx *gin.Context) {
 	// Get the state from the context
@@ -379,8 +758,22 @@ func DeleteProject(ctx *gin.Context) {
 			return err
 		}
 
+		// Remove the project from judges' itineraries
+		err = database.DeleteScheduledProject(state.Db, sc, &projectObjectId)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error removing project from judges' itineraries: " + err.Error()})
+			return err
+		}
+
 		fmt.Println("hello3")
 
+		// Delete all comparisons with this project
+		err = database.DeleteProjectComparisons(state.Db, sc, &projectObjectId)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error deleting comparisons for project: " + err.Error()})
+			return err
+		}
+
 		// Delete all flags for this project
 		err = database.DeleteFlagsCascade(state.Db, sc, &projectObjectId, nil)
 		if err != nil {
@@ -388,6 +781,13 @@ func DeleteProject(ctx *gin.Context) {
 			return err
 		}
 
+		// Delete all conflicts for this project
+		err = database.DeleteConflictsCascade(state.Db, sc, &projectObjectId, nil)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error deleting conflicts for project: " + err.Error()})
+			return err
+		}
+
 		fmt.Println("hello4")
 
 		return nil
@@ -398,6 +798,9 @@ func DeleteProject(ctx *gin.Context) {
 
 	fmt.Println("hello3.5")
 
+	// Remove the project from the comparisons
+	state.Comps.RemoveProjectFromComparison(projectObjectId)
+
 	// Update all judge rankings
 	err = judging.InitAggregateRankings(state.Db)
 	if err != nil {
@@ -739,6 +1142,13 @@ func MoveProjectGroup(ctx *gin.Context) {
 			return err
 		}
 
+		// Update judges' itineraries
+		err = database.UpdateScheduledProjectNumber(state.Db, sc, &id, currMaxNum+1)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "cannot update judges' itineraries: " + err.Error()})
+			return err
+		}
+
 		return nil
 	})
 	if err != nil {
@@ -798,6 +1208,13 @@ func MoveProject(ctx *gin.Context) {
 			return err
 		}
 
+		// Update judges' itineraries
+		err = database.UpdateScheduledProjectNumber(state.Db, sc, &id, moveReq.Location)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "cannot update judges' itineraries: " + err.Error()})
+			return err
+		}
+
 		return nil
 	})
 	if err != nil {
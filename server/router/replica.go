@@ -0,0 +1,136 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"server/database"
+	"server/judging"
+	"server/models"
+	"sync/atomic"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// syncInterval is how often an instance picks up the changes made by the other instances
+const syncInterval = 2 * time.Second
+
+// leaderTimeout is how long the leader can go without renewing its claim before another instance takes over
+const leaderTimeout = 10 * time.Second
+
+// Replica is this instance of the server. When several instances share the database (JURY_MULTI_INSTANCE),
+// the clock, comparisons, and rate limits are kept in the database and synced every few seconds,
+// and one instance is elected leader to do the background work. A single instance is always the leader.
+type Replica struct {
+	Id     string
+	Shared bool
+	leader atomic.Bool
+}
+
+// NewReplica creates the replica for this instance
+func NewReplica(shared bool) *Replica {
+	r := &Replica{
+		Id:     primitive.NewObjectID().Hex(),
+		Shared: shared,
+	}
+	r.leader.Store(!shared)
+	return r
+}
+
+// IsLeader returns true if this instance should do the background work
+func (r *Replica) IsLeader() bool {
+	return r.leader.Load()
+}
+
+// startSync keeps this instance in sync with the others in the background for as long as the server runs.
+// This does nothing if this is the only instance.
+func startSync(state *State) {
+	if !state.Replica.Shared {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(syncInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			syncState(state)
+		}
+	}()
+}
+
+// syncState syncs the shared state once, claims or renews leadership, and cleans up old request counts if leader
+func syncState(state *State) {
+	ctx := context.Background()
+
+	// Options hold the clock and rate limiter settings
+	options, err := database.GetOptions(state.Db, ctx)
+	if err != nil {
+		state.Logger.SystemLogf("Error syncing options: %s", err.Error())
+	} else {
+		syncClock(state, &options.Clock)
+		state.Limiter.Update(int(options.MaxReqPerMin), options.BlockReqs)
+	}
+
+	err = judging.SyncComparisons(state.Db, ctx, state.Comps)
+	if err != nil {
+		state.Logger.SystemLogf("Error syncing comparisons: %s", err.Error())
+	}
+
+	// Become the leader if there is none
+	wasLeader := state.Replica.IsLeader()
+	leader, err := database.ClaimLeader(state.Db, ctx, state.Replica.Id, leaderTimeout)
+	if err != nil {
+		state.Logger.SystemLogf("Error claiming leader: %s", err.Error())
+	}
+	state.Replica.leader.Store(leader)
+	if leader && !wasLeader {
+		state.Logger.SystemLogf("Instance %s is now the leader", state.Replica.Id)
+	}
+
+	// Keep the request counts of the last minute
+	if leader {
+		err = database.DeleteOldRequestCounts(state.Db, ctx, time.Now().Unix()/60-1)
+		if err != nil {
+			state.Logger.SystemLogf("Error deleting old request counts: %s", err.Error())
+		}
+	}
+}
+
+// syncClock replaces the clock with the stored clock if another instance changed it
+func syncClock(state *State, stored *models.ClockState) {
+	state.Clock.Mutex.Lock()
+	defer state.Clock.Mutex.Unlock()
+
+	if stored.Version != state.Clock.State.Version {
+		state.Clock.State = *stored
+	}
+}
+
+// updateClock applies a change to the clock and backs it up. When running multiple instances,
+// the change is made to the stored clock and only saved if no other instance changed it in the meantime.
+// The clock mutex MUST be held by the caller.
+func updateClock(state *State, ctx context.Context, change func(*models.ClockState)) error {
+	if !state.Replica.Shared {
+		change(&state.Clock.State)
+		return database.UpdateClockConditional(state.Db, ctx, &state.Clock.State)
+	}
+
+	// Start from the stored clock, since another instance may have changed it since the last sync
+	options, err := database.GetOptions(state.Db, ctx)
+	if err != nil {
+		return err
+	}
+	clock := options.Clock
+	change(&clock)
+	clock.Version++
+
+	ok, err := database.UpdateClockIfVersion(state.Db, ctx, &clock, options.Clock.Version)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("the clock was changed by another instance at the same time, please try again")
+	}
+	state.Clock.State = clock
+	return nil
+}
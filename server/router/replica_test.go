@@ -0,0 +1,20 @@
+package router
+
+import "testing"
+
+func TestNewReplica(t *testing.T) {
+	// A single instance does all the background work
+	single := NewReplica(false)
+	if !single.IsLeader() {
+		t.Errorf("expected a single instance to be the leader")
+	}
+
+	// Instances sharing the database have to claim leadership first
+	shared := NewReplica(true)
+	if shared.IsLeader() {
+		t.Errorf("expected a shared instance not to be the leader until it claims it")
+	}
+	if shared.Id == "" || shared.Id == single.Id {
+		t.Errorf("expected every instance to have its own ID")
+	}
+}
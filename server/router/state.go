@@ -15,15 +15,19 @@ type State struct {
 	Comps   *judging.Comparisons
 	Logger  *logging.Logger
 	Limiter *Limiter
+	Teams   *Limiter // Limits the invalid team codes each IP can try (see getTeamProject)
+	Replica *Replica
 }
 
-func NewState(db *mongo.Database, clock *models.SafeClock, comps *judging.Comparisons, logger *logging.Logger, limiter *Limiter) *State {
+func NewState(db *mongo.Database, clock *models.SafeClock, comps *judging.Comparisons, logger *logging.Logger, limiter *Limiter, teams *Limiter, replica *Replica) *State {
 	return &State{
 		Db:      db,
 		Clock:   clock,
 		Comps:   comps,
 		Logger:  logger,
 		Limiter: limiter,
+		Teams:   teams,
+		Replica: replica,
 	}
 }
 
@@ -0,0 +1,192 @@
+package router
+
+import (
+	"net/http"
+	"server/database"
+	"server/judging"
+	"server/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type TeamCodeRequest struct {
+	Code string `json:"code"`
+}
+
+type TeamProject struct {
+	Name     string              `json:"name"`
+	Location int64               `json:"location"`
+	Away     []models.AwayWindow `json:"away"`
+}
+
+// maxTeamCodeFailures is the number of invalid team codes an IP can try per minute,
+// so that team codes can't be guessed
+const maxTeamCodeFailures = 10
+
+// getTeamProject finds the project with the team code, sending an error and returning nil if there isn't one
+func getTeamProject(ctx *gin.Context, state *State, code string) *models.Project {
+	if code == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "team code is required"})
+		return nil
+	}
+
+	// Stop IPs that have tried too many invalid codes
+	key := "team-code|" + ctx.ClientIP()
+	exceeded, err := state.Teams.Exceeded(key)
+	if err != nil {
+		ctx.JSON(http.StatusServiceUnavailable, gin.H{"error": "error checking team code attempts: " + err.Error()})
+		return nil
+	}
+	if exceeded {
+		ctx.JSON(http.StatusTooManyRequests, gin.H{"error": "too many invalid team codes, try again in a minute"})
+		return nil
+	}
+
+	project, err := database.FindProjectByTeamCode(state.Db, ctx, code)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error finding project: " + err.Error()})
+		return nil
+	}
+	if project == nil {
+		_, err = state.Teams.CheckNewRequest(key)
+		if err != nil {
+			state.Logger.SystemLogf("Error counting invalid team code from %s: %s", ctx.ClientIP(), err.Error())
+		}
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "invalid team code"})
+		return nil
+	}
+	return project
+}
+
+// POST /team/project - GetTeamProject gets the project of a team from their team code
+func GetTeamProject(ctx *gin.Context) {
+	// Get the state from the context
+	state := GetState(ctx)
+
+	// Get the request object
+	var codeReq TeamCodeRequest
+	err := ctx.BindJSON(&codeReq)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "error reading request body: " + err.Error()})
+		return
+	}
+
+	// Get the project
+	project := getTeamProject(ctx, state, codeReq.Code)
+	if project == nil {
+		return
+	}
+
+	// Send OK
+	ctx.JSON(http.StatusOK, TeamProject{
+		Name:     project.Name,
+		Location: project.Location,
+		Away:     project.Away,
+	})
+}
+
+type SetTeamAwayRequest struct {
+	Code string              `json:"code"`
+	Away []models.AwayWindow `json:"away"`
+}
+
+// PUT /team/away - SetTeamAway lets a team set the times they are away from their table.
+// This only replaces the windows the team set themselves; windows set by admins are kept.
+func SetTeamAway(ctx *gin.Context) {
+	// Get the state from the context
+	state := GetState(ctx)
+
+	// Get the request object
+	var awayReq SetTeamAwayRequest
+	err := ctx.BindJSON(&awayReq)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "error reading request body: " + err.Error()})
+		return
+	}
+
+	// Get the project
+	project := getTeamProject(ctx, state, awayReq.Code)
+	if project == nil {
+		return
+	}
+
+	// Keep the admin windows and replace the team's own
+	away := []models.AwayWindow{}
+	for _, w := range project.Away {
+		if w.Source != "team" {
+			away = append(away, w)
+		}
+	}
+	for _, w := range awayReq.Away {
+		w.Source = "team"
+		away = append(away, w)
+	}
+	err = models.ValidateAwayWindows(away)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Set the away windows
+	err = database.SetProjectAway(state.Db, ctx, &project.Id, away)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error setting project away windows: " + err.Error()})
+		return
+	}
+
+	// Send OK
+	state.Logger.SystemLogf("Team of project %s set %d away windows", project.Id.Hex(), len(awayReq.Away))
+	ctx.JSON(http.StatusOK, gin.H{"ok": 1})
+}
+
+// POST /team/back - TeamBack lets a team mark their project as back after judges found them absent.
+// This is only allowed if the project has absences that count or was hidden for being absent,
+// so teams can't use it to unhide a project hidden by an admin or to keep getting prioritized.
+func TeamBack(ctx *gin.Context) {
+	// Get the state from the context
+	state := GetState(ctx)
+
+	// Get the request object
+	var codeReq TeamCodeRequest
+	err := ctx.BindJSON(&codeReq)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "error reading request body: " + err.Error()})
+		return
+	}
+
+	// Get the project
+	project := getTeamProject(ctx, state, codeReq.Code)
+	if project == nil {
+		return
+	}
+
+	// Make sure the project was actually absent
+	options, err := database.GetOptions(state.Db, ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error getting options: " + err.Error()})
+		return
+	}
+	absences, err := getProjectAbsences(ctx, state, project, options)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error getting absences: " + err.Error()})
+		return
+	}
+	if !absences.Hidden && (!project.Active || absences.Absent == 0) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "project has not been marked absent"})
+		return
+	}
+
+	// Mark the project as back
+	err = database.WithTransaction(state.Db, func(sc mongo.SessionContext) error {
+		return judging.MarkProjectBack(state.Db, sc, project, "team")
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Send OK
+	state.Logger.SystemLogf("Team of project %s marked their project as back", project.Id.Hex())
+	ctx.JSON(http.StatusOK, gin.H{"ok": 1})
+}
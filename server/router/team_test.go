@@ -0,0 +1,36 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestGetTeamProjectLimits(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	state := &State{Teams: CreateLimiter(maxTeamCodeFailures, false)}
+	lookup := func(code string) int {
+		w := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(w)
+		ctx.Request = httptest.NewRequest("POST", "/api/team/project", nil)
+		ctx.Request.RemoteAddr = "1.1.1.1:1234"
+		if project := getTeamProject(ctx, state, code); project != nil {
+			t.Fatalf("expected no project to be found")
+		}
+		return w.Code
+	}
+
+	if code := lookup(""); code != http.StatusBadRequest {
+		t.Errorf("expected a missing team code to be rejected, got %d", code)
+	}
+
+	// IPs that tried too many invalid codes are stopped before the code is looked up
+	for i := 0; i < maxTeamCodeFailures; i++ {
+		state.Teams.CheckNewRequest("team-code|1.1.1.1")
+	}
+	if code := lookup("ABCDEFGHJK"); code != http.StatusTooManyRequests {
+		t.Errorf("expected too many invalid team codes to be rate limited, got %d", code)
+	}
+}
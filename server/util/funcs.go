@@ -6,6 +6,7 @@ import (
 	"math/big"
 	"reflect"
 	"server/models"
+	"slices"
 	"strings"
 	"time"
 
@@ -81,6 +82,57 @@ func RankingToString(rankings []primitive.ObjectID) string {
 	return sb.String()
 }
 
+// GetRankingTiers returns the judge's rankings grouped into tiers of tied projects.
+// Judges that ranked before tiers existed (or whose tiers are out of sync with their
+// rankings) get one tier per ranked project.
+func GetRankingTiers(judge *models.Judge) [][]primitive.ObjectID {
+	flat := FlattenTiers(judge.RankingTiers)
+	if len(flat) == len(judge.Rankings) && slices.Equal(flat, judge.Rankings) {
+		tiers := make([][]primitive.ObjectID, 0, len(judge.RankingTiers))
+		for _, tier := range judge.RankingTiers {
+			if len(tier) > 0 {
+				tiers = append(tiers, tier)
+			}
+		}
+		return tiers
+	}
+
+	tiers := make([][]primitive.ObjectID, 0, len(judge.Rankings))
+	for _, id := range judge.Rankings {
+		tiers = append(tiers, []primitive.ObjectID{id})
+	}
+	return tiers
+}
+
+// FlattenTiers converts a list of ranking tiers to a single ordered ranking
+func FlattenTiers(tiers [][]primitive.ObjectID) []primitive.ObjectID {
+	out := []primitive.ObjectID{}
+	for _, tier := range tiers {
+		out = append(out, tier...)
+	}
+	return out
+}
+
+// TiersToString converts a list of ranking tiers to a string, with tied projects joined by "="
+func TiersToString(tiers [][]primitive.ObjectID) string {
+	sb := strings.Builder{}
+	sb.WriteString("[")
+	for i, tier := range tiers {
+		if i != 0 {
+			sb.WriteString(", ")
+		}
+		for j, id := range tier {
+			if j != 0 {
+				sb.WriteString(" = ")
+			}
+			sb.WriteString(id.Hex())
+		}
+	}
+	sb.WriteString("]")
+
+	return sb.String()
+}
+
 // StructToString converts a struct to a string
 func StructToString(s interface{}) string {
 	sb := strings.Builder{}
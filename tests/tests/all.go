@@ -20,6 +20,10 @@ func RunTests(context *src.Context) {
 				"Valid Admin Auth":     AdminAuth,
 				"Add Judge":            AddJudge,
 				"Get Clock":            GetClock,
+				"Invalid Team Code":    InvalidTeamCode,
+				"Team Code Attempts":   TeamCodeAttempts,
+				"Invalid Heartbeat":    InvalidJudgeHeartbeat,
+				"Judge Heartbeat":      JudgeHeartbeat,
 			},
 		},
 	}
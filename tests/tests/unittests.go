@@ -1,7 +1,12 @@
 package tests
 
 import (
+	ctx "context"
+	"strings"
 	"tests/src"
+
+	"github.com/valyala/fastjson"
+	"go.mongodb.org/mongo-driver/bson"
 )
 
 func Heartbeat(context *src.Context) src.Result {
@@ -42,3 +47,53 @@ func GetClock(context *src.Context) src.Result {
 	}
 	return src.ResultOk()
 }
+
+func InvalidTeamCode(context *src.Context) src.Result {
+	res := src.PostRequest(context.Logger, "/team/project", src.H{"code": "NOTACODE"}, src.DefaultAuth())
+	return src.AssertNotOk(res, "Invalid team code should not be successful")
+}
+
+func TeamCodeAttempts(context *src.Context) src.Result {
+	// Use up the invalid team codes this IP can try in a minute
+	var res string
+	for i := 0; i <= 10; i++ {
+		res = src.PostRequest(context.Logger, "/team/project", src.H{"code": "NOTACODE"}, src.DefaultAuth())
+	}
+	if !strings.Contains(res, "too many invalid team codes") && !strings.Contains(res, "Too many requests") {
+		return src.NewResult(false, "Too many invalid team codes should be rate limited")
+	}
+	return src.ResultOk()
+}
+
+func InvalidJudgeHeartbeat(context *src.Context) src.Result {
+	res := src.PostRequest(context.Logger, "/judge/heartbeat", nil, src.DefaultAuth())
+	return src.AssertNotOk(res, "Heartbeat without a judge token should not be successful")
+}
+
+func JudgeHeartbeat(context *src.Context) src.Result {
+	res := src.PostRequest(context.Logger, "/judge/new", src.H{"name": "Heartbeat Judge", "email": "heartbeat@gmail.com", "track": "", "notes": "", "no_send": true}, src.AdminAuth())
+	if !src.IsOk(res) {
+		return src.NewResult(false, "Error adding a judge")
+	}
+
+	// Log in with the judge's code from the database
+	var judge struct {
+		Code string `bson:"code"`
+	}
+	err := context.Db.Collection("judges").FindOne(ctx.Background(), bson.M{"name": "Heartbeat Judge"}).Decode(&judge)
+	if err != nil {
+		return src.NewResult(false, "Error finding the judge in the database: "+err.Error())
+	}
+	res = src.PostRequest(context.Logger, "/judge/login", src.H{"code": judge.Code}, src.DefaultAuth())
+	token := fastjson.GetString([]byte(res), "token")
+	if token == "" {
+		return src.NewResult(false, "Error logging in as the judge")
+	}
+
+	// The judge doesn't have a project yet, so there is no lease to renew
+	res = src.PostRequest(context.Logger, "/judge/heartbeat", nil, src.JudgeAuth(token))
+	if !src.IsOk(res) || !src.IsValue(res, "current", src.BoolType, false) {
+		return src.NewResult(false, "Error sending a heartbeat")
+	}
+	return src.ResultOk()
+}